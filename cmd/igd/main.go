@@ -34,6 +34,7 @@ import (
 	"github.com/inspektor-gadget/ig-desktop/internal/config"
 	"github.com/inspektor-gadget/ig-desktop/internal/environment"
 	"github.com/inspektor-gadget/ig-desktop/internal/server"
+	"github.com/inspektor-gadget/ig-desktop/pkg/metrics"
 )
 
 type singleEnvConfig struct {
@@ -45,6 +46,10 @@ func main() {
 	listenAddr := flag.String("listen", ":8080", "Address to listen on (e.g., :8080 or 127.0.0.1:8080)")
 	assetsDir := flag.String("assets", "", "Override the embedded frontend with a build directory")
 	configFile := flag.String("config", "", "Single-environment JSON configuration")
+	metricsFile := flag.String("metrics", "", "JSON file with metric definitions; enables the /metrics endpoint")
+	allowSinks := flag.Bool("allow-sinks", false, "Allow clients to write gadget output to files on this host and send it to webhook, OTLP and syslog destinations")
+	allowHostKubeconfig := flag.Bool("allow-host-kubeconfig", false, "Allow environments to load kubeconfig files of this host, inline kubeconfigs or the in-cluster config")
+	allowHostSSHKeys := flag.Bool("allow-host-ssh-keys", false, "Allow SSH environments to use key files, the SSH agent and known hosts files of this host, and to skip host key checking")
 	flag.Parse()
 
 	var frontendFS fs.FS
@@ -74,10 +79,26 @@ func main() {
 		}
 	}
 
+	var registry *metrics.Registry
+	if *metricsFile != "" {
+		defs, err := metrics.LoadDefinitions(*metricsFile)
+		if err != nil {
+			log.Fatalf("loading metric definitions: %v", err)
+		}
+		registry, err = metrics.NewRegistry(defs)
+		if err != nil {
+			log.Fatalf("invalid metric definitions: %v", err)
+		}
+	}
+
 	srv := server.New(server.Config{
-		ListenAddr:      *listenAddr,
-		Assets:          frontendFS,
-		SingleEnvConfig: frontendConfig,
+		ListenAddr:          *listenAddr,
+		Assets:              frontendFS,
+		SingleEnvConfig:     frontendConfig,
+		Metrics:             registry,
+		AllowSinks:          *allowSinks,
+		AllowHostKubeconfig: *allowHostKubeconfig,
+		AllowHostSSHKeys:    *allowHostSSHKeys,
 	})
 
 	// Handle shutdown signals
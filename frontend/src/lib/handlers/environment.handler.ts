@@ -13,6 +13,18 @@ export function handleEnvironmentCreate(msg: { data?: Environment }): void {
 	environments[msg.data.id] = msg.data;
 }
 
+/**
+ * Handle environment update (type 102).
+ * Replaces an existing environment in the environments store.
+ */
+export function handleEnvironmentUpdate(msg: { data?: Environment }): void {
+	if (!msg.data?.id) {
+		console.warn('handleEnvironmentUpdate: missing data.id', msg);
+		return;
+	}
+	environments[msg.data.id] = msg.data;
+}
+
 /**
  * Handle environment deletion (type 101).
  * Removes an environment from the environments store.
@@ -1,4 +1,5 @@
 import { instances } from '$lib/shared/instances.svelte';
+import { environments } from '$lib/shared/environments.svelte';
 import { currentSessionStore } from '$lib/stores/current-session.svelte';
 import { configuration } from '$lib/stores/configuration.svelte';
 import { EventRingBuffer } from '$lib/utils/ring-buffer';
@@ -7,7 +8,10 @@ import type {
 	GadgetEventMessage,
 	GadgetLogMessage,
 	GadgetQuitMessage,
-	GadgetArrayDataMessage
+	GadgetArrayDataMessage,
+	GadgetReconnectMessage,
+	GadgetAlertMessage,
+	GadgetResultMessage
 } from '$lib/types';
 import { pluginRegistry } from '$lib/services/plugin-registry.service.svelte';
 import {
@@ -19,6 +23,9 @@ import {
 import { PLUGIN_CAPABILITIES } from '$lib/types/plugin-system';
 import type { Datasource, DatasourceField } from '$lib/types/charts';
 import type { GadgetDatasource } from '$lib/types';
+import { t } from '$lib/i18n/index.svelte';
+import { toastStore } from '$lib/stores/toast.svelte';
+import { formatAbsoluteTime } from '$lib/utils/time';
 
 /**
  * Convert GadgetDatasource to Datasource type for processor context.
@@ -377,6 +384,17 @@ export function getArraySnapshots(instanceID: string, datasourceID: string): Arr
 export function handleGadgetInfo(msg: GadgetInfoMessage): void {
 	const sessionInfo = msg.sessionInfo;
 
+	// Runs in several environments share the instance and each send their info; keep what
+	// was received so far and only add the environment
+	const existing = instances[msg.instanceID];
+	if (existing?.running && msg.environmentID && existing.environment !== msg.environmentID) {
+		const envs = existing.environments ?? [existing.environment];
+		if (!envs.includes(msg.environmentID)) {
+			existing.environments = [...envs, msg.environmentID];
+		}
+		return;
+	}
+
 	instances[msg.instanceID] = {
 		name: msg.instanceName || msg.data?.imageName || 'Unknown Gadget',
 		running: true,
@@ -428,11 +446,71 @@ export function handleGadgetQuit(msg: GadgetQuitMessage): void {
 	if (instances[msg.instanceID]) {
 		instances[msg.instanceID].running = false;
 
+		// Report the environments of a multi-environment instance that failed
+		for (const [envID, res] of Object.entries(msg.data?.environments ?? {})) {
+			if (res.error) {
+				instances[msg.instanceID].logs.push({
+					msg: `${environments[envID]?.name ?? envID}: ${res.error}`,
+					severity: 'error'
+				});
+			}
+		}
+
 		// Cleanup data processors for this instance
 		cleanupProcessors(msg.instanceID);
 	}
 }
 
+/**
+ * Handle reconnect markers (type 7).
+ * Adds a warning to the instance's logs, as events sent while disconnected are lost.
+ */
+export function handleGadgetReconnect(msg: GadgetReconnectMessage): void {
+	if (instances[msg.instanceID]) {
+		instances[msg.instanceID].logs.push({
+			msg: t('Reconnected, {{seconds}} seconds of events may be missing', {
+				seconds: msg.data.missingSeconds
+			}),
+			severity: 'warning',
+			timestamp: msg.data.timestamp,
+			node: msg.data.node || undefined,
+			source: 'runtime'
+		});
+	}
+}
+
+/**
+ * Handle alerts (type 8).
+ * Adds a warning to the instance's logs and, unless replaying, shows a toast.
+ */
+export function handleGadgetAlert(msg: GadgetAlertMessage, notify = true): void {
+	const alert = msg.data;
+	const text = t('Alert "{{rule}}" matched {{count}} events of {{image}}', {
+		rule: alert.ruleName,
+		count: alert.count,
+		image: alert.image
+	});
+	if (instances[msg.instanceID]) {
+		instances[msg.instanceID].logs.push({
+			msg: text,
+			severity: 'warning',
+			timestamp: formatAbsoluteTime(alert.timestamp)
+		});
+	}
+	if (notify) {
+		toastStore.warning(text);
+	}
+}
+
+/**
+ * Handle the results of a finished run (type 9).
+ */
+export function handleGadgetResult(msg: GadgetResultMessage): void {
+	if (instances[msg.instanceID]) {
+		instances[msg.instanceID].results = msg.data.results;
+	}
+}
+
 /**
  * Handle bulk gadget event data (type 6).
  * Processes an array of events at once instead of individually.
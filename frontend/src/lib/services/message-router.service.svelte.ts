@@ -4,11 +4,15 @@ import {
 	handleGadgetEvent,
 	handleGadgetLogging,
 	handleGadgetQuit,
-	handleGadgetArrayData
+	handleGadgetArrayData,
+	handleGadgetReconnect,
+	handleGadgetAlert,
+	handleGadgetResult
 } from '$lib/handlers/gadget.handler.svelte';
 import {
 	handleEnvironmentCreate,
-	handleEnvironmentDelete
+	handleEnvironmentDelete,
+	handleEnvironmentUpdate
 } from '$lib/handlers/environment.handler';
 import {
 	handleDeploymentProgress,
@@ -60,6 +64,18 @@ export class MessageRouterService {
 				handleGadgetArrayData(msg);
 				break;
 
+			case 7: // Reconnect
+				handleGadgetReconnect(msg);
+				break;
+
+			case 8: // Alert
+				handleGadgetAlert(msg);
+				break;
+
+			case 9: // Result
+				handleGadgetResult(msg);
+				break;
+
 			case 100: // Environment create
 				handleEnvironmentCreate(msg);
 				break;
@@ -68,6 +84,10 @@ export class MessageRouterService {
 				handleEnvironmentDelete(msg);
 				break;
 
+			case 102: // Environment update
+				handleEnvironmentUpdate(msg);
+				break;
+
 			case 200: // Deployment progress
 				handleDeploymentProgress(msg);
 				break;
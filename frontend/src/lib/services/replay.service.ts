@@ -1,19 +1,28 @@
 import {
 	handleGadgetEvent,
 	handleGadgetLogging,
-	handleGadgetArrayData
+	handleGadgetArrayData,
+	handleGadgetReconnect,
+	handleGadgetAlert,
+	handleGadgetResult
 } from '$lib/handlers/gadget.handler.svelte';
 import type {
 	RecordedEvent,
 	GadgetEventMessage,
 	GadgetLogMessage,
-	GadgetArrayDataMessage
+	GadgetArrayDataMessage,
+	GadgetReconnectMessage,
+	GadgetAlertMessage,
+	GadgetResultMessage
 } from '$lib/types';
 
 // Event type constants (from internal/api/constants.go)
 const TypeGadgetEvent = 3;
 const TypeGadgetLog = 4;
 const TypeGadgetEventArray = 6;
+const TypeGadgetReconnect = 7;
+const TypeGadgetAlert = 8;
+const TypeGadgetResult = 9;
 
 export interface ReplayOptions {
 	instanceId: string;
@@ -124,6 +133,27 @@ export class ReplayService {
 					data: event.data as Record<string, unknown>[]
 				} as GadgetArrayDataMessage);
 				break;
+			case TypeGadgetReconnect:
+				handleGadgetReconnect({
+					instanceID: instanceId,
+					data: event.data
+				} as GadgetReconnectMessage);
+				break;
+			case TypeGadgetAlert:
+				handleGadgetAlert(
+					{
+						instanceID: instanceId,
+						data: event.data
+					} as GadgetAlertMessage,
+					false
+				);
+				break;
+			case TypeGadgetResult:
+				handleGadgetResult({
+					instanceID: instanceId,
+					data: event.data
+				} as GadgetResultMessage);
+				break;
 		}
 	}
 }
@@ -51,6 +51,8 @@ class CurrentEnvironmentState {
 
 		if (this.environment.runtime === 'grpc-ig') {
 			runtime = this.environment.params?.['remote-address'] || t('Remote');
+		} else if (this.environment.runtime === 'grpc-ssh') {
+			runtime = this.environment.params?.['ssh-hosts'] || t('SSH');
 		} else {
 			// For Kubernetes, include context if available
 			const context = this.environment.params?.['context'];
@@ -75,7 +77,7 @@ class CurrentEnvironmentState {
 		if (!this.environment) {
 			return false;
 		}
-		// Kubernetes connections are always secure
+		// Kubernetes and SSH connections are always secure
 		if (this.environment.runtime !== 'grpc-ig') {
 			return true;
 		}
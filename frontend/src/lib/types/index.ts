@@ -10,6 +10,9 @@ export interface Environment {
 	name: string;
 	runtime: string;
 	params?: Record<string, string>;
+	group?: string;
+	labels?: Record<string, string>;
+	defaultParams?: Record<string, string>;
 }
 
 export interface Environments {
@@ -111,6 +114,10 @@ export interface LogEntry {
 	severity: number | string;
 	timestamp?: string;
 	msgID?: string;
+	/** Node the message concerns, if it isn't about the run as a whole */
+	node?: string;
+	/** "gadget" or "runtime" for node messages */
+	source?: string;
 }
 
 export interface GadgetInstanceData {
@@ -120,10 +127,14 @@ export interface GadgetInstanceData {
 	events: EventRingBuffer<Record<string, unknown>>;
 	logs: LogEntry[];
 	environment: string;
+	/** All environments of a run in several environments at once, which share the instance */
+	environments?: string[];
 	startTime: number;
 	eventCount: number;
 	session?: SessionInfo;
 	attached?: boolean;
+	/** Final output of the run per node, once it has finished */
+	results?: Record<string, GadgetResult>;
 	[key: string]: unknown;
 }
 
@@ -232,10 +243,22 @@ export interface GadgetLogMessage extends GadgetMessageBase {
 }
 
 /**
- * Message for gadget quit (type 5)
+ * Outcome of a run in one environment of a multi-environment instance
+ */
+export interface EnvironmentRunResult {
+	error?: string;
+	limit?: string;
+	value?: number;
+}
+
+/**
+ * Message for gadget quit (type 5). Multi-environment instances send a single one once all
+ * environments are done, without environmentID and with the outcome per environment.
  */
 export interface GadgetQuitMessage {
 	instanceID: string;
+	environmentID?: string;
+	data?: { environments?: Record<string, EnvironmentRunResult> };
 }
 
 /**
@@ -244,3 +267,61 @@ export interface GadgetQuitMessage {
 export interface GadgetArrayDataMessage extends GadgetMessageBase {
 	data: Record<string, unknown>[];
 }
+
+/**
+ * Marker sent when the connection to a target was re-established
+ */
+export interface ReconnectMarker {
+	node: string;
+	missingSeconds: number;
+	msg: string;
+	timestamp: string;
+}
+
+/**
+ * Message for reconnect markers (type 7)
+ */
+export interface GadgetReconnectMessage extends GadgetMessageBase {
+	data: ReconnectMarker;
+}
+
+/**
+ * Alert raised by an alert rule matching the events of a run
+ */
+export interface GadgetAlert {
+	id: string;
+	ruleID: string;
+	ruleName: string;
+	instanceID: string;
+	environmentID?: string;
+	image: string;
+	datasource: string;
+	count: number;
+	timestamp: number;
+	event?: Record<string, unknown>;
+	sessionId?: string;
+}
+
+/**
+ * Message for alerts (type 8)
+ */
+export interface GadgetAlertMessage extends GadgetMessageBase {
+	data: GadgetAlert;
+}
+
+/**
+ * Final output of a gadget on a single node
+ */
+export interface GadgetResult {
+	payload?: string;
+	/** "base64" if the payload isn't valid UTF-8 */
+	encoding?: string;
+	error?: string;
+}
+
+/**
+ * Message for the results of a finished run (type 9), keyed by node
+ */
+export interface GadgetResultMessage extends GadgetMessageBase {
+	data: { results: Record<string, GadgetResult> };
+}
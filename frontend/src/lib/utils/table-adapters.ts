@@ -98,8 +98,11 @@ function shouldHideColumn(field: DatasourceField, env?: Environment): boolean {
 		if (field.flags & 0x0001) return true; // empty flag
 	}
 
-	// Runtime-specific: hide kubernetes fields in grpc-ig runtime
-	if (env?.runtime === 'grpc-ig' && field.tags?.includes('kubernetes')) {
+	// Runtime-specific: hide kubernetes fields in daemon runtimes
+	if (
+		(env?.runtime === 'grpc-ig' || env?.runtime === 'grpc-ssh') &&
+		field.tags?.includes('kubernetes')
+	) {
 		return true;
 	}
 
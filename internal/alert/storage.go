@@ -0,0 +1,223 @@
+// Copyright 2026 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package alert persists alert rules and the alerts they raised. The rules
+// themselves are evaluated by the gadget service on live events.
+package alert
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/google/uuid"
+
+	"github.com/inspektor-gadget/ig-desktop/pkg/api"
+	"github.com/inspektor-gadget/ig-desktop/pkg/gadget"
+)
+
+// DefaultMaxAlertsFileSize is the size in bytes after which the alerts file is rotated. Only the
+// previous file is kept, so raised alerts take up at most about twice this size.
+const DefaultMaxAlertsFileSize = 8 << 20
+
+// Storage keeps alert rules as one JSON file per rule and appends raised
+// alerts to a JSON lines file. It implements gadget.AlertStore.
+type Storage struct {
+	rulesDir   string
+	alertsFile string
+	maxSize    int64
+
+	mu sync.Mutex
+}
+
+// NewStorage creates a new Storage below the given directory
+func NewStorage(dir string) (*Storage, error) {
+	rulesDir := filepath.Join(dir, "rules")
+	if err := os.MkdirAll(rulesDir, 0o755); err != nil {
+		return nil, fmt.Errorf("creating rules directory: %w", err)
+	}
+	return &Storage{
+		rulesDir:   rulesDir,
+		alertsFile: filepath.Join(dir, "alerts.jsonl"),
+		maxSize:    DefaultMaxAlertsFileSize,
+	}, nil
+}
+
+// AddRule creates a new rule and persists it to disk
+func (s *Storage) AddRule(rule *gadget.AlertRule) error {
+	rule.ID = uuid.New().String()
+	return s.SetRule(rule)
+}
+
+// SetRule persists a rule with its existing ID.
+func (s *Storage) SetRule(rule *gadget.AlertRule) error {
+	if err := uuid.Validate(rule.ID); err != nil {
+		return &api.ErrInvalidRequest{Reason: fmt.Sprintf("invalid alert rule ID: %s", rule.ID)}
+	}
+	filename := filepath.Join(s.rulesDir, rule.ID+".json")
+	d, _ := json.Marshal(rule)
+	return os.WriteFile(filename, d, 0o644)
+}
+
+// GetRule retrieves a single rule by ID
+func (s *Storage) GetRule(id string) (*gadget.AlertRule, error) {
+	if err := uuid.Validate(id); err != nil {
+		return nil, &api.ErrInvalidRequest{Reason: fmt.Sprintf("invalid alert rule ID: %s", id)}
+	}
+	b, err := os.ReadFile(filepath.Join(s.rulesDir, id+".json"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, &api.ErrAlertRuleNotFound{ID: id}
+		}
+		return nil, fmt.Errorf("reading alert rule file: %w", err)
+	}
+	var rule gadget.AlertRule
+	err = json.Unmarshal(b, &rule)
+	if err != nil {
+		return nil, fmt.Errorf("parsing alert rule file: %w", err)
+	}
+	return &rule, nil
+}
+
+// DeleteRule removes a rule from disk
+func (s *Storage) DeleteRule(id string) error {
+	if err := uuid.Validate(id); err != nil {
+		return &api.ErrInvalidRequest{Reason: fmt.Sprintf("invalid alert rule ID: %s", id)}
+	}
+	err := os.Remove(filepath.Join(s.rulesDir, id+".json"))
+	if os.IsNotExist(err) {
+		return &api.ErrAlertRuleNotFound{ID: id}
+	}
+	return err
+}
+
+// AlertRules returns all persisted rules
+func (s *Storage) AlertRules() ([]*gadget.AlertRule, error) {
+	files, err := os.ReadDir(s.rulesDir)
+	if err != nil {
+		return nil, err
+	}
+	rules := make([]*gadget.AlertRule, 0, len(files))
+	for _, file := range files {
+		b, err := os.ReadFile(filepath.Join(s.rulesDir, file.Name()))
+		if err != nil {
+			continue
+		}
+		var rule gadget.AlertRule
+		err = json.Unmarshal(b, &rule)
+		if err != nil {
+			// Skip invalid files
+			continue
+		}
+		rules = append(rules, &rule)
+	}
+	return rules, nil
+}
+
+// rotatedFile is the previous alerts file
+func (s *Storage) rotatedFile() string {
+	return s.alertsFile + ".1"
+}
+
+// AddAlert appends a raised alert, rotating the alerts file if it would grow beyond its maximum size
+func (s *Storage) AddAlert(alert *gadget.Alert) error {
+	d, err := json.Marshal(alert)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if fi, err := os.Stat(s.alertsFile); err == nil && fi.Size() > 0 && fi.Size()+int64(len(d))+1 > s.maxSize {
+		if err := os.Rename(s.alertsFile, s.rotatedFile()); err != nil {
+			return fmt.Errorf("rotating alerts file: %w", err)
+		}
+	}
+
+	f, err := os.OpenFile(s.alertsFile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("opening alerts file: %w", err)
+	}
+	defer f.Close()
+	_, err = f.Write(append(d, '\n'))
+	return err
+}
+
+// readAlerts returns the content of the rotated and the current alerts file
+func (s *Storage) readAlerts() ([]byte, error) {
+	var res []byte
+	for _, file := range []string{s.rotatedFile(), s.alertsFile} {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		res = append(res, b...)
+	}
+	return res, nil
+}
+
+// Alerts returns the raised alerts, newest first, optionally filtered by rule.
+// At most limit alerts are returned if limit is positive.
+func (s *Storage) Alerts(ruleID string, limit int) ([]*gadget.Alert, error) {
+	s.mu.Lock()
+	b, err := s.readAlerts()
+	s.mu.Unlock()
+	if err != nil {
+		return nil, fmt.Errorf("reading alerts file: %w", err)
+	}
+
+	var alerts []*gadget.Alert
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var alert gadget.Alert
+		if err := json.Unmarshal(scanner.Bytes(), &alert); err != nil {
+			// Skip invalid lines
+			continue
+		}
+		if ruleID != "" && alert.RuleID != ruleID {
+			continue
+		}
+		alerts = append(alerts, &alert)
+	}
+
+	res := make([]*gadget.Alert, 0, len(alerts))
+	for i := len(alerts) - 1; i >= 0; i-- {
+		if limit > 0 && len(res) >= limit {
+			break
+		}
+		res = append(res, alerts[i])
+	}
+	return res, nil
+}
+
+// ClearAlerts removes all raised alerts
+func (s *Storage) ClearAlerts() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, file := range []string{s.rotatedFile(), s.alertsFile} {
+		if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
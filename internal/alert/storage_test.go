@@ -0,0 +1,102 @@
+// Copyright 2026 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package alert
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/inspektor-gadget/ig-desktop/pkg/gadget"
+)
+
+func TestAlertsRotation(t *testing.T) {
+	s, err := NewStorage(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.maxSize = 400
+
+	for i := range 20 {
+		if err := s.AddAlert(&gadget.Alert{ID: fmt.Sprint(i), RuleID: "rule"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, file := range []string{s.alertsFile, s.rotatedFile()} {
+		fi, err := os.Stat(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fi.Size() > s.maxSize {
+			t.Errorf("%s has %d bytes, expected at most %d", file, fi.Size(), s.maxSize)
+		}
+	}
+
+	alerts, err := s.Alerts("", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(alerts) == 0 || len(alerts) >= 20 {
+		t.Fatalf("expected old alerts to be dropped, got %d alerts", len(alerts))
+	}
+	for i, alert := range alerts {
+		if expected := fmt.Sprint(19 - i); alert.ID != expected {
+			t.Fatalf("expected alert %s at %d, got %s", expected, i, alert.ID)
+		}
+	}
+
+	if alerts, err := s.Alerts("rule", 2); err != nil || len(alerts) != 2 || alerts[0].ID != "19" {
+		t.Errorf("unexpected limited alerts %v: %v", alerts, err)
+	}
+
+	if err := s.ClearAlerts(); err != nil {
+		t.Fatal(err)
+	}
+	if alerts, err := s.Alerts("", 0); err != nil || len(alerts) != 0 {
+		t.Errorf("expected no alerts after clearing, got %v: %v", alerts, err)
+	}
+}
+
+func TestAlertRules(t *testing.T) {
+	s, err := NewStorage(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rule := &gadget.AlertRule{Name: "nc", Expression: `comm == "nc"`, Threshold: 2}
+	if err := s.AddRule(rule); err != nil {
+		t.Fatal(err)
+	}
+	got, err := s.GetRule(rule.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *got != *rule {
+		t.Errorf("expected %+v, got %+v", rule, got)
+	}
+	if rules, err := s.AlertRules(); err != nil || len(rules) != 1 {
+		t.Errorf("expected one rule, got %v: %v", rules, err)
+	}
+
+	if err := s.DeleteRule(rule.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.GetRule(rule.ID); err == nil {
+		t.Error("expected deleted rule to be gone")
+	}
+	if _, err := s.GetRule("../rules"); err == nil {
+		t.Error("expected invalid ID to be rejected")
+	}
+}
@@ -0,0 +1,174 @@
+// Copyright 2026 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/inspektor-gadget/ig-desktop/pkg/api"
+	"github.com/inspektor-gadget/ig-desktop/pkg/gadget"
+)
+
+func (h *Handler) validateAlertRule(rule *gadget.AlertRule) error {
+	if rule.Expression == "" {
+		return &api.ErrInvalidRequest{Reason: "expression is required"}
+	}
+	if rule.Threshold < 0 || rule.Window < 0 || rule.Cooldown < 0 {
+		return &api.ErrInvalidRequest{Reason: "threshold, window and cooldown must not be negative"}
+	}
+	if err := h.gadgetService.CheckAlertRule(rule); err != nil {
+		return &api.ErrInvalidRequest{Reason: err.Error()}
+	}
+	if rule.Name == "" {
+		rule.Name = rule.Expression
+	}
+	return nil
+}
+
+// HandleListAlertRules returns all alert rules
+func (h *Handler) HandleListAlertRules(ev *api.Event) {
+	if h.alertStorage == nil {
+		h.send(ev.SetError(fmt.Errorf("alerting not available")))
+		return
+	}
+
+	rules, err := h.alertStorage.AlertRules()
+	if err != nil {
+		h.send(ev.SetError(err))
+		return
+	}
+	h.send(ev.SetData(rules))
+}
+
+// HandleCreateAlertRule creates a new alert rule; it applies to gadgets started afterwards
+func (h *Handler) HandleCreateAlertRule(ev *api.Event) {
+	if h.alertStorage == nil {
+		h.send(ev.SetError(fmt.Errorf("alerting not available")))
+		return
+	}
+
+	rule := &gadget.AlertRule{}
+	err := json.Unmarshal(ev.Data, rule)
+	if err != nil {
+		h.send(ev.SetError(err))
+		return
+	}
+	if err := h.validateAlertRule(rule); err != nil {
+		h.send(ev.SetError(err))
+		return
+	}
+
+	err = h.alertStorage.AddRule(rule)
+	if err != nil {
+		h.send(ev.SetError(err))
+		return
+	}
+	h.send(ev.SetData(rule))
+}
+
+// HandleUpdateAlertRule replaces an existing alert rule
+func (h *Handler) HandleUpdateAlertRule(ev *api.Event) {
+	if h.alertStorage == nil {
+		h.send(ev.SetError(fmt.Errorf("alerting not available")))
+		return
+	}
+
+	rule := &gadget.AlertRule{}
+	err := json.Unmarshal(ev.Data, rule)
+	if err != nil {
+		h.send(ev.SetError(err))
+		return
+	}
+	if _, err := h.alertStorage.GetRule(rule.ID); err != nil {
+		h.send(ev.SetError(err))
+		return
+	}
+	if err := h.validateAlertRule(rule); err != nil {
+		h.send(ev.SetError(err))
+		return
+	}
+
+	err = h.alertStorage.SetRule(rule)
+	if err != nil {
+		h.send(ev.SetError(err))
+		return
+	}
+	h.send(ev.SetData(rule))
+}
+
+// HandleDeleteAlertRule deletes an alert rule; alerts it raised are kept
+func (h *Handler) HandleDeleteAlertRule(ev *api.Event) {
+	if h.alertStorage == nil {
+		h.send(ev.SetError(fmt.Errorf("alerting not available")))
+		return
+	}
+
+	var req struct {
+		ID string `json:"id"`
+	}
+	err := json.Unmarshal(ev.Data, &req)
+	if err != nil {
+		h.send(ev.SetError(err))
+		return
+	}
+
+	err = h.alertStorage.DeleteRule(req.ID)
+	if err != nil {
+		h.send(ev.SetError(err))
+		return
+	}
+	h.send(ev.SetData(req))
+}
+
+// HandleListAlerts returns raised alerts, newest first
+func (h *Handler) HandleListAlerts(ev *api.Event) {
+	if h.alertStorage == nil {
+		h.send(ev.SetError(fmt.Errorf("alerting not available")))
+		return
+	}
+
+	var req struct {
+		RuleID string `json:"ruleID"`
+		Limit  int    `json:"limit"`
+	}
+	if len(ev.Data) > 0 {
+		if err := json.Unmarshal(ev.Data, &req); err != nil {
+			h.send(ev.SetError(err))
+			return
+		}
+	}
+
+	alerts, err := h.alertStorage.Alerts(req.RuleID, req.Limit)
+	if err != nil {
+		h.send(ev.SetError(err))
+		return
+	}
+	h.send(ev.SetData(alerts))
+}
+
+// HandleClearAlerts removes all raised alerts
+func (h *Handler) HandleClearAlerts(ev *api.Event) {
+	if h.alertStorage == nil {
+		h.send(ev.SetError(fmt.Errorf("alerting not available")))
+		return
+	}
+
+	if err := h.alertStorage.ClearAlerts(); err != nil {
+		h.send(ev.SetError(err))
+		return
+	}
+	h.send(ev.SetData(nil))
+}
@@ -0,0 +1,56 @@
+// Copyright 2026 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handlers
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/inspektor-gadget/ig-desktop/internal/alert"
+	"github.com/inspektor-gadget/ig-desktop/pkg/api"
+)
+
+func TestHandleCreateAlertRule(t *testing.T) {
+	h, _ := newTestHandler(t)
+	storage, err := alert.NewStorage(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.alertStorage = storage
+
+	for _, tc := range []struct {
+		expression string
+		valid      bool
+	}{
+		{`comm == "nc"`, true},
+		{`comm ==`, false},
+		{``, false},
+	} {
+		data, _ := json.Marshal(map[string]string{"expression": tc.expression})
+		ev := &api.Event{Command: "createAlertRule", Data: data}
+		h.HandleCreateAlertRule(ev)
+		if ev.Success != tc.valid {
+			t.Errorf("%q: expected success=%v, got error %q", tc.expression, tc.valid, ev.Error)
+		}
+	}
+
+	rules, err := storage.AlertRules()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rules) != 1 {
+		t.Errorf("expected only the valid rule to be stored, got %d rules", len(rules))
+	}
+}
@@ -0,0 +1,133 @@
+// Copyright 2026 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/inspektor-gadget/ig-desktop/internal/credentials"
+	"github.com/inspektor-gadget/ig-desktop/internal/environment"
+	"github.com/inspektor-gadget/ig-desktop/pkg/api"
+)
+
+// credentialInfo is a stored credential as returned to the frontend
+type credentialInfo struct {
+	*credentials.Credential
+	Ref    string `json:"ref"`    // value to use for the TLS param of an environment
+	Status string `json:"status"` // credentials.StatusValid, StatusExpiring or StatusExpired
+}
+
+func newCredentialInfo(c *credentials.Credential, now time.Time) *credentialInfo {
+	return &credentialInfo{
+		Credential: c,
+		Ref:        credentials.Ref(c.ID),
+		Status:     c.Status(now, credentials.DefaultExpiryWarning),
+	}
+}
+
+// HandleImportCredential stores the given PEM material. The content has to be sent by the client, as
+// reading a path would let remote clients of igd read any file the daemon can access.
+func (h *Handler) HandleImportCredential(ev *api.Event) {
+	if h.credentialStore == nil {
+		h.send(ev.SetError(fmt.Errorf("credential store not available")))
+		return
+	}
+
+	req := struct {
+		Name string `json:"name"`
+		Kind string `json:"kind"`
+		PEM  string `json:"pem"`
+	}{}
+	err := json.Unmarshal(ev.Data, &req)
+	if err != nil {
+		h.send(ev.SetError(err))
+		return
+	}
+
+	if strings.TrimSpace(req.PEM) == "" {
+		h.send(ev.SetError(&api.ErrInvalidRequest{Reason: "pem is required"}))
+		return
+	}
+
+	c, err := h.credentialStore.Import(req.Name, req.Kind, []byte(req.PEM))
+	if err != nil {
+		h.send(ev.SetError(err))
+		return
+	}
+	h.send(ev.SetData(newCredentialInfo(c, time.Now())))
+}
+
+// HandleListCredentials returns all stored credentials along with their expiry state
+func (h *Handler) HandleListCredentials(ev *api.Event) {
+	if h.credentialStore == nil {
+		h.send(ev.SetError(fmt.Errorf("credential store not available")))
+		return
+	}
+
+	list, err := h.credentialStore.List()
+	if err != nil {
+		h.send(ev.SetError(err))
+		return
+	}
+	now := time.Now()
+	res := make([]*credentialInfo, 0, len(list))
+	for _, c := range list {
+		res = append(res, newCredentialInfo(c, now))
+	}
+	h.send(ev.SetData(res))
+}
+
+// HandleDeleteCredential removes a stored credential unless an environment still references it
+func (h *Handler) HandleDeleteCredential(ev *api.Event) {
+	if h.credentialStore == nil {
+		h.send(ev.SetError(fmt.Errorf("credential store not available")))
+		return
+	}
+
+	req := struct {
+		ID string `json:"id"`
+	}{}
+	err := json.Unmarshal(ev.Data, &req)
+	if err != nil {
+		h.send(ev.SetError(err))
+		return
+	}
+
+	users, err := environment.UsingCredential(h.envStorage, req.ID)
+	if err != nil {
+		h.send(ev.SetError(err))
+		return
+	}
+	if len(users) > 0 {
+		names := make([]string, 0, len(users))
+		for _, env := range users {
+			names = append(names, fmt.Sprintf("%q", env.Name))
+		}
+		h.send(ev.SetError(&api.ErrInvalidRequest{
+			Reason: fmt.Sprintf("credential is used by %s", strings.Join(names, ", ")),
+		}))
+		return
+	}
+
+	err = h.credentialStore.Delete(req.ID)
+	if err != nil {
+		h.send(ev.SetError(err))
+		return
+	}
+	h.send(ev.SetData(nil))
+}
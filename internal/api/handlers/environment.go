@@ -15,10 +15,17 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
 
 	"github.com/inspektor-gadget/ig-desktop/internal/environment"
 	"github.com/inspektor-gadget/ig-desktop/pkg/api"
+	grpcruntime "github.com/inspektor-gadget/ig-desktop/pkg/grpc-runtime"
+	"github.com/inspektor-gadget/ig-desktop/pkg/k8s"
 )
 
 // HandleCreateEnvironment handles creating a new environment
@@ -30,6 +37,18 @@ func (h *Handler) HandleCreateEnvironment(ev *api.Event) {
 		return
 	}
 
+	err = env.Normalize()
+	if err != nil {
+		h.send(ev.SetError(err))
+		return
+	}
+
+	err = h.runtimeFactory.ValidateParams(env.Runtime, env.Params)
+	if err != nil {
+		h.send(ev.SetError(err))
+		return
+	}
+
 	err = h.envStorage.Add(env)
 	if err != nil {
 		h.send(ev.SetError(err))
@@ -48,6 +67,112 @@ func (h *Handler) HandleCreateEnvironment(ev *api.Event) {
 	h.send(ev.SetData(env))
 }
 
+// HandleUpdateEnvironment handles changing the name, params, group, labels, limits or default
+// params of an existing environment. Fields that are omitted keep their current values.
+func (h *Handler) HandleUpdateEnvironment(ev *api.Event) {
+	update := &environment.Update{}
+	err := json.Unmarshal(ev.Data, update)
+	if err != nil {
+		h.send(ev.SetError(err))
+		return
+	}
+
+	current, err := h.envStorage.Get(update.ID)
+	if err != nil {
+		h.send(ev.SetError(err))
+		return
+	}
+	env := update.Apply(current)
+
+	err = env.Normalize()
+	if err != nil {
+		h.send(ev.SetError(err))
+		return
+	}
+
+	err = h.runtimeFactory.ValidateParams(env.Runtime, env.Params)
+	if err != nil {
+		h.send(ev.SetError(err))
+		return
+	}
+
+	err = h.envStorage.Set(env)
+	if err != nil {
+		h.send(ev.SetError(err))
+		return
+	}
+
+	// The environment might point to a different target now
+	h.invalidateEnvironment(env.ID)
+
+	// Emit updated environment first
+	d, _ := json.Marshal(env)
+	cmd := &api.GadgetEvent{
+		Type: api.TypeEnvironmentUpdate,
+		Data: d,
+	}
+	h.send(cmd)
+
+	// Then acknowledge update action
+	h.send(ev.SetData(env))
+}
+
+// HandleListEnvironments returns the environments matching a group and labels, sorted by group
+// and name, together with all groups and labels in use to build filters from
+func (h *Handler) HandleListEnvironments(ev *api.Event) {
+	var req environment.Selector
+	err := json.Unmarshal(ev.Data, &req)
+	if err != nil {
+		h.send(ev.SetError(err))
+		return
+	}
+
+	environments, err := h.envStorage.List()
+	if err != nil {
+		h.send(ev.SetError(err))
+		return
+	}
+
+	h.send(ev.SetData(struct {
+		Environments []*environment.Environment `json:"environments"`
+		Groups       []string                   `json:"groups"`
+		Labels       map[string][]string        `json:"labels"`
+	}{
+		Environments: environment.Select(environments, req),
+		Groups:       environment.Groups(environments),
+		Labels:       environment.Labels(environments),
+	}))
+}
+
+// HandleTestEnvironment walks the connection path of an environment and reports the latency,
+// server version or error of every step
+func (h *Handler) HandleTestEnvironment(ev *api.Event) {
+	var req struct {
+		EnvironmentID string `json:"environmentID"`
+	}
+	err := json.Unmarshal(ev.Data, &req)
+	if err != nil {
+		h.send(ev.SetError(err))
+		return
+	}
+
+	go func() {
+		steps, err := h.runtimeFactory.CheckEnvironment(h.ctx, req.EnvironmentID)
+		if err != nil {
+			h.send(ev.SetError(err))
+			return
+		}
+		ok := true
+		for _, step := range steps {
+			ok = ok && step.OK()
+		}
+		h.send(ev.SetData(struct {
+			OK    bool                     `json:"ok"`
+			Steps []*grpcruntime.CheckStep `json:"steps"`
+		}{OK: ok, Steps: steps}))
+	}()
+}
+
 // HandleDeleteEnvironment handles deleting an environment
 func (h *Handler) HandleDeleteEnvironment(ev *api.Event) {
 	env := &environment.Environment{}
@@ -63,6 +188,8 @@ func (h *Handler) HandleDeleteEnvironment(ev *api.Event) {
 		return
 	}
 
+	h.invalidateEnvironment(env.ID)
+
 	// Emit deletion of environment first
 	d, _ := json.Marshal(env)
 	cmd := &api.GadgetEvent{
@@ -74,3 +201,205 @@ func (h *Handler) HandleDeleteEnvironment(ev *api.Event) {
 	// Then acknowledge delete action
 	h.send(ev.SetData(env))
 }
+
+// environmentReferences returns the schedules and presets using an environment by its ID
+func (h *Handler) environmentReferences() (map[string][]string, error) {
+	res := make(map[string][]string)
+	if h.scheduler != nil {
+		schedules, err := h.scheduler.List()
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range schedules {
+			res[s.EnvironmentID] = append(res[s.EnvironmentID], fmt.Sprintf("schedule %q", s.Name))
+		}
+	}
+	if h.presetStorage != nil {
+		presets, err := h.presetStorage.List()
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range presets {
+			if p.EnvironmentID != "" {
+				res[p.EnvironmentID] = append(res[p.EnvironmentID], fmt.Sprintf("preset %q", p.Name))
+			}
+		}
+	}
+	return res, nil
+}
+
+// HandleImportKubeContexts creates grpc-k8s environments for the selected (or all) kubeconfig
+// contexts that don't have one yet. Existing environments follow renamed contexts; environments
+// of removed contexts are deleted if prune is set, unless a schedule or preset still uses them.
+// With probe, every created environment is checked for an Inspektor Gadget deployment.
+func (h *Handler) HandleImportKubeContexts(ev *api.Event) {
+	var req struct {
+		Contexts  []string `json:"contexts"`
+		All       bool     `json:"all"`
+		Prune     bool     `json:"prune"`
+		Probe     bool     `json:"probe"`
+		Namespace string   `json:"namespace"` // for probing; defaults to "gadget"
+	}
+	err := json.Unmarshal(ev.Data, &req)
+	if err != nil {
+		h.send(ev.SetError(err))
+		return
+	}
+
+	contexts, err := environment.ListKubeContexts()
+	if err != nil {
+		h.send(ev.SetError(fmt.Errorf("failed to load kubeconfig: %w", err)))
+		return
+	}
+
+	selected := req.Contexts
+	if req.All {
+		selected = make([]string, 0, len(contexts))
+		for _, c := range contexts {
+			selected = append(selected, c.Name)
+		}
+	}
+
+	references, err := h.environmentReferences()
+	if err != nil {
+		h.send(ev.SetError(err))
+		return
+	}
+
+	res, err := environment.SyncKubeContexts(h.envStorage, contexts, selected, req.Prune, references)
+	if err != nil {
+		h.send(ev.SetError(err))
+		return
+	}
+
+	for _, env := range res.Renamed {
+		h.invalidateEnvironment(env.ID)
+		d, _ := json.Marshal(env)
+		h.send(&api.GadgetEvent{Type: api.TypeEnvironmentUpdate, Data: d})
+	}
+	for _, env := range res.Removed {
+		h.invalidateEnvironment(env.ID)
+		d, _ := json.Marshal(env)
+		h.send(&api.GadgetEvent{Type: api.TypeEnvironmentDelete, Data: d})
+	}
+	for _, env := range res.Created {
+		d, _ := json.Marshal(env)
+		h.send(&api.GadgetEvent{Type: api.TypeEnvironmentCreate, Data: d})
+	}
+
+	if !req.Probe || len(res.Created) == 0 {
+		h.send(ev.SetData(res))
+		return
+	}
+
+	namespace := req.Namespace
+	if namespace == "" {
+		namespace = "gadget"
+	}
+	go func() {
+		probes := make(map[string]*k8s.DeploymentStatus, len(res.Created))
+		var mu sync.Mutex
+		var wg sync.WaitGroup
+		for _, env := range res.Created {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				status := probeKubeContext(h.ctx, env.Params["context"], namespace)
+				mu.Lock()
+				probes[env.ID] = status
+				mu.Unlock()
+			}()
+		}
+		wg.Wait()
+
+		h.send(ev.SetData(struct {
+			*environment.KubeSyncResult
+			Probes map[string]*k8s.DeploymentStatus `json:"probes"`
+		}{KubeSyncResult: res, Probes: probes}))
+	}()
+}
+
+// HandleExportEnvironments returns the selected (or all) environments as JSON or YAML, with
+// secret params redacted
+func (h *Handler) HandleExportEnvironments(ev *api.Event) {
+	var req struct {
+		IDs    []string `json:"ids"`
+		Format string   `json:"format"`
+	}
+	err := json.Unmarshal(ev.Data, &req)
+	if err != nil {
+		h.send(ev.SetError(err))
+		return
+	}
+	if req.Format == "" {
+		req.Format = environment.ExportFormatJSON
+	}
+
+	data, err := environment.ExportEnvironments(h.envStorage, req.IDs, req.Format)
+	if err != nil {
+		h.send(ev.SetError(err))
+		return
+	}
+
+	h.send(ev.SetData(struct {
+		Format string `json:"format"`
+		Data   string `json:"data"`
+	}{Format: req.Format, Data: string(data)}))
+}
+
+// HandleImportEnvironments stores the environments of an export made by exportEnvironments
+func (h *Handler) HandleImportEnvironments(ev *api.Event) {
+	var req struct {
+		Data string `json:"data"`
+		environment.ImportOptions
+	}
+	err := json.Unmarshal(ev.Data, &req)
+	if err != nil {
+		h.send(ev.SetError(err))
+		return
+	}
+
+	res, err := environment.ImportEnvironments(h.envStorage, []byte(req.Data), req.ImportOptions, h.runtimeFactory.ValidateParams)
+	if err != nil {
+		h.send(ev.SetError(err))
+		return
+	}
+
+	for _, env := range res.Replaced {
+		h.invalidateEnvironment(env.ID)
+		d, _ := json.Marshal(env)
+		h.send(&api.GadgetEvent{Type: api.TypeEnvironmentUpdate, Data: d})
+	}
+	for _, env := range res.Created {
+		d, _ := json.Marshal(env)
+		h.send(&api.GadgetEvent{Type: api.TypeEnvironmentCreate, Data: d})
+	}
+
+	h.send(ev.SetData(res))
+}
+
+// probeKubeContext checks whether Inspektor Gadget is deployed in the cluster of a context
+func probeKubeContext(ctx context.Context, kubeContext string, namespace string) *k8s.DeploymentStatus {
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	config, err := k8s.GetKubeConfig("", kubeContext)
+	if err != nil {
+		return &k8s.DeploymentStatus{Error: fmt.Sprintf("failed to load kubeconfig: %v", err)}
+	}
+	status, err := k8s.CheckIGDeployment(ctx, config, namespace)
+	if err != nil && status == nil {
+		return &k8s.DeploymentStatus{Error: err.Error()}
+	}
+	return status
+}
+
+// invalidateEnvironment drops everything cached for an environment that has been changed or deleted
+func (h *Handler) invalidateEnvironment(id string) {
+	h.runtimeFactory.Invalidate(id)
+	if cache := h.gadgetService.InfoCache(); cache != nil {
+		if err := cache.Invalidate(id, ""); err != nil {
+			log.Printf("failed to remove cached gadget info: %v", err)
+		}
+	}
+}
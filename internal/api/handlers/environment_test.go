@@ -0,0 +1,118 @@
+// Copyright 2026 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"maps"
+	"testing"
+
+	"github.com/inspektor-gadget/ig-desktop/internal/environment"
+	"github.com/inspektor-gadget/ig-desktop/pkg/api"
+	"github.com/inspektor-gadget/ig-desktop/pkg/gadget"
+)
+
+// newTestHandler returns a handler with environment storage that records everything it sends
+func newTestHandler(t *testing.T) (*Handler, *[]any) {
+	t.Helper()
+	envStorage := environment.NewStorage(t.TempDir())
+	h := New(context.Background(), envStorage, environment.NewRuntimeFactory(envStorage),
+		gadget.NewService(gadget.NewInstanceManager()), nil, nil, nil, nil, nil, nil, nil, nil, "")
+	var sent []any
+	h.send = func(ev any) { sent = append(sent, ev) }
+	return h, &sent
+}
+
+func TestHandleUpdateEnvironment(t *testing.T) {
+	h, sent := newTestHandler(t)
+	h.runtimeFactory.SetHostPolicy(environment.HostPolicy{AllowKubeconfig: true})
+	env := &environment.Environment{
+		Name:          "prod",
+		Runtime:       "grpc-k8s",
+		Params:        map[string]string{"context": "prod", "kubeconfig-data": "apiVersion: v1"},
+		Group:         "clusters/eu",
+		Labels:        map[string]string{"team": "infra"},
+		Limits:        &api.RunLimits{MaxDuration: 60},
+		DefaultParams: map[string]string{"operator.KubeManager.namespace": "default"},
+		KubeContext:   &environment.KubeContextSource{Server: "https://prod:6443", User: "alice"},
+	}
+	if err := h.envStorage.Add(env); err != nil {
+		t.Fatal(err)
+	}
+
+	update := func(data string) *api.Event {
+		*sent = nil
+		ev := &api.Event{Command: "updateEnvironment", Data: json.RawMessage(data)}
+		h.HandleUpdateEnvironment(ev)
+		return ev
+	}
+
+	// Omitted fields are kept
+	if ev := update(`{"id":"` + env.ID + `","name":"production"}`); !ev.Success {
+		t.Fatalf("update failed: %s", ev.Error)
+	}
+	got, err := h.envStorage.Get(env.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Name != "production" || got.Runtime != "grpc-k8s" || got.Group != "clusters/eu" ||
+		!maps.Equal(got.Params, env.Params) || !maps.Equal(got.Labels, env.Labels) ||
+		!maps.Equal(got.DefaultParams, env.DefaultParams) || got.Limits == nil || got.Limits.MaxDuration != 60 ||
+		got.KubeContext == nil {
+		t.Fatalf("omitted fields weren't kept: %+v", got)
+	}
+	if len(*sent) != 2 {
+		t.Fatalf("expected update event and response, got %d messages", len(*sent))
+	}
+	if ev, ok := (*sent)[0].(*api.GadgetEvent); !ok || ev.Type != api.TypeEnvironmentUpdate {
+		t.Errorf("expected environment update event first, got %+v", (*sent)[0])
+	}
+
+	// Given maps replace the current ones, empty ones and empty limits clear them
+	if ev := update(`{"id":"` + env.ID + `","params":{"context":"dev"},"labels":{},"limits":{},"group":""}`); !ev.Success {
+		t.Fatalf("update failed: %s", ev.Error)
+	}
+	got, err = h.envStorage.Get(env.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !maps.Equal(got.Params, map[string]string{"context": "dev"}) || len(got.Labels) != 0 || got.Limits != nil || got.Group != "" {
+		t.Fatalf("fields weren't replaced: %+v", got)
+	}
+	if got.KubeContext != nil {
+		t.Error("kube context source kept for a different context")
+	}
+	if !maps.Equal(got.DefaultParams, env.DefaultParams) {
+		t.Error("omitted default params weren't kept")
+	}
+
+	// Invalid updates aren't stored
+	if ev := update(`{"id":"` + env.ID + `","runtime":"foo"}`); ev.Success {
+		t.Error("expected invalid runtime to be rejected")
+	}
+	if ev := update(`{"id":"` + env.ID + `","params":{"as-group":"devs"}}`); ev.Success {
+		t.Error("expected invalid params to be rejected")
+	}
+	if got, err := h.envStorage.Get(env.ID); err != nil || got.Runtime != "grpc-k8s" || got.Params["context"] != "dev" {
+		t.Errorf("rejected update was stored: %+v, %v", got, err)
+	}
+
+	// Servers refuse the config of the host unless allowed
+	h.runtimeFactory.SetHostPolicy(environment.HostPolicy{})
+	if ev := update(`{"id":"` + env.ID + `","params":{"in-cluster":"true"}}`); ev.Success {
+		t.Error("expected in-cluster config to be rejected")
+	}
+}
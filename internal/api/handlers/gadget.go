@@ -16,6 +16,7 @@ package handlers
 
 import (
 	"encoding/json"
+	"fmt"
 	"log"
 
 	"google.golang.org/protobuf/encoding/protojson"
@@ -28,15 +29,20 @@ import (
 // HandleRunGadget handles running a new gadget instance
 func (h *Handler) HandleRunGadget(ev *api.Event) {
 	var req struct {
-		ID            string            `json:"id"`
-		Image         string            `json:"image"`
-		EnvironmentID string            `json:"environmentID"`
-		Params        map[string]string `json:"params"`
-		Detached      bool              `json:"detached"`
-		InstanceName  string            `json:"instanceName"`
-		Record        bool              `json:"record"`
-		SessionID     string            `json:"sessionId"`
-		SessionName   string            `json:"sessionName"`
+		ID            string              `json:"id"`
+		Image         string              `json:"image"`
+		EnvironmentID string              `json:"environmentID"`
+		Params        map[string]string   `json:"params"`
+		Detached      bool                `json:"detached"`
+		InstanceName  string              `json:"instanceName"`
+		Record        bool                `json:"record"`
+		SessionID     string              `json:"sessionId"`
+		SessionName   string              `json:"sessionName"`
+		Limits        api.RunLimits       `json:"limits"`
+		Sinks         []gadget.SinkConfig `json:"sinks"`
+		Tags          []string            `json:"tags"`
+		Nodes         []string            `json:"nodes"`
+		LogLevel      string              `json:"logLevel"`
 	}
 	err := json.Unmarshal(ev.Data, &req)
 	if err != nil {
@@ -44,12 +50,6 @@ func (h *Handler) HandleRunGadget(ev *api.Event) {
 		return
 	}
 
-	runtime, err := h.runtimeFactory.GetRuntime(req.EnvironmentID)
-	if err != nil {
-		h.send(ev.SetError(err))
-		return
-	}
-
 	runReq := gadget.RunRequest{
 		ID:            req.ID,
 		Image:         req.Image,
@@ -60,22 +60,120 @@ func (h *Handler) HandleRunGadget(ev *api.Event) {
 		Record:        req.Record,
 		SessionID:     req.SessionID,
 		SessionName:   req.SessionName,
+		Limits:        req.Limits,
+		Sinks:         req.Sinks,
+		Tags:          req.Tags,
+		Nodes:         req.Nodes,
+		LogLevel:      req.LogLevel,
+	}
+
+	// Validating the params might have to fetch gadget info from the remote, which mustn't block
+	// other commands
+	go func() {
+		instanceID, err := h.runGadget(runReq)
+		if err != nil {
+			h.send(ev.SetError(err))
+			return
+		}
+
+		// Send acknowledgment with the instance ID
+		req.ID = instanceID
+		h.send(ev.SetData(req))
+
+		// If detached, send additional acknowledgment
+		if req.Detached {
+			h.send(ev.SetData(nil))
+		}
+	}()
+}
+
+// runGadget starts a gadget in the environment of the request, applying the
+// environment's default run limits and params
+func (h *Handler) runGadget(runReq gadget.RunRequest) (string, error) {
+	env, err := h.envStorage.Get(runReq.EnvironmentID)
+	if err != nil {
+		return "", err
+	}
+
+	runtime, err := h.runtimeFactory.GetRuntime(runReq.EnvironmentID)
+	if err != nil {
+		return "", err
 	}
 
-	instanceID, err := h.gadgetService.Run(h.ctx, runtime, runReq)
+	runReq.Limits = runReq.Limits.WithDefaults(env.Limits)
+	runReq.DefaultParams = env.DefaultParams
+	return h.gadgetService.Run(h.ctx, runtime, runReq)
+}
+
+// HandleRunGadgetMulti handles running one gadget in several environments as a single instance
+func (h *Handler) HandleRunGadgetMulti(ev *api.Event) {
+	var req struct {
+		ID             string              `json:"id"`
+		Image          string              `json:"image"`
+		EnvironmentIDs []string            `json:"environmentIDs"`
+		Params         map[string]string   `json:"params"`
+		Record         bool                `json:"record"`
+		SessionID      string              `json:"sessionId"`
+		SessionName    string              `json:"sessionName"`
+		Limits         api.RunLimits       `json:"limits"`
+		Sinks          []gadget.SinkConfig `json:"sinks"`
+		LogLevel       string              `json:"logLevel"`
+	}
+	err := json.Unmarshal(ev.Data, &req)
 	if err != nil {
 		h.send(ev.SetError(err))
 		return
 	}
 
-	// Send acknowledgment with the instance ID
-	req.ID = instanceID
-	h.send(ev.SetData(req))
+	// Connecting to the environments and validating the params might take a while, which mustn't
+	// block other commands
+	go func() {
+		targets := make([]gadget.EnvironmentRun, 0, len(req.EnvironmentIDs))
+		seen := make(map[string]bool, len(req.EnvironmentIDs))
+		for _, id := range req.EnvironmentIDs {
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
 
-	// If detached, send additional acknowledgment
-	if req.Detached {
-		h.send(ev.SetData(nil))
-	}
+			env, err := h.envStorage.Get(id)
+			if err != nil {
+				h.send(ev.SetError(err))
+				return
+			}
+			runtime, err := h.runtimeFactory.GetRuntime(id)
+			if err != nil {
+				h.send(ev.SetError(err))
+				return
+			}
+			targets = append(targets, gadget.EnvironmentRun{
+				EnvironmentID: id,
+				Runtime:       runtime,
+				Limits:        req.Limits.WithDefaults(env.Limits),
+				DefaultParams: env.DefaultParams,
+			})
+		}
+
+		runReq := gadget.RunRequest{
+			Image:       req.Image,
+			Params:      req.Params,
+			Record:      req.Record,
+			SessionID:   req.SessionID,
+			SessionName: req.SessionName,
+			Sinks:       req.Sinks,
+			LogLevel:    req.LogLevel,
+		}
+
+		instanceID, err := h.gadgetService.RunMulti(h.ctx, targets, runReq)
+		if err != nil {
+			h.send(ev.SetError(err))
+			return
+		}
+
+		// Send acknowledgment with the instance ID
+		req.ID = instanceID
+		h.send(ev.SetData(req))
+	}()
 }
 
 // HandleAttachInstance handles attaching to an existing gadget instance
@@ -86,6 +184,7 @@ func (h *Handler) HandleAttachInstance(ev *api.Event) {
 		EnvironmentID string            `json:"environmentID"`
 		Params        map[string]string `json:"params"`
 		InstanceName  string            `json:"instanceName"`
+		LogLevel      string            `json:"logLevel"`
 	}
 	err := json.Unmarshal(ev.Data, &req)
 	if err != nil {
@@ -105,6 +204,7 @@ func (h *Handler) HandleAttachInstance(ev *api.Event) {
 		EnvironmentID: req.EnvironmentID,
 		Params:        req.Params,
 		InstanceName:  req.InstanceName,
+		LogLevel:      req.LogLevel,
 	}
 
 	instanceID, err := h.gadgetService.Attach(h.ctx, runtime, attachReq)
@@ -123,6 +223,7 @@ func (h *Handler) HandleGetGadgetInfo(ev *api.Event) {
 	var req struct {
 		URL           string `json:"url"`
 		EnvironmentID string `json:"environmentID"`
+		Refresh       bool   `json:"refresh"`
 	}
 	err := json.Unmarshal(ev.Data, &req)
 	if err != nil {
@@ -137,7 +238,7 @@ func (h *Handler) HandleGetGadgetInfo(ev *api.Event) {
 	}
 
 	go func() {
-		info, err := h.gadgetService.GetInfo(h.ctx, runtime, req.URL)
+		info, err := h.gadgetService.GetCachedInfo(h.ctx, runtime, req.EnvironmentID, req.URL, req.Refresh)
 		if err != nil {
 			h.send(ev.SetError(err))
 			return
@@ -146,6 +247,87 @@ func (h *Handler) HandleGetGadgetInfo(ev *api.Event) {
 	}()
 }
 
+// HandleInvalidateGadgetInfo removes cached gadget info of an image, or of all images of the
+// environment if no image is given
+func (h *Handler) HandleInvalidateGadgetInfo(ev *api.Event) {
+	var req struct {
+		EnvironmentID string `json:"environmentID"`
+		Image         string `json:"image"`
+	}
+	err := json.Unmarshal(ev.Data, &req)
+	if err != nil {
+		h.send(ev.SetError(err))
+		return
+	}
+
+	cache := h.gadgetService.InfoCache()
+	if cache == nil {
+		h.send(ev.SetError(fmt.Errorf("gadget info cache not available")))
+		return
+	}
+	if err := cache.Invalidate(req.EnvironmentID, req.Image); err != nil {
+		h.send(ev.SetError(err))
+		return
+	}
+	h.send(ev.SetData(req))
+}
+
+// HandleListCachedGadgets returns the gadgets with cached info in an environment, which can be
+// viewed even if the environment is unreachable
+func (h *Handler) HandleListCachedGadgets(ev *api.Event) {
+	var req struct {
+		EnvironmentID string `json:"environmentID"`
+	}
+	err := json.Unmarshal(ev.Data, &req)
+	if err != nil {
+		h.send(ev.SetError(err))
+		return
+	}
+
+	cache := h.gadgetService.InfoCache()
+	if cache == nil {
+		h.send(ev.SetError(fmt.Errorf("gadget info cache not available")))
+		return
+	}
+	h.send(ev.SetData(cache.List(req.EnvironmentID)))
+}
+
+// HandleValidateGadgetParams checks params against the param descriptors of a gadget and
+// returns an error per invalid param
+func (h *Handler) HandleValidateGadgetParams(ev *api.Event) {
+	var req struct {
+		Image         string            `json:"image"`
+		EnvironmentID string            `json:"environmentID"`
+		Params        map[string]string `json:"params"`
+	}
+	err := json.Unmarshal(ev.Data, &req)
+	if err != nil {
+		h.send(ev.SetError(err))
+		return
+	}
+
+	runtime, err := h.runtimeFactory.GetRuntime(req.EnvironmentID)
+	if err != nil {
+		h.send(ev.SetError(err))
+		return
+	}
+
+	go func() {
+		paramErrors, err := h.gadgetService.ValidateParams(h.ctx, runtime, req.EnvironmentID, req.Image, req.Params)
+		if err != nil {
+			h.send(ev.SetError(err))
+			return
+		}
+		h.send(ev.SetData(struct {
+			Valid  bool             `json:"valid"`
+			Errors []api.ParamError `json:"errors"`
+		}{
+			Valid:  len(paramErrors) == 0,
+			Errors: paramErrors,
+		}))
+	}()
+}
+
 // HandleListInstances handles listing all gadget instances in an environment
 func (h *Handler) HandleListInstances(ev *api.Event) {
 	var req struct {
@@ -20,9 +20,13 @@ import (
 	"log"
 	"sync"
 
+	"github.com/inspektor-gadget/ig-desktop/internal/alert"
 	"github.com/inspektor-gadget/ig-desktop/internal/artifacthub"
+	"github.com/inspektor-gadget/ig-desktop/internal/credentials"
 	"github.com/inspektor-gadget/ig-desktop/internal/environment"
 	"github.com/inspektor-gadget/ig-desktop/internal/plugins"
+	"github.com/inspektor-gadget/ig-desktop/internal/preset"
+	"github.com/inspektor-gadget/ig-desktop/internal/scheduler"
 	"github.com/inspektor-gadget/ig-desktop/internal/session"
 	"github.com/inspektor-gadget/ig-desktop/pkg/api"
 	"github.com/inspektor-gadget/ig-desktop/pkg/api/transport"
@@ -39,6 +43,10 @@ type Handler struct {
 	artifactHub     *artifacthub.Client
 	sessionService  *session.Service
 	pluginService   *plugins.Service
+	scheduler       *scheduler.Scheduler
+	presetStorage   *preset.Storage
+	alertStorage    *alert.Storage
+	credentialStore *credentials.Store
 	helmDir         string
 
 	mu   sync.Mutex
@@ -69,6 +77,10 @@ func New(
 	artifactHub *artifacthub.Client,
 	sessionService *session.Service,
 	pluginService *plugins.Service,
+	scheduler *scheduler.Scheduler,
+	presetStorage *preset.Storage,
+	alertStorage *alert.Storage,
+	credentialStore *credentials.Store,
 	helmDir string,
 ) *Handler {
 	return &Handler{
@@ -80,6 +92,10 @@ func New(
 		artifactHub:     artifactHub,
 		sessionService:  sessionService,
 		pluginService:   pluginService,
+		scheduler:       scheduler,
+		presetStorage:   presetStorage,
+		alertStorage:    alertStorage,
+		credentialStore: credentialStore,
 		helmDir:         helmDir,
 	}
 }
@@ -89,15 +105,32 @@ func (h *Handler) Handlers() []api.CommandHandler {
 	return []api.CommandHandler{
 		commandHandler{"helo", h.HandleHelo},
 		commandHandler{"removeInstance", h.HandleRemoveInstance},
+		commandHandler{"removeInstances", h.HandleRemoveInstances},
+		commandHandler{"attachInstances", h.HandleAttachInstances},
+		commandHandler{"exportInstances", h.HandleExportInstances},
+		commandHandler{"importInstances", h.HandleImportInstances},
 		commandHandler{"stopInstance", h.HandleStopInstance},
 		commandHandler{"runGadget", h.HandleRunGadget},
+		commandHandler{"runGadgetMulti", h.HandleRunGadgetMulti},
 		commandHandler{"attachInstance", h.HandleAttachInstance},
 		commandHandler{"getRuntimes", h.HandleGetRuntimes},
 		commandHandler{"getRuntimeParams", h.HandleGetRuntimeParams},
 		commandHandler{"listInstances", h.HandleListInstances},
 		commandHandler{"getGadgetInfo", h.HandleGetGadgetInfo},
+		commandHandler{"validateGadgetParams", h.HandleValidateGadgetParams},
+		commandHandler{"invalidateGadgetInfo", h.HandleInvalidateGadgetInfo},
+		commandHandler{"listCachedGadgets", h.HandleListCachedGadgets},
 		commandHandler{"createEnvironment", h.HandleCreateEnvironment},
 		commandHandler{"deleteEnvironment", h.HandleDeleteEnvironment},
+		commandHandler{"updateEnvironment", h.HandleUpdateEnvironment},
+		commandHandler{"testEnvironment", h.HandleTestEnvironment},
+		commandHandler{"listEnvironments", h.HandleListEnvironments},
+		commandHandler{"importKubeContexts", h.HandleImportKubeContexts},
+		commandHandler{"exportEnvironments", h.HandleExportEnvironments},
+		commandHandler{"importEnvironments", h.HandleImportEnvironments},
+		commandHandler{"importCredential", h.HandleImportCredential},
+		commandHandler{"listCredentials", h.HandleListCredentials},
+		commandHandler{"deleteCredential", h.HandleDeleteCredential},
 		commandHandler{"getArtifactHubPackage", h.HandleGetArtifactHubPackage},
 		commandHandler{"checkIGDeployment", h.HandleCheckIGDeployment},
 		commandHandler{"deployIG", h.HandleDeployIG},
@@ -113,7 +146,29 @@ func (h *Handler) Handlers() []api.CommandHandler {
 		commandHandler{"getSession", h.HandleGetSession},
 		commandHandler{"getGadgetRun", h.HandleGetGadgetRun},
 		commandHandler{"getRunEvents", h.HandleGetRunEvents},
+		commandHandler{"getRunLogs", h.HandleGetRunLogs},
 		commandHandler{"deleteSession", h.HandleDeleteSession},
+		// Schedule handlers
+		commandHandler{"createSchedule", h.HandleCreateSchedule},
+		commandHandler{"listSchedules", h.HandleListSchedules},
+		commandHandler{"pauseSchedule", h.HandlePauseSchedule},
+		commandHandler{"resumeSchedule", h.HandleResumeSchedule},
+		commandHandler{"deleteSchedule", h.HandleDeleteSchedule},
+		// Preset handlers
+		commandHandler{"listPresets", h.HandleListPresets},
+		commandHandler{"createPreset", h.HandleCreatePreset},
+		commandHandler{"updatePreset", h.HandleUpdatePreset},
+		commandHandler{"deletePreset", h.HandleDeletePreset},
+		commandHandler{"runPreset", h.HandleRunPreset},
+		commandHandler{"exportPresets", h.HandleExportPresets},
+		commandHandler{"importPresets", h.HandleImportPresets},
+		// Alert handlers
+		commandHandler{"listAlertRules", h.HandleListAlertRules},
+		commandHandler{"createAlertRule", h.HandleCreateAlertRule},
+		commandHandler{"updateAlertRule", h.HandleUpdateAlertRule},
+		commandHandler{"deleteAlertRule", h.HandleDeleteAlertRule},
+		commandHandler{"listAlerts", h.HandleListAlerts},
+		commandHandler{"clearAlerts", h.HandleClearAlerts},
 		// Plugin handlers
 		commandHandler{"listPlugins", h.HandleListPlugins},
 		commandHandler{"getPlugin", h.HandleGetPlugin},
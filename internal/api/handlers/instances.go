@@ -0,0 +1,194 @@
+// Copyright 2026 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/inspektor-gadget/ig-desktop/pkg/api"
+	"github.com/inspektor-gadget/ig-desktop/pkg/gadget"
+)
+
+// instancesRequest selects the instances of an environment for bulk operations
+type instancesRequest struct {
+	EnvironmentID string `json:"environmentID"`
+	gadget.InstanceFilter
+}
+
+// HandleRemoveInstances removes all detached instances matching a filter. An empty filter is
+// rejected unless all is set, to avoid wiping an environment by accident.
+func (h *Handler) HandleRemoveInstances(ev *api.Event) {
+	var req struct {
+		instancesRequest
+		All bool `json:"all"`
+	}
+	err := json.Unmarshal(ev.Data, &req)
+	if err != nil {
+		h.send(ev.SetError(err))
+		return
+	}
+	if req.IsZero() && !req.All {
+		h.send(ev.SetError(&api.ErrInvalidRequest{Reason: "a filter is required to remove instances, or all has to be set"}))
+		return
+	}
+
+	runtime, err := h.runtimeFactory.GetRuntime(req.EnvironmentID)
+	if err != nil {
+		h.send(ev.SetError(err))
+		return
+	}
+
+	go func() {
+		removed, err := h.gadgetService.RemoveInstances(h.ctx, runtime, req.InstanceFilter)
+		if err != nil && len(removed) == 0 {
+			h.send(ev.SetError(err))
+			return
+		}
+		res := struct {
+			Removed []string `json:"removed"`
+			Error   string   `json:"error,omitempty"`
+		}{Removed: removed}
+		if err != nil {
+			res.Error = err.Error()
+		}
+		h.send(ev.SetData(res))
+	}()
+}
+
+// HandleAttachInstances attaches to all detached instances matching a filter
+func (h *Handler) HandleAttachInstances(ev *api.Event) {
+	var req instancesRequest
+	err := json.Unmarshal(ev.Data, &req)
+	if err != nil {
+		h.send(ev.SetError(err))
+		return
+	}
+
+	runtime, err := h.runtimeFactory.GetRuntime(req.EnvironmentID)
+	if err != nil {
+		h.send(ev.SetError(err))
+		return
+	}
+
+	go func() {
+		instances, err := h.gadgetService.FindInstances(h.ctx, runtime, req.InstanceFilter)
+		if err != nil {
+			h.send(ev.SetError(err))
+			return
+		}
+
+		type attached struct {
+			ID            string `json:"id"`
+			InstanceID    string `json:"instanceID"`
+			EnvironmentID string `json:"environmentID"`
+			InstanceName  string `json:"instanceName"`
+			Error         string `json:"error,omitempty"`
+		}
+		res := make([]attached, 0, len(instances))
+		for _, instance := range instances {
+			a := attached{
+				InstanceID:    instance.Id,
+				EnvironmentID: req.EnvironmentID,
+				InstanceName:  instance.Name,
+			}
+			a.ID, err = h.gadgetService.Attach(h.ctx, runtime, gadget.AttachRequest{
+				Image:         instance.Id,
+				EnvironmentID: req.EnvironmentID,
+				InstanceName:  instance.Name,
+			})
+			if err != nil {
+				a.Error = err.Error()
+			}
+			res = append(res, a)
+		}
+		h.send(ev.SetData(res))
+	}()
+}
+
+// HandleExportInstances returns the definitions of the detached instances matching a filter as YAML
+func (h *Handler) HandleExportInstances(ev *api.Event) {
+	var req instancesRequest
+	err := json.Unmarshal(ev.Data, &req)
+	if err != nil {
+		h.send(ev.SetError(err))
+		return
+	}
+
+	runtime, err := h.runtimeFactory.GetRuntime(req.EnvironmentID)
+	if err != nil {
+		h.send(ev.SetError(err))
+		return
+	}
+
+	go func() {
+		data, err := h.gadgetService.ExportInstances(h.ctx, runtime, req.InstanceFilter)
+		if err != nil {
+			h.send(ev.SetError(err))
+			return
+		}
+		h.send(ev.SetData(struct {
+			YAML string `json:"yaml"`
+		}{YAML: string(data)}))
+	}()
+}
+
+// HandleImportInstances creates the instances of a YAML bundle written by exportInstances
+// in the given environment
+func (h *Handler) HandleImportInstances(ev *api.Event) {
+	var req struct {
+		EnvironmentID string `json:"environmentID"`
+		YAML          string `json:"yaml"`
+	}
+	err := json.Unmarshal(ev.Data, &req)
+	if err != nil {
+		h.send(ev.SetError(err))
+		return
+	}
+
+	bundle, err := gadget.ParseInstanceBundle([]byte(req.YAML))
+	if err != nil {
+		h.send(ev.SetError(err))
+		return
+	}
+
+	runtime, err := h.runtimeFactory.GetRuntime(req.EnvironmentID)
+	if err != nil {
+		h.send(ev.SetError(err))
+		return
+	}
+
+	go func() {
+		type created struct {
+			Name        string           `json:"name"`
+			Image       string           `json:"image"`
+			Error       string           `json:"error,omitempty"`
+			ParamErrors []api.ParamError `json:"paramErrors,omitempty"`
+		}
+		res := make([]created, 0, len(bundle.Instances))
+		for _, spec := range bundle.Instances {
+			c := created{Name: spec.Name, Image: spec.Image}
+			if err := h.gadgetService.CreateInstance(h.ctx, runtime, req.EnvironmentID, spec); err != nil {
+				c.Error = err.Error()
+				var paramErr *api.ErrInvalidParams
+				if errors.As(err, &paramErr) {
+					c.ParamErrors = paramErr.Errors
+				}
+			}
+			res = append(res, c)
+		}
+		h.send(ev.SetData(res))
+	}()
+}
@@ -30,8 +30,9 @@ import (
 )
 
 type CheckIGDeploymentRequest struct {
-	Namespace   string `json:"namespace"`
-	KubeContext string `json:"kubeContext,omitempty"`
+	Namespace     string `json:"namespace"`
+	KubeContext   string `json:"kubeContext,omitempty"`
+	EnvironmentID string `json:"environmentID,omitempty"`
 }
 
 type DeployIGRequest struct {
@@ -41,8 +42,10 @@ type DeployIGRequest struct {
 	CustomValues string `json:"customValues,omitempty"`
 	KubeConfig   string `json:"kubeConfig,omitempty"`
 	KubeContext  string `json:"kubeContext,omitempty"`
-	Redeploy     bool   `json:"redeploy,omitempty"`
-	Undeploy     bool   `json:"undeploy,omitempty"`
+	// EnvironmentID selects the Kubernetes config of an environment instead of KubeConfig
+	EnvironmentID string `json:"environmentID,omitempty"`
+	Redeploy      bool   `json:"redeploy,omitempty"`
+	Undeploy      bool   `json:"undeploy,omitempty"`
 }
 
 type DeployIGResponse struct {
@@ -71,7 +74,12 @@ func (h *Handler) HandleCheckIGDeployment(ev *api.Event) {
 	}
 
 	// Get Kubernetes config with context
-	config, err := k8s.GetKubeConfig("", req.KubeContext)
+	source, err := h.kubeConfigSource(req.EnvironmentID, "", req.KubeContext)
+	if err != nil {
+		h.send(ev.SetError(err))
+		return
+	}
+	config, err := source.RESTConfig()
 	if err != nil {
 		h.send(ev.SetError(fmt.Errorf("failed to load kubeconfig: %w", err)))
 		return
@@ -115,6 +123,12 @@ func (h *Handler) HandleDeployIG(ev *api.Event) {
 		}
 	}
 
+	source, err := h.kubeConfigSource(req.EnvironmentID, req.KubeConfig, req.KubeContext)
+	if err != nil {
+		h.send(ev.SetError(err))
+		return
+	}
+
 	// Generate deployment ID
 	deploymentID := uuid.New().String()
 
@@ -124,8 +138,7 @@ func (h *Handler) HandleDeployIG(ev *api.Event) {
 		ReleaseName:  req.ReleaseName,
 		ChartVersion: req.ChartVersion,
 		CustomValues: customValues,
-		KubeConfig:   req.KubeConfig,
-		KubeContext:  req.KubeContext,
+		Kube:         source,
 		HelmDir:      h.helmDir,
 	}
 
@@ -51,19 +51,36 @@ type K8sResourceResponse struct {
 	Error string            `json:"error,omitempty"`
 }
 
+// kubeConfigSource returns where to load the Kubernetes config of a request from: the config of
+// the given environment if set, otherwise the given kubeconfig; kubeContext overrides the context
+// of either. Sources the host policy doesn't allow are rejected.
+func (h *Handler) kubeConfigSource(environmentID, kubeConfig, kubeContext string) (k8s.ConfigSource, error) {
+	source := k8s.ConfigSource{Path: kubeConfig}
+	if environmentID != "" {
+		env, err := h.envStorage.Get(environmentID)
+		if err != nil {
+			return source, fmt.Errorf("failed to get environment: %w", err)
+		}
+		source = k8s.ConfigSourceFromParams(env.Params)
+	}
+	if kubeContext != "" {
+		source.Context = kubeContext
+	}
+	if err := h.runtimeFactory.CheckKubeSource(source); err != nil {
+		return source, err
+	}
+	return source, nil
+}
+
 // getK8sClientFromEnvironment creates a Kubernetes client from environment config
 func (h *Handler) getK8sClientFromEnvironment(environmentID string) (*kubernetes.Clientset, error) {
-	env, err := h.envStorage.Get(environmentID)
+	source, err := h.kubeConfigSource(environmentID, "", "")
 	if err != nil {
-		return nil, fmt.Errorf("failed to get environment: %w", err)
+		return nil, err
 	}
 
-	// Get kubeconfig and context from environment params
-	kubeConfigPath := env.Params["kubeconfig"]
-	kubeContext := env.Params["context"]
-
 	// Get Kubernetes config
-	config, err := k8s.GetKubeConfig(kubeConfigPath, kubeContext)
+	config, err := source.RESTConfig()
 	if err != nil {
 		return nil, fmt.Errorf("failed to load kubeconfig: %w", err)
 	}
@@ -0,0 +1,229 @@
+// Copyright 2026 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handlers
+
+import (
+	"encoding/json"
+	"maps"
+
+	"github.com/inspektor-gadget/ig-desktop/internal/preset"
+	"github.com/inspektor-gadget/ig-desktop/pkg/api"
+	"github.com/inspektor-gadget/ig-desktop/pkg/gadget"
+)
+
+// validatePreset checks the user supplied parts of a preset
+func (h *Handler) validatePreset(p *preset.Preset) error {
+	if p.Image == "" {
+		return &api.ErrInvalidRequest{Reason: "image is required"}
+	}
+	if p.Name == "" {
+		p.Name = p.Image
+	}
+	if p.EnvironmentID != "" {
+		if _, err := h.envStorage.Get(p.EnvironmentID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// HandleListPresets returns all saved presets
+func (h *Handler) HandleListPresets(ev *api.Event) {
+	presets, err := h.presetStorage.List()
+	if err != nil {
+		h.send(ev.SetError(err))
+		return
+	}
+	h.send(ev.SetData(presets))
+}
+
+// HandleCreatePreset saves a new preset
+func (h *Handler) HandleCreatePreset(ev *api.Event) {
+	p := &preset.Preset{}
+	err := json.Unmarshal(ev.Data, p)
+	if err != nil {
+		h.send(ev.SetError(err))
+		return
+	}
+
+	if err := h.validatePreset(p); err != nil {
+		h.send(ev.SetError(err))
+		return
+	}
+
+	err = h.presetStorage.Add(p)
+	if err != nil {
+		h.send(ev.SetError(err))
+		return
+	}
+	h.send(ev.SetData(p))
+}
+
+// HandleUpdatePreset replaces an existing preset
+func (h *Handler) HandleUpdatePreset(ev *api.Event) {
+	p := &preset.Preset{}
+	err := json.Unmarshal(ev.Data, p)
+	if err != nil {
+		h.send(ev.SetError(err))
+		return
+	}
+
+	if _, err := h.presetStorage.Get(p.ID); err != nil {
+		h.send(ev.SetError(err))
+		return
+	}
+	if err := h.validatePreset(p); err != nil {
+		h.send(ev.SetError(err))
+		return
+	}
+
+	err = h.presetStorage.Set(p)
+	if err != nil {
+		h.send(ev.SetError(err))
+		return
+	}
+	h.send(ev.SetData(p))
+}
+
+// HandleDeletePreset deletes a preset
+func (h *Handler) HandleDeletePreset(ev *api.Event) {
+	var req struct {
+		ID string `json:"id"`
+	}
+	err := json.Unmarshal(ev.Data, &req)
+	if err != nil {
+		h.send(ev.SetError(err))
+		return
+	}
+
+	err = h.presetStorage.Delete(req.ID)
+	if err != nil {
+		h.send(ev.SetError(err))
+		return
+	}
+	h.send(ev.SetData(req))
+}
+
+// HandleRunPreset starts the gadget of a preset. The environment of the request
+// takes precedence over the one bound to the preset, and request params are
+// applied on top of the preset params.
+func (h *Handler) HandleRunPreset(ev *api.Event) {
+	var req struct {
+		ID            string            `json:"id"`
+		EnvironmentID string            `json:"environmentID"`
+		Params        map[string]string `json:"params"`
+		Limits        api.RunLimits     `json:"limits"`
+	}
+	err := json.Unmarshal(ev.Data, &req)
+	if err != nil {
+		h.send(ev.SetError(err))
+		return
+	}
+
+	p, err := h.presetStorage.Get(req.ID)
+	if err != nil {
+		h.send(ev.SetError(err))
+		return
+	}
+
+	environmentID := req.EnvironmentID
+	if environmentID == "" {
+		environmentID = p.EnvironmentID
+	}
+	if environmentID == "" {
+		h.send(ev.SetError(&api.ErrInvalidRequest{Reason: "preset is not bound to an environment, environmentID is required"}))
+		return
+	}
+
+	params := make(map[string]string, len(p.Params)+len(req.Params))
+	maps.Copy(params, p.Params)
+	maps.Copy(params, req.Params)
+
+	// Validating the params might have to fetch gadget info from the remote, which mustn't block
+	// other commands
+	go func() {
+		instanceID, err := h.runGadget(gadget.RunRequest{
+			Image:         p.Image,
+			EnvironmentID: environmentID,
+			Params:        params,
+			Record:        p.Record,
+			SessionName:   p.SessionName,
+			Limits:        req.Limits,
+			Sinks:         p.Sinks,
+		})
+		if err != nil {
+			h.send(ev.SetError(err))
+			return
+		}
+
+		// Send acknowledgment with the instance ID and the preset, so the client can
+		// apply the field selection
+		h.send(ev.SetData(struct {
+			ID            string            `json:"id"`
+			EnvironmentID string            `json:"environmentID"`
+			Image         string            `json:"image"`
+			Params        map[string]string `json:"params"`
+			Preset        *preset.Preset    `json:"preset"`
+		}{
+			ID:            instanceID,
+			EnvironmentID: environmentID,
+			Image:         p.Image,
+			Params:        params,
+			Preset:        p,
+		}))
+	}()
+}
+
+// HandleExportPresets returns a bundle of the requested presets (all if no IDs are given), with
+// the values of sink headers redacted
+func (h *Handler) HandleExportPresets(ev *api.Event) {
+	var req struct {
+		IDs []string `json:"ids"`
+	}
+	if len(ev.Data) > 0 {
+		if err := json.Unmarshal(ev.Data, &req); err != nil {
+			h.send(ev.SetError(err))
+			return
+		}
+	}
+
+	bundle, err := h.presetStorage.Export(req.IDs)
+	if err != nil {
+		h.send(ev.SetError(err))
+		return
+	}
+	h.send(ev.SetData(bundle))
+}
+
+// HandleImportPresets adds all presets of a bundle created by exportPresets and reports the sink
+// headers that were redacted on export
+func (h *Handler) HandleImportPresets(ev *api.Event) {
+	var bundle preset.Bundle
+	err := json.Unmarshal(ev.Data, &bundle)
+	if err != nil {
+		h.send(ev.SetError(err))
+		return
+	}
+
+	res, err := h.presetStorage.Import(&bundle, func(id string) bool {
+		_, err := h.envStorage.Get(id)
+		return err == nil
+	})
+	if err != nil {
+		h.send(ev.SetError(err))
+		return
+	}
+	h.send(ev.SetData(res))
+}
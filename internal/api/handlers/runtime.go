@@ -41,6 +41,7 @@ func (h *Handler) HandleGetRuntimes(ev *api.Event) {
 	runtimes := []RuntimeInfo{
 		{Key: "grpc-ig", Title: "IG Daemon", Description: "Connect to Inspektor Gadget running as Daemon"},
 		{Key: "grpc-k8s", Title: "IG on Kubernetes", Description: "Connect to Inspektor Gadget running on a Kubernetes cluster", Contexts: contexts},
+		{Key: "grpc-ssh", Title: "IG Daemon over SSH", Description: "Connect to Inspektor Gadget running as Daemon on hosts reachable over SSH"},
 	}
 	h.send(ev.SetData(runtimes))
 }
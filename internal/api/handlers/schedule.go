@@ -0,0 +1,117 @@
+// Copyright 2026 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/inspektor-gadget/ig-desktop/internal/scheduler"
+	"github.com/inspektor-gadget/ig-desktop/pkg/api"
+)
+
+// HandleCreateSchedule creates a new scheduled gadget run
+func (h *Handler) HandleCreateSchedule(ev *api.Event) {
+	if h.scheduler == nil {
+		h.send(ev.SetError(fmt.Errorf("scheduler not available")))
+		return
+	}
+
+	var schedule scheduler.Schedule
+	err := json.Unmarshal(ev.Data, &schedule)
+	if err != nil {
+		h.send(ev.SetError(err))
+		return
+	}
+
+	err = h.scheduler.Create(&schedule)
+	if err != nil {
+		h.send(ev.SetError(err))
+		return
+	}
+	h.send(ev.SetData(schedule))
+}
+
+// HandleListSchedules lists all schedules including their next activation
+func (h *Handler) HandleListSchedules(ev *api.Event) {
+	if h.scheduler == nil {
+		h.send(ev.SetError(fmt.Errorf("scheduler not available")))
+		return
+	}
+
+	schedules, err := h.scheduler.List()
+	if err != nil {
+		h.send(ev.SetError(err))
+		return
+	}
+	h.send(ev.SetData(schedules))
+}
+
+// HandlePauseSchedule stops a schedule from starting further runs
+func (h *Handler) HandlePauseSchedule(ev *api.Event) {
+	h.setSchedulePaused(ev, true)
+}
+
+// HandleResumeSchedule re-enables a paused schedule
+func (h *Handler) HandleResumeSchedule(ev *api.Event) {
+	h.setSchedulePaused(ev, false)
+}
+
+func (h *Handler) setSchedulePaused(ev *api.Event, paused bool) {
+	if h.scheduler == nil {
+		h.send(ev.SetError(fmt.Errorf("scheduler not available")))
+		return
+	}
+
+	var req struct {
+		ID string `json:"id"`
+	}
+	err := json.Unmarshal(ev.Data, &req)
+	if err != nil {
+		h.send(ev.SetError(err))
+		return
+	}
+
+	schedule, err := h.scheduler.SetPaused(req.ID, paused)
+	if err != nil {
+		h.send(ev.SetError(err))
+		return
+	}
+	h.send(ev.SetData(schedule))
+}
+
+// HandleDeleteSchedule deletes a schedule; sessions recorded by it are kept
+func (h *Handler) HandleDeleteSchedule(ev *api.Event) {
+	if h.scheduler == nil {
+		h.send(ev.SetError(fmt.Errorf("scheduler not available")))
+		return
+	}
+
+	var req struct {
+		ID string `json:"id"`
+	}
+	err := json.Unmarshal(ev.Data, &req)
+	if err != nil {
+		h.send(ev.SetError(err))
+		return
+	}
+
+	err = h.scheduler.Delete(req.ID)
+	if err != nil {
+		h.send(ev.SetError(err))
+		return
+	}
+	h.send(ev.SetData(req))
+}
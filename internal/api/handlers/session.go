@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"log"
 
+	"github.com/inspektor-gadget/ig-desktop/internal/session"
 	"github.com/inspektor-gadget/ig-desktop/pkg/api"
 )
 
@@ -240,6 +241,34 @@ func (h *Handler) HandleGetRunEvents(ev *api.Event) {
 	h.send(ev.SetData(responses))
 }
 
+// HandleGetRunLogs retrieves the log events of a gadget run, filtered by severity, node and source
+func (h *Handler) HandleGetRunLogs(ev *api.Event) {
+	var req struct {
+		SessionID string `json:"sessionId"`
+		RunID     string `json:"runId"`
+		session.LogFilter
+	}
+	err := json.Unmarshal(ev.Data, &req)
+	if err != nil {
+		h.send(ev.SetError(err))
+		return
+	}
+
+	sessionService := h.sessionService
+	if sessionService == nil {
+		h.send(ev.SetError(fmt.Errorf("session service not available")))
+		return
+	}
+
+	logs, err := sessionService.GetRunLogs(req.SessionID, req.RunID, req.LogFilter)
+	if err != nil {
+		h.send(ev.SetError(err))
+		return
+	}
+
+	h.send(ev.SetData(logs))
+}
+
 // HandleDeleteSession deletes a session and its file
 func (h *Handler) HandleDeleteSession(ev *api.Event) {
 	var req struct {
@@ -18,16 +18,18 @@ import (
 	"encoding/json"
 	"log"
 
+	"github.com/inspektor-gadget/ig-desktop/internal/environment"
 	"github.com/inspektor-gadget/ig-desktop/pkg/api"
 )
 
-// HandleHelo handles the initial handshake and sends all environments
+// HandleHelo handles the initial handshake and sends all environments, sorted by group and name
 func (h *Handler) HandleHelo(ev *api.Event) {
 	environments, err := h.envStorage.List()
 	if err != nil {
 		log.Printf("failed to get environments: %v", err)
 		return
 	}
+	environment.SortByGroup(environments)
 
 	for _, env := range environments {
 		d, _ := json.Marshal(env)
@@ -20,14 +20,21 @@ package app
 import (
 	"context"
 	"log"
+	"os"
 
+	"github.com/inspektor-gadget/ig-desktop/internal/alert"
 	"github.com/inspektor-gadget/ig-desktop/internal/api/handlers"
 	"github.com/inspektor-gadget/ig-desktop/internal/artifacthub"
+	"github.com/inspektor-gadget/ig-desktop/internal/collector"
 	"github.com/inspektor-gadget/ig-desktop/internal/config"
+	"github.com/inspektor-gadget/ig-desktop/internal/credentials"
 	"github.com/inspektor-gadget/ig-desktop/internal/environment"
 	"github.com/inspektor-gadget/ig-desktop/internal/plugins"
+	"github.com/inspektor-gadget/ig-desktop/internal/preset"
+	"github.com/inspektor-gadget/ig-desktop/internal/scheduler"
 	"github.com/inspektor-gadget/ig-desktop/internal/session"
 	"github.com/inspektor-gadget/ig-desktop/pkg/gadget"
+	"github.com/inspektor-gadget/ig-desktop/pkg/metrics"
 )
 
 // Services holds the shared backend services used by the application.
@@ -41,7 +48,11 @@ type Services struct {
 	GadgetService   *gadget.Service
 	ArtifactHub     *artifacthub.Client
 	SessionService  *session.Service
+	PresetStorage   *preset.Storage
+	AlertStorage    *alert.Storage
+	CredentialStore *credentials.Store
 	PluginService   *plugins.Service
+	Scheduler       *scheduler.Scheduler
 	Handler         *handlers.Handler
 }
 
@@ -60,9 +71,18 @@ func NewServices() *Services {
 	if err != nil {
 		log.Fatalf("failed to get helm directory: %v", err)
 	}
+	schedulesDir, err := config.GetDir("schedules")
+	if err != nil {
+		log.Fatalf("failed to get schedules directory: %v", err)
+	}
+	presetsDir, err := config.GetDir("presets")
+	if err != nil {
+		log.Fatalf("failed to get presets directory: %v", err)
+	}
 
 	// Initialize storage and services
 	envStorage := environment.NewStorage(envDir)
+	presetStorage := preset.NewStorage(presetsDir)
 	runtimeFactory := environment.NewRuntimeFactory(envStorage)
 	instanceManager := gadget.NewInstanceManager()
 	gadgetService := gadget.NewService(instanceManager)
@@ -91,6 +111,29 @@ func NewServices() *Services {
 		pluginService = nil
 	}
 
+	alertStorage, credentialStore, infoCache := initStores(runtimeFactory)
+
+	// The desktop app only serves its own user, who can use all sinks and the configuration of
+	// the host
+	gadgetService.SetSinkPolicy(newSinkPolicy())
+	runtimeFactory.SetHostPolicy(environment.HostPolicy{AllowKubeconfig: true, AllowSSHKeys: true})
+	if alertStorage != nil {
+		gadgetService.SetAlertStore(alertStorage)
+	}
+	if infoCache != nil {
+		gadgetService.SetInfoCache(infoCache)
+	}
+
+	// Scheduled runs are headless and share the session store with interactive runs
+	sched := scheduler.New(scheduler.NewStorage(schedulesDir), envStorage, runtimeFactory, sessionService)
+	if alertStorage != nil {
+		sched.SetAlertStore(alertStorage)
+	}
+	if infoCache != nil {
+		sched.SetInfoCache(infoCache)
+	}
+	sched.Start(ctx)
+
 	// Create handler with all dependencies (send function will be set in Register)
 	handler := handlers.New(
 		ctx,
@@ -101,6 +144,10 @@ func NewServices() *Services {
 		artifactHubClient,
 		sessionService,
 		pluginService,
+		sched,
+		presetStorage,
+		alertStorage,
+		credentialStore,
 		helmDir,
 	)
 
@@ -112,7 +159,11 @@ func NewServices() *Services {
 		GadgetService:   gadgetService,
 		ArtifactHub:     artifactHubClient,
 		SessionService:  sessionService,
+		PresetStorage:   presetStorage,
+		AlertStorage:    alertStorage,
+		CredentialStore: credentialStore,
 		PluginService:   pluginService,
+		Scheduler:       sched,
 		Handler:         handler,
 	}
 }
@@ -120,13 +171,21 @@ func NewServices() *Services {
 // SharedServices holds services that can be safely shared across multiple
 // client connections. Used by the WebSocket server.
 type SharedServices struct {
-	ctx            context.Context
-	envStorage     *environment.Storage
-	runtimeFactory *environment.RuntimeFactory
-	artifactHub    *artifacthub.Client
-	sessionService *session.Service
-	pluginService  *plugins.Service
-	helmDir        string
+	ctx             context.Context
+	envStorage      *environment.Storage
+	runtimeFactory  *environment.RuntimeFactory
+	artifactHub     *artifacthub.Client
+	sessionService  *session.Service
+	pluginService   *plugins.Service
+	scheduler       *scheduler.Scheduler
+	presetStorage   *preset.Storage
+	alertStorage    *alert.Storage
+	credentialStore *credentials.Store
+	metrics         *metrics.Registry
+	infoCache       *gadget.InfoCache
+	sinkPolicy      gadget.SinkPolicy
+	hostPolicy      environment.HostPolicy
+	helmDir         string
 }
 
 // NewSharedServices creates shared services for multi-client scenarios.
@@ -143,9 +202,18 @@ func NewSharedServices() *SharedServices {
 	if err != nil {
 		log.Fatalf("failed to get helm directory: %v", err)
 	}
+	schedulesDir, err := config.GetDir("schedules")
+	if err != nil {
+		log.Fatalf("failed to get schedules directory: %v", err)
+	}
+	presetsDir, err := config.GetDir("presets")
+	if err != nil {
+		log.Fatalf("failed to get presets directory: %v", err)
+	}
 
 	// Initialize shared storage and services
 	envStorage := environment.NewStorage(envDir)
+	presetStorage := preset.NewStorage(presetsDir)
 	runtimeFactory := environment.NewRuntimeFactory(envStorage)
 	artifactHubClient := artifacthub.NewClient()
 
@@ -170,15 +238,123 @@ func NewSharedServices() *SharedServices {
 		pluginService = nil
 	}
 
+	alertStorage, credentialStore, infoCache := initStores(runtimeFactory)
+
+	// The scheduler runs once per process, independent of connected clients
+	sched := scheduler.New(scheduler.NewStorage(schedulesDir), envStorage, runtimeFactory, sessionService)
+	if alertStorage != nil {
+		sched.SetAlertStore(alertStorage)
+	}
+	if infoCache != nil {
+		sched.SetInfoCache(infoCache)
+	}
+	sched.Start(ctx)
+
 	return &SharedServices{
-		ctx:            ctx,
-		envStorage:     envStorage,
-		runtimeFactory: runtimeFactory,
-		artifactHub:    artifactHubClient,
-		sessionService: sessionService,
-		pluginService:  pluginService,
-		helmDir:        helmDir,
+		ctx:             ctx,
+		envStorage:      envStorage,
+		runtimeFactory:  runtimeFactory,
+		artifactHub:     artifactHubClient,
+		sessionService:  sessionService,
+		pluginService:   pluginService,
+		scheduler:       sched,
+		presetStorage:   presetStorage,
+		alertStorage:    alertStorage,
+		credentialStore: credentialStore,
+		infoCache:       infoCache,
+		helmDir:         helmDir,
+	}
+}
+
+// SetMetrics aggregates the events of all gadget runs, including scheduled ones, into the
+// given registry. Detached instances are attached to in the background, so they are
+// aggregated without a connected client. It has to be called before connections are created.
+func (s *SharedServices) SetMetrics(registry *metrics.Registry) {
+	s.metrics = registry
+	s.scheduler.SetMetrics(registry)
+	collector.New(s.envStorage, s.runtimeFactory, registry).Start(s.ctx, collector.DefaultInterval)
+}
+
+// AllowSinks lets clients forward gadget output to files in the sinks directory and to webhook,
+// OTLP and syslog destinations of their choice. Without it, clients can't use any sinks. It has
+// to be called before connections are created.
+func (s *SharedServices) AllowSinks() {
+	s.sinkPolicy = newSinkPolicy()
+}
+
+// AllowHostKubeconfig lets clients create Kubernetes environments loading kubeconfig files of the
+// host, inline kubeconfigs, or the in-cluster config. Without it, Kubernetes environments use the
+// default kubeconfig of the host.
+func (s *SharedServices) AllowHostKubeconfig() {
+	s.hostPolicy.AllowKubeconfig = true
+	s.runtimeFactory.SetHostPolicy(s.hostPolicy)
+}
+
+// AllowHostSSHKeys lets clients create SSH environments authenticating with key files or the SSH
+// agent of the host, using known hosts files of the host, or skipping host key checking. Without
+// it, SSH environments have to authenticate with a stored credential.
+func (s *SharedServices) AllowHostSSHKeys() {
+	s.hostPolicy.AllowSSHKeys = true
+	s.runtimeFactory.SetHostPolicy(s.hostPolicy)
+}
+
+// initStores initializes the alert storage, the credential store and the gadget info cache.
+// Stores that fail to initialize are nil, disabling the features using them.
+func initStores(runtimeFactory *environment.RuntimeFactory) (*alert.Storage, *credentials.Store, *gadget.InfoCache) {
+	// Initialize alert storage
+	var alertStorage *alert.Storage
+	alertsDir, err := config.GetDir("alerts")
+	if err != nil {
+		log.Printf("failed to get alerts directory: %v (alerting will be disabled)", err)
+	} else {
+		alertStorage, err = alert.NewStorage(alertsDir)
+		if err != nil {
+			log.Printf("failed to initialize alert storage: %v (alerting will be disabled)", err)
+			alertStorage = nil
+		}
+	}
+
+	// Initialize credential store
+	var credentialStore *credentials.Store
+	credentialsDir, err := config.GetDir("credentials")
+	if err != nil {
+		log.Printf("failed to get credentials directory: %v (stored credentials will be disabled)", err)
+	} else {
+		credentialStore, err = credentials.NewStore(credentialsDir, os.Getenv(credentials.PassphraseEnv))
+		if err != nil {
+			log.Printf("failed to initialize credential store: %v (stored credentials will be disabled)", err)
+			credentialStore = nil
+		} else {
+			runtimeFactory.SetCredentialStore(credentialStore)
+		}
+	}
+
+	// Initialize gadget info cache
+	var infoCache *gadget.InfoCache
+	infoCacheDir, err := config.GetDir("gadget-info")
+	if err != nil {
+		log.Printf("failed to get gadget info directory: %v (gadget info caching will be disabled)", err)
+	} else {
+		infoCache, err = gadget.NewInfoCache(infoCacheDir, gadget.DefaultInfoCacheTTL)
+		if err != nil {
+			log.Printf("failed to initialize gadget info cache: %v (gadget info caching will be disabled)", err)
+			infoCache = nil
+		}
+	}
+
+	return alertStorage, credentialStore, infoCache
+}
+
+// newSinkPolicy returns a policy allowing all sinks; file sinks write to the sinks directory
+func newSinkPolicy() gadget.SinkPolicy {
+	policy := gadget.SinkPolicy{AllowNetwork: true}
+	sinksDir, err := config.GetDir("sinks")
+	if err != nil {
+		log.Printf("failed to get sinks directory: %v (file sinks will be disabled)", err)
+	} else {
+		policy.FileDir = sinksDir
 	}
+	return policy
 }
 
 // ConnectionServices holds per-connection service instances.
@@ -198,10 +374,22 @@ func (s *SharedServices) NewConnectionServices() *ConnectionServices {
 	// so that gadget output goes to the correct client
 	instanceManager := gadget.NewInstanceManager()
 	gadgetService := gadget.NewService(instanceManager)
+	gadgetService.SetSinkPolicy(s.sinkPolicy)
 
 	if s.sessionService != nil {
 		gadgetService.SetSessionRecorder(s.sessionService)
 	}
+	if s.alertStorage != nil {
+		gadgetService.SetAlertStore(s.alertStorage)
+	}
+	if s.metrics != nil {
+		// Instances clients attach to are already aggregated by the collector
+		gadgetService.SetMetrics(s.metrics)
+		gadgetService.SkipAttachedMetrics()
+	}
+	if s.infoCache != nil {
+		gadgetService.SetInfoCache(s.infoCache)
+	}
 
 	// Create handler with per-connection gadget service
 	handler := handlers.New(
@@ -213,6 +401,10 @@ func (s *SharedServices) NewConnectionServices() *ConnectionServices {
 		s.artifactHub,
 		s.sessionService,
 		s.pluginService,
+		s.scheduler,
+		s.presetStorage,
+		s.alertStorage,
+		s.credentialStore,
 		s.helmDir,
 	)
 
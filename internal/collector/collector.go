@@ -0,0 +1,155 @@
+// Copyright 2026 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package collector attaches to the detached gadget instances of all environments in the
+// background, so that their events are aggregated into metrics without a connected client.
+package collector
+
+import (
+	"context"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	igapi "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-service/api"
+
+	"github.com/inspektor-gadget/ig-desktop/internal/environment"
+	"github.com/inspektor-gadget/ig-desktop/pkg/api"
+	"github.com/inspektor-gadget/ig-desktop/pkg/gadget"
+	"github.com/inspektor-gadget/ig-desktop/pkg/metrics"
+)
+
+// DefaultInterval is how often environments are checked for new detached instances
+const DefaultInterval = time.Minute
+
+// listTimeout limits listing the instances of a single environment
+const listTimeout = 30 * time.Second
+
+// Collector keeps a run attached to every detached instance that a metric applies to
+type Collector struct {
+	envStorage     *environment.Storage
+	runtimeFactory *environment.RuntimeFactory
+	registry       *metrics.Registry
+	gadgetService  *gadget.Service
+
+	mu       sync.Mutex
+	attached map[string]string // instance key -> ID of the attached run
+	runs     map[string]string // ID of the attached run -> instance key
+}
+
+// New creates a collector feeding the given registry
+func New(envStorage *environment.Storage, runtimeFactory *environment.RuntimeFactory, registry *metrics.Registry) *Collector {
+	gadgetService := gadget.NewService(gadget.NewInstanceManager())
+	gadgetService.SetMetrics(registry)
+
+	c := &Collector{
+		envStorage:     envStorage,
+		runtimeFactory: runtimeFactory,
+		registry:       registry,
+		gadgetService:  gadgetService,
+		attached:       make(map[string]string),
+		runs:           make(map[string]string),
+	}
+	gadgetService.SetSendFunc(c.handleEvent)
+	return c
+}
+
+// Start checks for new detached instances every interval until ctx is done
+func (c *Collector) Start(ctx context.Context, interval time.Duration) {
+	go func() {
+		for {
+			c.sync(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(interval):
+			}
+		}
+	}()
+}
+
+// handleEvent receives the output of the attached runs; events only feed metrics, so only the
+// end of a run is of interest here, allowing to attach again once the instance is back.
+func (c *Collector) handleEvent(ev any) {
+	gev, ok := ev.(*api.GadgetEvent)
+	if !ok || gev.Type != api.TypeGadgetStop {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if key, ok := c.runs[gev.InstanceID]; ok {
+		log.Printf("collector: detached instance %s is no longer attached", key)
+		delete(c.runs, gev.InstanceID)
+		delete(c.attached, key)
+	}
+}
+
+// sync attaches to the detached instances of all environments that aren't attached yet
+func (c *Collector) sync(ctx context.Context) {
+	envs, err := c.envStorage.List()
+	if err != nil {
+		log.Printf("collector: listing environments: %v", err)
+		return
+	}
+	for _, env := range envs {
+		runtime, err := c.runtimeFactory.GetRuntime(env.ID)
+		if err != nil {
+			log.Printf("collector: environment %s: %v", env.Name, err)
+			continue
+		}
+		listCtx, cancel := context.WithTimeout(ctx, listTimeout)
+		instances, err := c.gadgetService.ListInstances(listCtx, runtime)
+		cancel()
+		if err != nil {
+			log.Printf("collector: listing instances of environment %s: %v", env.Name, err)
+			continue
+		}
+
+		for _, instance := range instances {
+			key := env.ID + "/" + instance.Id
+			c.mu.Lock()
+			_, ok := c.attached[key]
+			c.mu.Unlock()
+			if ok || !c.wanted(instance) {
+				continue
+			}
+
+			id, err := c.gadgetService.Attach(ctx, runtime, gadget.AttachRequest{
+				Image:         instance.Id,
+				EnvironmentID: env.ID,
+				InstanceName:  instance.Name,
+			})
+			if err != nil {
+				log.Printf("collector: attaching to instance %s of environment %s: %v", instance.Name, env.Name, err)
+				continue
+			}
+			c.mu.Lock()
+			c.attached[key] = id
+			c.runs[id] = key
+			c.mu.Unlock()
+		}
+	}
+}
+
+// wanted returns whether any metric may apply to the instance
+func (c *Collector) wanted(instance *igapi.GadgetInstance) bool {
+	image := instance.GetGadgetConfig().GetImageName()
+	for _, m := range c.registry.Metrics() {
+		if def := m.Definition(); def.Image == "" || strings.Contains(image, def.Image) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,94 @@
+// Copyright 2026 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"testing"
+
+	igapi "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-service/api"
+
+	"github.com/inspektor-gadget/ig-desktop/pkg/api"
+	"github.com/inspektor-gadget/ig-desktop/pkg/metrics"
+)
+
+func newTestCollector(t *testing.T, defs []metrics.Definition) *Collector {
+	t.Helper()
+	registry, err := metrics.NewRegistry(defs)
+	if err != nil {
+		t.Fatalf("NewRegistry: %v", err)
+	}
+	return New(nil, nil, registry)
+}
+
+func TestWanted(t *testing.T) {
+	instance := &igapi.GadgetInstance{
+		Id:           "abc",
+		GadgetConfig: &igapi.GadgetRunRequest{ImageName: "ghcr.io/inspektor-gadget/gadget/trace_exec:latest"},
+	}
+
+	tests := []struct {
+		name string
+		defs []metrics.Definition
+		want bool
+	}{
+		{
+			name: "no metrics",
+			want: false,
+		},
+		{
+			name: "any image",
+			defs: []metrics.Definition{{Name: "events_total", Type: metrics.TypeCounter}},
+			want: true,
+		},
+		{
+			name: "matching image",
+			defs: []metrics.Definition{{Name: "execs_total", Type: metrics.TypeCounter, Image: "trace_exec"}},
+			want: true,
+		},
+		{
+			name: "other image",
+			defs: []metrics.Definition{{Name: "opens_total", Type: metrics.TypeCounter, Image: "trace_open"}},
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestCollector(t, tt.defs)
+			if got := c.wanted(instance); got != tt.want {
+				t.Errorf("wanted() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandleEventStop(t *testing.T) {
+	c := newTestCollector(t, nil)
+	c.attached["env/abc"] = "run1"
+	c.runs["run1"] = "env/abc"
+
+	// Other events keep the instance attached
+	c.handleEvent(&api.GadgetEvent{Type: api.TypeGadgetLog, InstanceID: "run1"})
+	if _, ok := c.attached["env/abc"]; !ok {
+		t.Fatalf("instance detached on log event")
+	}
+
+	c.handleEvent(&api.GadgetEvent{Type: api.TypeGadgetStop, InstanceID: "run1"})
+	if _, ok := c.attached["env/abc"]; ok {
+		t.Errorf("instance still attached after stop")
+	}
+	if _, ok := c.runs["run1"]; ok {
+		t.Errorf("run still tracked after stop")
+	}
+}
@@ -0,0 +1,390 @@
+// Copyright 2026 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package credentials keeps TLS material and SSH keys for environments in a managed directory. Environments
+// reference stored credentials by ID instead of pointing to files anywhere on disk.
+package credentials
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/ssh"
+
+	"github.com/inspektor-gadget/ig-desktop/pkg/api"
+)
+
+// PassphraseEnv is the environment variable holding the passphrase to encrypt credentials with.
+// Credentials are stored unencrypted if it isn't set.
+const PassphraseEnv = "IG_DESKTOP_CREDENTIALS_PASSPHRASE"
+
+// RefPrefix marks param values that reference a stored credential, e.g. "credential:<id>"
+const RefPrefix = "credential:"
+
+// DefaultExpiryWarning is how long before their expiry certificates are reported as expiring
+const DefaultExpiryWarning = 30 * 24 * time.Hour
+
+// Kinds of credentials
+const (
+	KindKey  = "key"  // private key
+	KindCert = "cert" // client certificate
+	KindCA   = "ca"   // one or more CA certificates
+)
+
+const (
+	saltFile       = "salt"
+	kdfIterations  = 600_000
+	encryptionSalt = 16
+)
+
+// Credential describes stored PEM material; the material itself is only returned by Data
+type Credential struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Kind      string `json:"kind"`
+	Created   int64  `json:"created"` // unix ms
+	Encrypted bool   `json:"encrypted"`
+
+	// Subject and NotAfter are set for certificates; NotAfter is the earliest expiry of all
+	// certificates of the credential
+	Subject  string `json:"subject,omitempty"`
+	NotAfter int64  `json:"notAfter,omitempty"` // unix ms
+}
+
+// Expiry states of a credential
+const (
+	StatusValid    = "valid"
+	StatusExpiring = "expiring"
+	StatusExpired  = "expired"
+)
+
+// Expires returns whether a certificate expires before now+within
+func (c *Credential) Expires(now time.Time, within time.Duration) bool {
+	return c.NotAfter != 0 && now.Add(within).After(time.UnixMilli(c.NotAfter))
+}
+
+// Status returns the expiry state of a credential; keys are always valid
+func (c *Credential) Status(now time.Time, within time.Duration) string {
+	switch {
+	case c.Expires(now, 0):
+		return StatusExpired
+	case c.Expires(now, within):
+		return StatusExpiring
+	}
+	return StatusValid
+}
+
+// Ref returns the param value referencing a credential
+func Ref(id string) string {
+	return RefPrefix + id
+}
+
+// ParseRef returns the ID of the credential referenced by a param value
+func ParseRef(value string) (string, bool) {
+	return strings.CutPrefix(value, RefPrefix)
+}
+
+// Store keeps credentials as one metadata and one data file per credential. The directory and
+// files are only accessible by the current user; with a passphrase, data files are encrypted
+// using AES-GCM.
+type Store struct {
+	dir string
+	key []byte // nil without passphrase
+
+	mu sync.Mutex
+}
+
+// NewStore creates a store in dir. If passphrase is not empty, newly imported credentials are
+// encrypted and encrypted credentials can be read.
+func NewStore(dir string, passphrase string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("creating credentials directory: %w", err)
+	}
+	if err := os.Chmod(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("restricting credentials directory: %w", err)
+	}
+
+	s := &Store{dir: dir}
+	if passphrase == "" {
+		return s, nil
+	}
+
+	saltPath := filepath.Join(dir, saltFile)
+	salt, err := os.ReadFile(saltPath)
+	if errors.Is(err, os.ErrNotExist) {
+		salt = make([]byte, encryptionSalt)
+		if _, err := rand.Read(salt); err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(saltPath, salt, 0o600); err != nil {
+			return nil, fmt.Errorf("writing salt: %w", err)
+		}
+	} else if err != nil {
+		return nil, fmt.Errorf("reading salt: %w", err)
+	}
+	s.key, err = pbkdf2.Key(sha256.New, passphrase, salt, kdfIterations, 32)
+	if err != nil {
+		return nil, fmt.Errorf("deriving key: %w", err)
+	}
+	return s, nil
+}
+
+func (s *Store) metaPath(id string) string {
+	return filepath.Join(s.dir, id+".json")
+}
+
+func (s *Store) dataPath(id string) string {
+	return filepath.Join(s.dir, id+".pem")
+}
+
+// parse validates PEM material of the given kind and fills in certificate details
+func parse(c *Credential, data []byte) error {
+	switch c.Kind {
+	case KindKey:
+		block, _ := pem.Decode(data)
+		if block == nil || !strings.Contains(block.Type, "PRIVATE KEY") {
+			return fmt.Errorf("no PEM encoded private key found")
+		}
+		// Parsing a key pair needs a certificate, so check the key on its own
+		if _, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+			return nil
+		}
+		if _, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+			return nil
+		}
+		if _, err := x509.ParseECPrivateKey(block.Bytes); err == nil {
+			return nil
+		}
+		// OpenSSH keys, as used by SSH environments
+		if _, err := ssh.ParseRawPrivateKey(data); err == nil {
+			return nil
+		}
+		return fmt.Errorf("unsupported private key")
+	case KindCert, KindCA:
+		rest := data
+		for {
+			var block *pem.Block
+			block, rest = pem.Decode(rest)
+			if block == nil {
+				break
+			}
+			if block.Type != "CERTIFICATE" {
+				continue
+			}
+			cert, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				return fmt.Errorf("parsing certificate: %w", err)
+			}
+			if c.Subject == "" {
+				c.Subject = cert.Subject.String()
+			}
+			if notAfter := cert.NotAfter.UnixMilli(); c.NotAfter == 0 || notAfter < c.NotAfter {
+				c.NotAfter = notAfter
+			}
+		}
+		if c.NotAfter == 0 {
+			return fmt.Errorf("no PEM encoded certificate found")
+		}
+		return nil
+	}
+	return fmt.Errorf("invalid kind %q", c.Kind)
+}
+
+// Import validates and stores PEM material
+func (s *Store) Import(name, kind string, data []byte) (*Credential, error) {
+	c := &Credential{
+		ID:      uuid.New().String(),
+		Name:    name,
+		Kind:    kind,
+		Created: time.Now().UnixMilli(),
+	}
+	if err := parse(c, data); err != nil {
+		return nil, &api.ErrInvalidRequest{Reason: fmt.Sprintf("invalid %s: %v", kind, err)}
+	}
+	if c.Name == "" {
+		c.Name = c.Subject
+	}
+
+	if s.key != nil {
+		var err error
+		data, err = s.encrypt(data)
+		if err != nil {
+			return nil, err
+		}
+		c.Encrypted = true
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := os.WriteFile(s.dataPath(c.ID), data, 0o600); err != nil {
+		return nil, fmt.Errorf("writing credential: %w", err)
+	}
+	meta, _ := json.Marshal(c)
+	if err := os.WriteFile(s.metaPath(c.ID), meta, 0o600); err != nil {
+		os.Remove(s.dataPath(c.ID))
+		return nil, fmt.Errorf("writing credential: %w", err)
+	}
+	return c, nil
+}
+
+// Get returns the metadata of a credential
+func (s *Store) Get(id string) (*Credential, error) {
+	if err := uuid.Validate(id); err != nil {
+		return nil, &api.ErrCredentialNotFound{ID: id}
+	}
+	b, err := os.ReadFile(s.metaPath(id))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, &api.ErrCredentialNotFound{ID: id}
+		}
+		return nil, fmt.Errorf("reading credential: %w", err)
+	}
+	c := &Credential{}
+	if err := json.Unmarshal(b, c); err != nil {
+		return nil, fmt.Errorf("parsing credential: %w", err)
+	}
+	return c, nil
+}
+
+// List returns all credentials, sorted by name
+func (s *Store) List() ([]*Credential, error) {
+	files, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]*Credential, 0, len(files))
+	for _, file := range files {
+		id, ok := strings.CutSuffix(file.Name(), ".json")
+		if !ok {
+			continue
+		}
+		c, err := s.Get(id)
+		if err != nil {
+			// Skip invalid files
+			continue
+		}
+		res = append(res, c)
+	}
+	slices.SortFunc(res, func(a, b *Credential) int { return strings.Compare(a.Name, b.Name) })
+	return res, nil
+}
+
+// Data returns the decrypted PEM material of a credential
+func (s *Store) Data(id string) ([]byte, error) {
+	c, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(s.dataPath(id))
+	if err != nil {
+		return nil, fmt.Errorf("reading credential: %w", err)
+	}
+	if !c.Encrypted {
+		return data, nil
+	}
+	if s.key == nil {
+		return nil, fmt.Errorf("credential %q is encrypted, but %s is not set", c.Name, PassphraseEnv)
+	}
+	return s.decrypt(data)
+}
+
+// Delete removes a credential
+func (s *Store) Delete(id string) error {
+	if _, err := s.Get(id); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := os.Remove(s.dataPath(id)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return os.Remove(s.metaPath(id))
+}
+
+// Expiring returns the certificates that expire within the given duration or already expired
+func (s *Store) Expiring(within time.Duration) ([]*Credential, error) {
+	list, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	return slices.DeleteFunc(list, func(c *Credential) bool {
+		return !c.Expires(now, within)
+	}), nil
+}
+
+func (s *Store) encrypt(data []byte) ([]byte, error) {
+	gcm, err := s.gcm()
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, data, nil), nil
+}
+
+func (s *Store) decrypt(data []byte) ([]byte, error) {
+	gcm, err := s.gcm()
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < gcm.NonceSize() {
+		return nil, fmt.Errorf("decrypting credential: data too short")
+	}
+	nonce, ciphertext := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+	plain, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("decrypting credential: wrong passphrase or corrupted data")
+	}
+	return plain, nil
+}
+
+func (s *Store) gcm() (cipher.AEAD, error) {
+	block, err := aes.NewCipher(s.key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// KeyPair checks that a stored key and certificate belong together
+func (s *Store) KeyPair(certID, keyID string) (tls.Certificate, error) {
+	cert, err := s.Data(certID)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	key, err := s.Data(keyID)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	return tls.X509KeyPair(cert, key)
+}
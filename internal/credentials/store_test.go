@@ -0,0 +1,191 @@
+// Copyright 2026 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package credentials
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+
+	"github.com/inspektor-gadget/ig-desktop/pkg/api"
+)
+
+// newCert returns a PEM encoded self-signed certificate and its key
+func newCert(t *testing.T, notAfter time.Time) ([]byte, []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})
+}
+
+func TestStoreEncrypted(t *testing.T) {
+	dir := t.TempDir()
+	certPEM, keyPEM := newCert(t, time.Now().Add(365*24*time.Hour))
+
+	s, err := NewStore(dir, "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := s.Import("client key", KindKey, keyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := s.Import("", KindCert, certPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !key.Encrypted || cert.Name != "CN=test" {
+		t.Fatalf("unexpected credentials %+v, %+v", key, cert)
+	}
+
+	raw, err := os.ReadFile(filepath.Join(dir, key.ID+".pem"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(raw, []byte("PRIVATE KEY")) {
+		t.Fatal("key is stored in plain text")
+	}
+	if fi, err := os.Stat(dir); err != nil || fi.Mode().Perm() != 0o700 {
+		t.Fatalf("unexpected directory permissions: %v, %v", fi.Mode(), err)
+	}
+
+	// A new store with the same passphrase reads the material back
+	s, err = NewStore(dir, "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := s.Data(key.ID)
+	if err != nil || !bytes.Equal(data, keyPEM) {
+		t.Fatalf("unexpected key data: %v", err)
+	}
+	if _, err := s.KeyPair(cert.ID, key.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	wrong, err := NewStore(dir, "wrong")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wrong.Data(key.ID); err == nil {
+		t.Error("decrypted with wrong passphrase")
+	}
+	plain, err := NewStore(dir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := plain.Data(key.ID); err == nil {
+		t.Error("read encrypted credential without passphrase")
+	}
+
+	if err := s.Delete(key.ID); err != nil {
+		t.Fatal(err)
+	}
+	var notFound *api.ErrCredentialNotFound
+	if _, err := s.Get(key.ID); !errors.As(err, &notFound) {
+		t.Errorf("expected not found, got %v", err)
+	}
+}
+
+func TestStoreValidation(t *testing.T) {
+	s, err := NewStore(t.TempDir(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPEM, keyPEM := newCert(t, time.Now().Add(time.Hour))
+
+	var invalid *api.ErrInvalidRequest
+	if _, err := s.Import("", KindKey, certPEM); !errors.As(err, &invalid) {
+		t.Errorf("certificate accepted as key: %v", err)
+	}
+	if _, err := s.Import("", KindCA, keyPEM); !errors.As(err, &invalid) {
+		t.Errorf("key accepted as CA: %v", err)
+	}
+	if _, err := s.Import("", "password", keyPEM); !errors.As(err, &invalid) {
+		t.Errorf("invalid kind accepted: %v", err)
+	}
+
+	// SSH environments use keys in the OpenSSH format
+	_, sshKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, err := ssh.MarshalPrivateKey(sshKey, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Import("ssh key", KindKey, pem.EncodeToMemory(block)); err != nil {
+		t.Errorf("OpenSSH key rejected: %v", err)
+	}
+}
+
+func TestStoreExpiry(t *testing.T) {
+	s, err := NewStore(t.TempDir(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	for name, notAfter := range map[string]time.Time{
+		"expired":  now.Add(-time.Hour),
+		"expiring": now.Add(24 * time.Hour),
+		"valid":    now.Add(90 * 24 * time.Hour),
+	} {
+		certPEM, _ := newCert(t, notAfter)
+		c, err := s.Import(name, KindCA, certPEM)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if status := c.Status(now, DefaultExpiryWarning); status != name {
+			t.Errorf("%s: unexpected status %q", name, status)
+		}
+	}
+
+	expiring, err := s.Expiring(DefaultExpiryWarning)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(expiring) != 2 || expiring[0].Name != "expired" || expiring[1].Name != "expiring" {
+		t.Errorf("unexpected expiring credentials %+v", expiring)
+	}
+}
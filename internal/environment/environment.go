@@ -14,10 +14,79 @@
 
 package environment
 
+import (
+	"github.com/inspektor-gadget/ig-desktop/pkg/api"
+	"github.com/inspektor-gadget/ig-desktop/pkg/k8s"
+)
+
 // Environment represents a runtime environment configuration
 type Environment struct {
 	ID      string            `json:"id"`
 	Name    string            `json:"name"`
 	Runtime string            `json:"runtime"`
 	Params  map[string]string `json:"params"`
+
+	// Group is the folder the environment is listed in; nested folders are separated by "/"
+	Group string `json:"group,omitempty"`
+	// Labels are arbitrary key/value pairs to select environments by
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// Limits are the default run limits for gadgets started in this environment
+	Limits *api.RunLimits `json:"limits,omitempty"`
+	// DefaultParams are gadget params applied to every run in this environment that doesn't set them
+	DefaultParams map[string]string `json:"defaultParams,omitempty"`
+
+	// KubeContext is set for grpc-k8s environments and identifies the cluster and user of their context
+	KubeContext *KubeContextSource `json:"kubeContext,omitempty"`
+}
+
+// Update changes some fields of an environment. Omitted fields keep their current values; maps and
+// limits that are given replace the current ones as a whole, so that an empty map or empty limits
+// clear them.
+type Update struct {
+	ID            string             `json:"id"`
+	Name          *string            `json:"name"`
+	Runtime       *string            `json:"runtime"`
+	Params        map[string]string  `json:"params"`
+	Group         *string            `json:"group"`
+	Labels        map[string]string  `json:"labels"`
+	Limits        *api.RunLimits     `json:"limits"`
+	DefaultParams map[string]string  `json:"defaultParams"`
+	KubeContext   *KubeContextSource `json:"kubeContext"`
+}
+
+// Apply returns a copy of env with the update applied. The kube context source is dropped if the
+// context changes without a new source being given.
+func (u *Update) Apply(env *Environment) *Environment {
+	res := *env
+	if u.Name != nil && *u.Name != "" {
+		res.Name = *u.Name
+	}
+	if u.Runtime != nil && *u.Runtime != "" {
+		res.Runtime = *u.Runtime
+	}
+	if u.Params != nil {
+		res.Params = u.Params
+	}
+	if u.Group != nil {
+		res.Group = *u.Group
+	}
+	if u.Labels != nil {
+		res.Labels = u.Labels
+	}
+	if u.Limits != nil {
+		res.Limits = u.Limits
+		if u.Limits.IsZero() {
+			res.Limits = nil
+		}
+	}
+	if u.DefaultParams != nil {
+		res.DefaultParams = u.DefaultParams
+	}
+	if u.KubeContext != nil {
+		res.KubeContext = u.KubeContext
+	} else if res.Params[k8s.ParamContext] != env.Params[k8s.ParamContext] {
+		res.KubeContext = nil
+	}
+	return &res
 }
@@ -0,0 +1,289 @@
+// Copyright 2026 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package environment
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+	"sigs.k8s.io/yaml"
+
+	"github.com/inspektor-gadget/ig-desktop/pkg/api"
+	grpcruntime "github.com/inspektor-gadget/ig-desktop/pkg/grpc-runtime"
+	"github.com/inspektor-gadget/ig-desktop/pkg/k8s"
+)
+
+// Formats of exported environments
+const (
+	ExportFormatJSON = "json"
+	ExportFormatYAML = "yaml"
+)
+
+// ExportVersion is the version of the format written by ExportEnvironments
+const ExportVersion = 1
+
+// RedactedValue replaces the values of secret params in exports
+const RedactedValue = "<redacted>"
+
+// Ways to resolve name conflicts on import
+const (
+	ConflictSkip    = "skip"    // keep the existing environment
+	ConflictRename  = "rename"  // import under a new name
+	ConflictReplace = "replace" // overwrite the existing environment, keeping its ID
+)
+
+// secretParams are params whose values are local to a machine or confidential
+var secretParams = []string{
+	grpcruntime.ParamTLSKey,
+	grpcruntime.ParamTLSCert,
+	grpcruntime.ParamTLSServerCA,
+	grpcruntime.ParamSSHKeyFile,
+	k8s.ParamKubeconfigData,
+}
+
+func isSecretParam(key string) bool {
+	if slices.Contains(secretParams, key) {
+		return true
+	}
+	key = strings.ToLower(key)
+	for _, s := range []string{"password", "token", "secret"} {
+		if strings.Contains(key, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactParam returns the value of a param as exported. Secret params are replaced entirely,
+// while only the credentials are removed from the proxy URL.
+func redactParam(key, value string) string {
+	if value == "" {
+		return value
+	}
+	if isSecretParam(key) {
+		return RedactedValue
+	}
+	if key == k8s.ParamProxyURL {
+		if u, err := url.Parse(value); err == nil && u.User != nil {
+			u.User = nil
+			return strings.Replace(u.String(), "://", "://"+RedactedValue+"@", 1)
+		}
+	}
+	return value
+}
+
+// isRedacted returns true if the value of a param was redacted by redactParam
+func isRedacted(key, value string) bool {
+	if key == k8s.ParamProxyURL {
+		return strings.Contains(value, "://"+RedactedValue+"@")
+	}
+	return value == RedactedValue
+}
+
+// EnvironmentBundle is the exchange format for environments
+type EnvironmentBundle struct {
+	Version      int            `json:"version"`
+	Environments []*Environment `json:"environments"`
+}
+
+// ExportEnvironments returns the environments with the given IDs (all if empty) in the given
+// format. The values of secret params are replaced with RedactedValue.
+func ExportEnvironments(storage *Storage, ids []string, format string) ([]byte, error) {
+	var envs []*Environment
+	if len(ids) == 0 {
+		all, err := storage.List()
+		if err != nil {
+			return nil, err
+		}
+		envs = all
+	} else {
+		for _, id := range ids {
+			env, err := storage.Get(id)
+			if err != nil {
+				return nil, err
+			}
+			envs = append(envs, env)
+		}
+	}
+	slices.SortFunc(envs, func(a, b *Environment) int { return strings.Compare(a.Name, b.Name) })
+
+	bundle := &EnvironmentBundle{Version: ExportVersion, Environments: envs}
+	for _, env := range envs {
+		params := maps.Clone(env.Params)
+		for key, value := range params {
+			params[key] = redactParam(key, value)
+		}
+		env.Params = params
+	}
+
+	switch format {
+	case ExportFormatJSON, "":
+		return json.MarshalIndent(bundle, "", "  ")
+	case ExportFormatYAML:
+		return yaml.Marshal(bundle)
+	default:
+		return nil, &api.ErrInvalidRequest{Reason: fmt.Sprintf("unsupported format %q", format)}
+	}
+}
+
+// ImportOptions control how ImportEnvironments treats IDs and conflicts
+type ImportOptions struct {
+	KeepIDs    bool   `json:"keepIDs"`    // use the IDs of the bundle instead of generating new ones
+	OnConflict string `json:"onConflict"` // ConflictSkip (default), ConflictRename or ConflictReplace
+}
+
+// ImportResult lists the outcome of ImportEnvironments
+type ImportResult struct {
+	Created  []*Environment `json:"created"`
+	Replaced []*Environment `json:"replaced"`
+	Skipped  []string       `json:"skipped"` // names of environments that already existed
+	// Redacted lists the params per imported environment ID that have to be set before use
+	Redacted map[string][]string `json:"redacted"`
+	Errors   []string            `json:"errors"`
+}
+
+// ImportEnvironments stores the environments of a bundle written by ExportEnvironments, in
+// either JSON or YAML. Conflicts are detected by name. Redacted params aren't stored but
+// reported, so that they can be filled in afterwards; replaced environments keep their current
+// values instead. Environments are checked using validate, like RuntimeFactory.ValidateParams,
+// and reported in the errors if invalid.
+func ImportEnvironments(storage *Storage, data []byte, opts ImportOptions, validate func(runtimeType string, params map[string]string) error) (*ImportResult, error) {
+	switch opts.OnConflict {
+	case "":
+		opts.OnConflict = ConflictSkip
+	case ConflictSkip, ConflictRename, ConflictReplace:
+	default:
+		return nil, &api.ErrInvalidRequest{Reason: fmt.Sprintf("invalid conflict resolution %q", opts.OnConflict)}
+	}
+
+	// JSON is valid YAML, so both formats are read the same way
+	bundle := &EnvironmentBundle{}
+	if err := yaml.Unmarshal(data, bundle); err != nil {
+		return nil, &api.ErrInvalidRequest{Reason: fmt.Sprintf("parsing environments: %v", err)}
+	}
+	if bundle.Version != ExportVersion {
+		return nil, &api.ErrInvalidRequest{Reason: fmt.Sprintf("unsupported environment bundle version %d", bundle.Version)}
+	}
+
+	existing, err := storage.List()
+	if err != nil {
+		return nil, err
+	}
+	byName := make(map[string]*Environment, len(existing))
+	byID := make(map[string]*Environment, len(existing))
+	for _, env := range existing {
+		byName[env.Name] = env
+		byID[env.ID] = env
+	}
+
+	res := &ImportResult{
+		Created:  []*Environment{},
+		Replaced: []*Environment{},
+		Skipped:  []string{},
+		Redacted: map[string][]string{},
+		Errors:   []string{},
+	}
+	for _, env := range bundle.Environments {
+		if env == nil || env.Name == "" || env.Runtime == "" {
+			res.Errors = append(res.Errors, "skipping environment without name or runtime")
+			continue
+		}
+
+		if err := env.Normalize(); err != nil {
+			res.Errors = append(res.Errors, fmt.Sprintf("%s: %v", env.Name, err))
+			continue
+		}
+
+		var redacted []string
+		for key, value := range env.Params {
+			if isRedacted(key, value) {
+				redacted = append(redacted, key)
+				delete(env.Params, key)
+			}
+		}
+		slices.Sort(redacted)
+
+		var replaced *Environment
+		if current, ok := byName[env.Name]; ok {
+			switch opts.OnConflict {
+			case ConflictSkip:
+				res.Skipped = append(res.Skipped, env.Name)
+				continue
+			case ConflictRename:
+				env.Name = uniqueName(env.Name, byName)
+			case ConflictReplace:
+				env.ID = current.ID
+				replaced = current
+			}
+		}
+
+		if replaced == nil {
+			if !opts.KeepIDs || uuid.Validate(env.ID) != nil {
+				env.ID = uuid.New().String()
+			} else if current, ok := byID[env.ID]; ok {
+				res.Errors = append(res.Errors, fmt.Sprintf("%s: ID %s is already used by %q", env.Name, env.ID, current.Name))
+				continue
+			}
+		} else {
+			// Keep the local values of redacted params
+			redacted = slices.DeleteFunc(redacted, func(key string) bool {
+				value, ok := replaced.Params[key]
+				if ok {
+					if env.Params == nil {
+						env.Params = map[string]string{}
+					}
+					env.Params[key] = value
+				}
+				return ok
+			})
+		}
+
+		if err := validate(env.Runtime, env.Params); err != nil {
+			res.Errors = append(res.Errors, fmt.Sprintf("%s: %v", env.Name, err))
+			continue
+		}
+
+		if err := storage.Set(env); err != nil {
+			res.Errors = append(res.Errors, fmt.Sprintf("%s: %v", env.Name, err))
+			continue
+		}
+		byName[env.Name] = env
+		byID[env.ID] = env
+		if replaced != nil {
+			res.Replaced = append(res.Replaced, env)
+		} else {
+			res.Created = append(res.Created, env)
+		}
+		if len(redacted) > 0 {
+			res.Redacted[env.ID] = redacted
+		}
+	}
+	return res, nil
+}
+
+// uniqueName appends a counter to name until it isn't taken
+func uniqueName(name string, taken map[string]*Environment) string {
+	for i := 2; ; i++ {
+		candidate := fmt.Sprintf("%s (%d)", name, i)
+		if _, ok := taken[candidate]; !ok {
+			return candidate
+		}
+	}
+}
@@ -0,0 +1,135 @@
+// Copyright 2026 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package environment
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+
+	"github.com/inspektor-gadget/ig-desktop/pkg/api"
+)
+
+// NormalizeGroup trims blanks and empty segments from a "/"-separated group, so that
+// " prod//eu/ " and "prod/eu" are the same group
+func NormalizeGroup(group string) string {
+	var segments []string
+	for _, s := range strings.Split(group, "/") {
+		if s = strings.TrimSpace(s); s != "" {
+			segments = append(segments, s)
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// Normalize normalizes the group of the environment and checks its labels and default params
+func (e *Environment) Normalize() error {
+	e.Group = NormalizeGroup(e.Group)
+	for key := range e.Labels {
+		if strings.TrimSpace(key) == "" {
+			return &api.ErrInvalidRequest{Reason: "label keys must not be empty"}
+		}
+	}
+	for key := range e.DefaultParams {
+		if strings.TrimSpace(key) == "" {
+			return &api.ErrInvalidRequest{Reason: "default param keys must not be empty"}
+		}
+	}
+	return nil
+}
+
+// Selector selects environments by group and labels
+type Selector struct {
+	// Group selects the environments of a group and of all groups nested in it
+	Group string `json:"group"`
+	// Labels selects environments that have all of the given labels; an empty value only
+	// requires the label to be present
+	Labels map[string]string `json:"labels"`
+}
+
+// Matches returns true if the environment is selected
+func (s Selector) Matches(env *Environment) bool {
+	if group := NormalizeGroup(s.Group); group != "" {
+		if env.Group != group && !strings.HasPrefix(env.Group, group+"/") {
+			return false
+		}
+	}
+	for key, value := range s.Labels {
+		v, ok := env.Labels[key]
+		if !ok || (value != "" && v != value) {
+			return false
+		}
+	}
+	return true
+}
+
+// Select returns the environments matched by the selector, sorted by group and name
+func Select(environments []*Environment, selector Selector) []*Environment {
+	res := make([]*Environment, 0, len(environments))
+	for _, env := range environments {
+		if selector.Matches(env) {
+			res = append(res, env)
+		}
+	}
+	SortByGroup(res)
+	return res
+}
+
+// SortByGroup sorts environments by group and name; ungrouped environments come first
+func SortByGroup(environments []*Environment) {
+	slices.SortFunc(environments, func(a, b *Environment) int {
+		return cmp.Or(strings.Compare(a.Group, b.Group), strings.Compare(a.Name, b.Name))
+	})
+}
+
+// Groups returns all groups the environments are in, including the parents of nested groups, sorted
+func Groups(environments []*Environment) []string {
+	seen := make(map[string]bool)
+	for _, env := range environments {
+		for group := env.Group; group != ""; {
+			if seen[group] {
+				break
+			}
+			seen[group] = true
+			i := strings.LastIndex(group, "/")
+			if i < 0 {
+				break
+			}
+			group = group[:i]
+		}
+	}
+	res := make([]string, 0, len(seen))
+	for group := range seen {
+		res = append(res, group)
+	}
+	slices.Sort(res)
+	return res
+}
+
+// Labels returns all label keys used by the environments with their values, sorted
+func Labels(environments []*Environment) map[string][]string {
+	res := make(map[string][]string)
+	for _, env := range environments {
+		for key, value := range env.Labels {
+			if !slices.Contains(res[key], value) {
+				res[key] = append(res[key], value)
+			}
+		}
+	}
+	for key := range res {
+		slices.Sort(res[key])
+	}
+	return res
+}
@@ -0,0 +1,72 @@
+// Copyright 2026 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package environment
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSelect(t *testing.T) {
+	envs := []*Environment{
+		{Name: "b", Group: "prod/eu", Labels: map[string]string{"team": "infra"}},
+		{Name: "a", Group: "prod/eu", Labels: map[string]string{"team": "web"}},
+		{Name: "c", Group: "prod/us"},
+		{Name: "d", Group: "production"},
+		{Name: "e"},
+	}
+
+	names := func(envs []*Environment) []string {
+		var res []string
+		for _, env := range envs {
+			res = append(res, env.Name)
+		}
+		return res
+	}
+	for _, tc := range []struct {
+		selector Selector
+		expected []string
+	}{
+		{Selector{}, []string{"e", "a", "b", "c", "d"}},
+		{Selector{Group: "prod"}, []string{"a", "b", "c"}},
+		{Selector{Group: " /prod/eu/ "}, []string{"a", "b"}},
+		{Selector{Labels: map[string]string{"team": ""}}, []string{"a", "b"}},
+		{Selector{Group: "prod", Labels: map[string]string{"team": "infra"}}, []string{"b"}},
+	} {
+		if got := names(Select(envs, tc.selector)); !slices.Equal(got, tc.expected) {
+			t.Errorf("%+v: expected %v, got %v", tc.selector, tc.expected, got)
+		}
+	}
+
+	if groups := Groups(envs); !slices.Equal(groups, []string{"prod", "prod/eu", "prod/us", "production"}) {
+		t.Errorf("unexpected groups %v", groups)
+	}
+	if labels := Labels(envs); !slices.Equal(labels["team"], []string{"infra", "web"}) {
+		t.Errorf("unexpected labels %v", labels)
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	env := &Environment{Group: " prod//eu/ "}
+	if err := env.Normalize(); err != nil || env.Group != "prod/eu" {
+		t.Errorf("unexpected group %q: %v", env.Group, err)
+	}
+	if err := (&Environment{Labels: map[string]string{" ": "x"}}).Normalize(); err == nil {
+		t.Error("expected error for empty label key")
+	}
+	if err := (&Environment{DefaultParams: map[string]string{"": "x"}}).Normalize(); err == nil {
+		t.Error("expected error for empty default param key")
+	}
+}
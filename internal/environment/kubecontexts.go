@@ -0,0 +1,199 @@
+// Copyright 2026 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package environment
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/inspektor-gadget/ig-desktop/pkg/api"
+	"github.com/inspektor-gadget/ig-desktop/pkg/k8s"
+)
+
+// KubeContext describes a context of the kubeconfig
+type KubeContext struct {
+	Name      string `json:"name"`
+	Server    string `json:"server"`
+	User      string `json:"user"`
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// KubeContextSource records which cluster and user the context of an imported environment
+// pointed to, so that the environment can follow renames of the context
+type KubeContextSource struct {
+	Server string `json:"server"`
+	User   string `json:"user"`
+}
+
+// ListKubeContexts returns the contexts of the current kubeconfig, sorted by name
+func ListKubeContexts() ([]KubeContext, error) {
+	cc, err := k8s.ConfigSource{}.ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := cc.RawConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]KubeContext, 0, len(cfg.Contexts))
+	for name, kctx := range cfg.Contexts {
+		c := KubeContext{
+			Name:      name,
+			User:      kctx.AuthInfo,
+			Namespace: kctx.Namespace,
+		}
+		if cluster, ok := cfg.Clusters[kctx.Cluster]; ok {
+			c.Server = cluster.Server
+		}
+		list = append(list, c)
+	}
+	slices.SortFunc(list, func(a, b KubeContext) int { return strings.Compare(a.Name, b.Name) })
+	return list, nil
+}
+
+// KubeSyncResult lists the changes made by SyncKubeContexts
+type KubeSyncResult struct {
+	Created []*Environment `json:"created"`
+	Renamed []*Environment `json:"renamed"` // environments that now use the new name of their context
+	Removed []*Environment `json:"removed"` // only if pruning was requested
+	Stale   []*Environment `json:"stale"`   // environments whose context is gone
+	Skipped []string       `json:"skipped"` // requested contexts that already have an environment
+	// InUse lists the references per ID of stale environments that weren't pruned because
+	// something still refers to them
+	InUse map[string][]string `json:"inUse"`
+}
+
+// SyncKubeContexts brings the grpc-k8s environments using the default kubeconfig in line with
+// its contexts and creates environments for the selected contexts that don't have one yet.
+// Environments whose context has been renamed are updated; environments whose context is gone
+// are deleted if prune is set, and reported as stale otherwise. Environments with references,
+// given by environment ID, are never deleted. Environments with their own kubeconfig or running
+// in-cluster are left alone.
+func SyncKubeContexts(storage *Storage, contexts []KubeContext, selected []string, prune bool, references map[string][]string) (*KubeSyncResult, error) {
+	byName := make(map[string]KubeContext, len(contexts))
+	for _, c := range contexts {
+		byName[c.Name] = c
+	}
+	for _, name := range selected {
+		if _, ok := byName[name]; !ok {
+			return nil, &api.ErrInvalidRequest{Reason: fmt.Sprintf("unknown kubernetes context %q", name)}
+		}
+	}
+
+	envs, err := storage.List()
+	if err != nil {
+		return nil, err
+	}
+
+	res := &KubeSyncResult{
+		Created: []*Environment{},
+		Renamed: []*Environment{},
+		Removed: []*Environment{},
+		Stale:   []*Environment{},
+		Skipped: []string{},
+		InUse:   map[string][]string{},
+	}
+
+	used := make(map[string]bool)
+	var missing []*Environment
+	for _, env := range envs {
+		if env.Runtime != "grpc-k8s" || env.Params[k8s.ParamContext] == "" || !k8s.ConfigSourceFromParams(env.Params).IsDefault() {
+			continue
+		}
+		c, ok := byName[env.Params[k8s.ParamContext]]
+		if !ok {
+			missing = append(missing, env)
+			continue
+		}
+		used[c.Name] = true
+		if env.KubeContext == nil {
+			// Environments created by hand learn their source, so that they can follow renames later on
+			env.KubeContext = &KubeContextSource{Server: c.Server, User: c.User}
+			if err := storage.Set(env); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for _, env := range missing {
+		if renamed := findRenamedContext(env, contexts, used); renamed != nil {
+			oldName := env.Params[k8s.ParamContext]
+			env.Params[k8s.ParamContext] = renamed.Name
+			if env.Name == oldName {
+				env.Name = renamed.Name
+			}
+			if err := storage.Set(env); err != nil {
+				return nil, err
+			}
+			used[renamed.Name] = true
+			res.Renamed = append(res.Renamed, env)
+			continue
+		}
+		if prune {
+			if refs := references[env.ID]; len(refs) > 0 {
+				res.InUse[env.ID] = refs
+			} else {
+				if err := storage.Delete(env); err != nil {
+					return nil, err
+				}
+				res.Removed = append(res.Removed, env)
+				continue
+			}
+		}
+		res.Stale = append(res.Stale, env)
+	}
+
+	for _, name := range selected {
+		if used[name] {
+			res.Skipped = append(res.Skipped, name)
+			continue
+		}
+		c := byName[name]
+		env := &Environment{
+			Name:        c.Name,
+			Runtime:     "grpc-k8s",
+			Params:      map[string]string{k8s.ParamContext: c.Name},
+			KubeContext: &KubeContextSource{Server: c.Server, User: c.User},
+		}
+		if err := storage.Add(env); err != nil {
+			return nil, err
+		}
+		used[name] = true
+		res.Created = append(res.Created, env)
+	}
+	return res, nil
+}
+
+// findRenamedContext returns the unused context pointing to the same cluster and user as the
+// context the environment was created from, if there is exactly one
+func findRenamedContext(env *Environment, contexts []KubeContext, used map[string]bool) *KubeContext {
+	if env.KubeContext == nil || env.KubeContext.Server == "" {
+		return nil
+	}
+	var match *KubeContext
+	for i, c := range contexts {
+		if used[c.Name] || c.Server != env.KubeContext.Server || c.User != env.KubeContext.User {
+			continue
+		}
+		if match != nil {
+			// Ambiguous
+			return nil
+		}
+		match = &contexts[i]
+	}
+	return match
+}
@@ -0,0 +1,118 @@
+// Copyright 2026 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package environment
+
+import (
+	"testing"
+
+	"github.com/inspektor-gadget/ig-desktop/pkg/k8s"
+)
+
+func TestSyncKubeContexts(t *testing.T) {
+	storage := NewStorage(t.TempDir())
+
+	contexts := []KubeContext{
+		{Name: "dev", Server: "https://dev:6443", User: "alice"},
+		{Name: "prod", Server: "https://prod:6443", User: "alice"},
+	}
+	res, err := SyncKubeContexts(storage, contexts, []string{"dev", "prod"}, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Created) != 2 {
+		t.Fatalf("expected 2 created environments, got %d", len(res.Created))
+	}
+
+	// Importing again skips both
+	res, err = SyncKubeContexts(storage, contexts, []string{"dev", "prod"}, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Created) != 0 || len(res.Skipped) != 2 {
+		t.Fatalf("expected contexts to be skipped, got %+v", res)
+	}
+
+	// "dev" is renamed, "prod" removed
+	contexts = []KubeContext{
+		{Name: "development", Server: "https://dev:6443", User: "alice"},
+	}
+	res, err = SyncKubeContexts(storage, contexts, nil, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Renamed) != 1 || res.Renamed[0].Params["context"] != "development" || res.Renamed[0].Name != "development" {
+		t.Fatalf("expected dev to be renamed, got %+v", res.Renamed)
+	}
+	if len(res.Stale) != 1 || res.Stale[0].Params["context"] != "prod" {
+		t.Fatalf("expected prod to be stale, got %+v", res.Stale)
+	}
+
+	res, err = SyncKubeContexts(storage, contexts, nil, true, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Removed) != 1 {
+		t.Fatalf("expected prod to be removed, got %+v", res.Removed)
+	}
+	envs, err := storage.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(envs) != 1 {
+		t.Fatalf("expected 1 environment left, got %d", len(envs))
+	}
+
+	if _, err := SyncKubeContexts(storage, contexts, []string{"missing"}, false, nil); err == nil {
+		t.Error("expected error for unknown context")
+	}
+}
+
+func TestSyncKubeContextsOwnConfigAndReferences(t *testing.T) {
+	storage := NewStorage(t.TempDir())
+
+	// None of these use the default kubeconfig, so their contexts aren't expected in it
+	for _, params := range []map[string]string{
+		{k8s.ParamContext: "a", k8s.ParamKubeconfig: "/etc/kube/a.yaml"},
+		{k8s.ParamContext: "b", k8s.ParamKubeconfigData: "apiVersion: v1"},
+		{k8s.ParamContext: "c", k8s.ParamInCluster: "true"},
+	} {
+		if err := storage.Add(&Environment{Name: params[k8s.ParamContext], Runtime: "grpc-k8s", Params: params}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	used := &Environment{Name: "used", Runtime: "grpc-k8s", Params: map[string]string{k8s.ParamContext: "used"}}
+	if err := storage.Add(used); err != nil {
+		t.Fatal(err)
+	}
+
+	references := map[string][]string{used.ID: {`schedule "nightly"`}}
+	res, err := SyncKubeContexts(storage, nil, nil, true, references)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Removed) != 0 {
+		t.Fatalf("expected nothing to be removed, got %+v", res.Removed)
+	}
+	if len(res.Stale) != 1 || res.Stale[0].ID != used.ID || len(res.InUse[used.ID]) != 1 {
+		t.Fatalf("expected referenced environment to be kept as stale, got %+v", res)
+	}
+	envs, err := storage.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(envs) != 4 {
+		t.Fatalf("expected all 4 environments to be kept, got %d", len(envs))
+	}
+}
@@ -15,35 +15,121 @@
 package environment
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
-	"github.com/inspektor-gadget/inspektor-gadget/cmd/kubectl-gadget/utils"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
-	"k8s.io/client-go/tools/clientcmd"
 
+	"github.com/inspektor-gadget/ig-desktop/internal/credentials"
 	"github.com/inspektor-gadget/ig-desktop/pkg/api"
 	grpcruntime "github.com/inspektor-gadget/ig-desktop/pkg/grpc-runtime"
+	"github.com/inspektor-gadget/ig-desktop/pkg/k8s"
 )
 
-// RuntimeFactory handles creation and initialization of runtimes from environment configs
+// RuntimeFactory handles creation and initialization of runtimes from environment configs.
+// Runtimes are cached per environment, so that commands share their pooled connections; a cached
+// runtime is replaced when its environment or the kubeconfig it was loaded from changes.
 type RuntimeFactory struct {
-	storage *Storage
+	storage     *Storage
+	credentials *credentials.Store // nil if credential references can't be resolved
+	hostPolicy  HostPolicy
+
+	mu       sync.Mutex
+	runtimes map[string]*cachedRuntime
+}
+
+type cachedRuntime struct {
+	runtime     *grpcruntime.Runtime
+	environment *Environment
+	kubeconfig  map[string]fileState // only for grpc-k8s environments
+}
+
+// fileState is used to detect changes of a file
+type fileState struct {
+	modTime time.Time
+	size    int64
 }
 
 // NewRuntimeFactory creates a new RuntimeFactory
 func NewRuntimeFactory(storage *Storage) *RuntimeFactory {
 	return &RuntimeFactory{
-		storage: storage,
+		storage:  storage,
+		runtimes: make(map[string]*cachedRuntime),
 	}
 }
 
+// HostPolicy restricts environment params that make runtimes use the configuration of the host
+// they run on. The zero value allows none of them, as clients of a server must not be able to use
+// the kubeconfig, service account or SSH keys of the host.
+type HostPolicy struct {
+	// AllowKubeconfig allows Kubernetes environments to load kubeconfig files, inline kubeconfigs
+	// and the in-cluster config. Without it, they use the default kubeconfig of the host.
+	AllowKubeconfig bool
+	// AllowSSHKeys allows SSH environments to use key files, the SSH agent and known hosts files
+	// of the host, and to skip host key checking. Without it, their key has to be a stored
+	// credential.
+	AllowSSHKeys bool
+}
+
+// SetHostPolicy sets which params referring to the host environments may use
+func (f *RuntimeFactory) SetHostPolicy(policy HostPolicy) {
+	f.hostPolicy = policy
+}
+
+// CheckKubeSource returns an error if the policy doesn't allow loading the Kubernetes config from
+// the given source
+func (f *RuntimeFactory) CheckKubeSource(source k8s.ConfigSource) error {
+	if params := source.HostParams(); len(params) > 0 && !f.hostPolicy.AllowKubeconfig {
+		return &api.ErrInvalidRequest{Reason: fmt.Sprintf("%s is disabled on this server", params[0])}
+	}
+	return nil
+}
+
+// SetCredentialStore sets the store that TLS params referencing a credential are resolved from
+func (f *RuntimeFactory) SetCredentialStore(store *credentials.Store) {
+	f.credentials = store
+}
+
+// kubeconfigState returns the state of the kubeconfig files an environment is loaded from
+func kubeconfigState(environment *Environment) map[string]fileState {
+	res := make(map[string]fileState)
+	for _, file := range k8s.ConfigSourceFromParams(environment.Params).Files() {
+		fi, err := os.Stat(file)
+		if err != nil {
+			// Missing files are recorded as well, so that creating them is noticed
+			res[file] = fileState{}
+			continue
+		}
+		res[file] = fileState{modTime: fi.ModTime(), size: fi.Size()}
+	}
+	return res
+}
+
+// current returns true if the cached runtime was created from the given environment and,
+// for Kubernetes, from the current kubeconfig
+func (c *cachedRuntime) current(environment *Environment) bool {
+	if c.environment.Runtime != environment.Runtime || !maps.Equal(c.environment.Params, environment.Params) {
+		return false
+	}
+	return c.kubeconfig == nil || maps.Equal(c.kubeconfig, kubeconfigState(environment))
+}
+
 // GetKubernetesContexts returns a list of available Kubernetes contexts
 func GetKubernetesContexts() ([]string, error) {
-	cfg, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
-		clientcmd.NewDefaultClientConfigLoadingRules(),
-		&clientcmd.ConfigOverrides{},
-	).RawConfig()
+	cc, err := k8s.ConfigSource{}.ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := cc.RawConfig()
 	if err != nil {
 		return nil, err
 	}
@@ -55,55 +141,338 @@ func GetKubernetesContexts() ([]string, error) {
 	return list, nil
 }
 
-// GetRuntime loads an environment and initializes its runtime
+// GetRuntime returns the initialized runtime of an environment, reusing the cached one if it is
+// still current
 func (f *RuntimeFactory) GetRuntime(id string) (*grpcruntime.Runtime, error) {
 	environment, err := f.storage.Get(id)
 	if err != nil {
 		return nil, err
 	}
 
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if cached, ok := f.runtimes[id]; ok {
+		if cached.current(environment) {
+			return cached.runtime, nil
+		}
+		cached.runtime.Close()
+		delete(f.runtimes, id)
+	}
+
+	cached := &cachedRuntime{environment: environment}
+	if environment.Runtime == "grpc-k8s" {
+		cached.kubeconfig = kubeconfigState(environment)
+	}
+	rt, err := f.newRuntime(environment)
+	if err != nil {
+		return nil, err
+	}
+	cached.runtime = rt
+	f.runtimes[id] = cached
+	return rt, nil
+}
+
+// Invalidate closes the cached runtime of an environment and its connections; used when an
+// environment has been changed or deleted
+func (f *RuntimeFactory) Invalidate(id string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if cached, ok := f.runtimes[id]; ok {
+		cached.runtime.Close()
+		delete(f.runtimes, id)
+	}
+}
+
+// credentialKinds maps the TLS and SSH params that can reference a stored credential to the kind
+// of credential they expect
+var credentialKinds = map[string]string{
+	grpcruntime.ParamTLSKey:      credentials.KindKey,
+	grpcruntime.ParamTLSCert:     credentials.KindCert,
+	grpcruntime.ParamTLSServerCA: credentials.KindCA,
+	grpcruntime.ParamSSHKeyFile:  credentials.KindKey,
+}
+
+// UsingCredential returns the environments with a param referencing the given credential
+func UsingCredential(storage *Storage, id string) ([]*Environment, error) {
+	envs, err := storage.List()
+	if err != nil {
+		return nil, err
+	}
+	return slices.DeleteFunc(envs, func(env *Environment) bool {
+		for param := range credentialKinds {
+			if ref, ok := credentials.ParseRef(env.Params[param]); ok && ref == id {
+				return false
+			}
+		}
+		return true
+	}), nil
+}
+
+// resolveCredentials returns options providing the material of TLS and SSH params that reference
+// a stored credential. Certificates that are about to expire are logged.
+func resolveCredentials(environment *Environment, store *credentials.Store) ([]grpcruntime.Option, error) {
+	var opts []grpcruntime.Option
+	for param := range credentialKinds {
+		id, ok := credentials.ParseRef(environment.Params[param])
+		if !ok {
+			continue
+		}
+		if store == nil {
+			return nil, fmt.Errorf("%s references credential %s, but the credential store is not available", param, id)
+		}
+		c, err := store.Get(id)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", param, err)
+		}
+		if c.Expires(time.Now(), credentials.DefaultExpiryWarning) {
+			log.Printf("warning: certificate %q of environment %q expires %s", c.Name, environment.Name,
+				time.UnixMilli(c.NotAfter).Format(time.RFC3339))
+		}
+		data, err := store.Data(id)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", param, err)
+		}
+		if param == grpcruntime.ParamSSHKeyFile {
+			opts = append(opts, grpcruntime.WithSSHKeyData(data))
+		} else {
+			opts = append(opts, grpcruntime.WithTLSData(param, data))
+		}
+	}
+	return opts, nil
+}
+
+// newRuntime creates and initializes the runtime of an environment
+func (f *RuntimeFactory) newRuntime(environment *Environment) (*grpcruntime.Runtime, error) {
+	pool := grpcruntime.WithConnectionPool(grpcruntime.DefaultConnIdleTimeout)
+
 	var rt *grpcruntime.Runtime
 	switch environment.Runtime {
 	case "grpc-k8s":
-		rt = grpcruntime.New(grpcruntime.WithConnectUsingK8SProxy)
+		source := k8s.ConfigSourceFromParams(environment.Params)
+		if err := f.CheckKubeSource(source); err != nil {
+			return nil, err
+		}
+		rt = grpcruntime.New(grpcruntime.WithConnectUsingK8SProxy, pool)
 
-		// Load Kubernetes config with context override if specified
-		context := environment.Params["context"]
-		config, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
-			clientcmd.NewDefaultClientConfigLoadingRules(),
-			&clientcmd.ConfigOverrides{
-				CurrentContext: context,
-			},
-		).ClientConfig()
+		// Load Kubernetes config from the kubeconfig, inline content or in-cluster config of
+		// the environment, with context override if specified
+		cc, err := source.ClientConfig()
 		if err != nil {
+			rt.Close()
+			return nil, fmt.Errorf("could not load kubernetes config: %v", err)
+		}
+		config, err := cc.ClientConfig()
+		if err != nil {
+			rt.Close()
 			return nil, fmt.Errorf("could not load kubernetes config: %v", err)
 		}
 		rt.SetRestConfig(config)
 
-		namespace, _ := utils.GetNamespace()
+		namespace, _, _ := cc.Namespace()
 		rt.SetDefaultValue(gadgets.K8SNamespace, namespace)
 	case "grpc-ig":
-		rt = grpcruntime.New()
+		tlsData, err := resolveCredentials(environment, f.credentials)
+		if err != nil {
+			return nil, err
+		}
+		rt = grpcruntime.New(append(tlsData, pool)...)
+	case "grpc-ssh":
+		if errs := f.checkSSHParams(environment.Params); len(errs) > 0 {
+			return nil, &api.ErrInvalidParams{Errors: errs}
+		}
+		keyData, err := resolveCredentials(environment, f.credentials)
+		if err != nil {
+			return nil, err
+		}
+		rt = grpcruntime.New(append(keyData, grpcruntime.WithConnectUsingSSH, pool)...)
 	default:
 		return nil, &api.ErrInvalidRuntime{Runtime: environment.Runtime}
 	}
 
 	params := rt.GlobalParamDescs().ToParams()
-	err = params.CopyFromMap(environment.Params, "")
+	err := params.CopyFromMap(environment.Params, "")
 	if err != nil {
 		log.Printf("failed to copy params: %v", err)
+		rt.Close()
 		return nil, fmt.Errorf("copying environment params: %w", err)
 	}
 
 	err = rt.Init(params)
 	if err != nil {
 		log.Printf("failed to init runtime: %v", err)
+		rt.Close()
 		return nil, fmt.Errorf("initializing runtime: %w", err)
 	}
 
 	return rt, nil
 }
 
+// CheckEnvironment tests the connection to an environment step by step, starting with loading
+// its configuration. Failing steps are reported in the result; the error is only set if the
+// environment doesn't exist.
+func (f *RuntimeFactory) CheckEnvironment(ctx context.Context, id string) ([]*grpcruntime.CheckStep, error) {
+	environment, err := f.storage.Get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Use a fresh runtime, so that neither the config nor connections come from the cache
+	step := &grpcruntime.CheckStep{Step: grpcruntime.CheckStepLoadConfig}
+	start := time.Now()
+	rt, err := f.newRuntime(environment)
+	step.Latency = time.Since(start).Milliseconds()
+	if err != nil {
+		step.Error = err.Error()
+		return []*grpcruntime.CheckStep{step}, nil
+	}
+	defer rt.Close()
+	return append([]*grpcruntime.CheckStep{step}, rt.Check(ctx)...), nil
+}
+
+// ValidateParams checks the given values of the global params of a runtime type. Values of keys
+// that aren't runtime params, like the kubernetes context, are left alone.
+func (f *RuntimeFactory) ValidateParams(runtimeType string, values map[string]string) error {
+	var rt *grpcruntime.Runtime
+	switch runtimeType {
+	case "grpc-k8s":
+		rt = grpcruntime.New(grpcruntime.WithConnectUsingK8SProxy)
+	case "grpc-ig":
+		rt = grpcruntime.New()
+	case "grpc-ssh":
+		rt = grpcruntime.New(grpcruntime.WithConnectUsingSSH)
+	default:
+		return &api.ErrInvalidRuntime{Runtime: runtimeType}
+	}
+
+	var paramErrors []api.ParamError
+	for _, desc := range rt.GlobalParamDescs() {
+		value, ok := values[desc.Key]
+		if !ok || value == "" {
+			continue
+		}
+		if err := desc.Validate(value); err != nil {
+			reason := api.ParamErrorInvalidValue
+			if len(desc.PossibleValues) > 0 {
+				reason = api.ParamErrorNotAllowed
+			}
+			// Drop the "invalid value ... as ..." wrapping, the key and value are reported separately
+			if inner := errors.Unwrap(err); inner != nil {
+				err = inner
+			}
+			paramErrors = append(paramErrors, api.ParamError{
+				Key:     desc.Key,
+				Value:   value,
+				Reason:  reason,
+				Message: err.Error(),
+			})
+		}
+	}
+	paramErrors = append(paramErrors, f.validateCredentialRefs(values)...)
+	switch runtimeType {
+	case "grpc-k8s":
+		paramErrors = append(paramErrors, f.validateKubeParams(values)...)
+	case "grpc-ssh":
+		paramErrors = append(paramErrors, f.checkSSHParams(values)...)
+	}
+	if len(paramErrors) > 0 {
+		return &api.ErrInvalidParams{Errors: paramErrors}
+	}
+	return nil
+}
+
+// validateKubeParams checks the impersonation and proxy settings and the inline kubeconfig of a
+// Kubernetes environment, and that the policy allows where its config comes from
+func (f *RuntimeFactory) validateKubeParams(values map[string]string) []api.ParamError {
+	var paramErrors []api.ParamError
+	source := k8s.ConfigSourceFromParams(values)
+	if !f.hostPolicy.AllowKubeconfig {
+		for _, param := range source.HostParams() {
+			paramErrors = append(paramErrors, api.ParamError{
+				Key:     param,
+				Value:   values[param],
+				Reason:  api.ParamErrorNotAllowed,
+				Message: fmt.Sprintf("%s is disabled on this server", param),
+			})
+		}
+	}
+	for _, err := range source.Errors() {
+		paramErrors = append(paramErrors, api.ParamError{
+			Key:     err.Param,
+			Value:   values[err.Param],
+			Reason:  api.ParamErrorInvalidValue,
+			Message: err.Error(),
+		})
+	}
+	return paramErrors
+}
+
+// checkSSHParams checks that the policy allows the SSH keys and host key checking of an SSH
+// environment. Without AllowSSHKeys, the key has to be a stored credential, so that neither key
+// files nor the agent of the host are used, and host keys are checked against the known hosts of
+// the host.
+func (f *RuntimeFactory) checkSSHParams(values map[string]string) []api.ParamError {
+	if f.hostPolicy.AllowSSHKeys {
+		return nil
+	}
+	var paramErrors []api.ParamError
+	if _, ok := credentials.ParseRef(values[grpcruntime.ParamSSHKeyFile]); !ok {
+		paramErrors = append(paramErrors, api.ParamError{
+			Key:     grpcruntime.ParamSSHKeyFile,
+			Value:   values[grpcruntime.ParamSSHKeyFile],
+			Reason:  api.ParamErrorNotAllowed,
+			Message: "the SSH key has to reference a stored credential on this server",
+		})
+	}
+	if value := values[grpcruntime.ParamSSHKnownHostsFile]; value != "" {
+		paramErrors = append(paramErrors, api.ParamError{
+			Key:     grpcruntime.ParamSSHKnownHostsFile,
+			Value:   value,
+			Reason:  api.ParamErrorNotAllowed,
+			Message: fmt.Sprintf("%s is disabled on this server", grpcruntime.ParamSSHKnownHostsFile),
+		})
+	}
+	if insecure, _ := strconv.ParseBool(values[grpcruntime.ParamSSHInsecureHostKey]); insecure {
+		paramErrors = append(paramErrors, api.ParamError{
+			Key:     grpcruntime.ParamSSHInsecureHostKey,
+			Value:   values[grpcruntime.ParamSSHInsecureHostKey],
+			Reason:  api.ParamErrorNotAllowed,
+			Message: fmt.Sprintf("%s is disabled on this server", grpcruntime.ParamSSHInsecureHostKey),
+		})
+	}
+	return paramErrors
+}
+
+// validateCredentialRefs checks that params referencing a credential point to a stored
+// credential of the right kind
+func (f *RuntimeFactory) validateCredentialRefs(values map[string]string) []api.ParamError {
+	var paramErrors []api.ParamError
+	for param, kind := range credentialKinds {
+		id, ok := credentials.ParseRef(values[param])
+		if !ok {
+			continue
+		}
+		var msg string
+		if f.credentials == nil {
+			msg = "credential store is not available"
+		} else if c, err := f.credentials.Get(id); err != nil {
+			msg = err.Error()
+		} else if c.Kind != kind {
+			msg = fmt.Sprintf("credential %q is a %s, expected a %s", c.Name, c.Kind, kind)
+		}
+		if msg != "" {
+			paramErrors = append(paramErrors, api.ParamError{
+				Key:     param,
+				Value:   values[param],
+				Reason:  api.ParamErrorInvalidValue,
+				Message: msg,
+			})
+		}
+	}
+	slices.SortFunc(paramErrors, func(a, b api.ParamError) int { return strings.Compare(a.Key, b.Key) })
+	return paramErrors
+}
+
 // GetRuntimeParams returns the global parameters for a given runtime type
 func (f *RuntimeFactory) GetRuntimeParams(runtimeType string) (interface{}, error) {
 	var rt *grpcruntime.Runtime
@@ -112,6 +481,8 @@ func (f *RuntimeFactory) GetRuntimeParams(runtimeType string) (interface{}, erro
 		rt = grpcruntime.New(grpcruntime.WithConnectUsingK8SProxy)
 	case "grpc-ig":
 		rt = grpcruntime.New()
+	case "grpc-ssh":
+		rt = grpcruntime.New(grpcruntime.WithConnectUsingSSH)
 	default:
 		return nil, &api.ErrInvalidRuntime{Runtime: runtimeType}
 	}
@@ -0,0 +1,157 @@
+// Copyright 2026 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package environment
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/inspektor-gadget/ig-desktop/internal/credentials"
+	"github.com/inspektor-gadget/ig-desktop/pkg/api"
+	grpcruntime "github.com/inspektor-gadget/ig-desktop/pkg/grpc-runtime"
+	"github.com/inspektor-gadget/ig-desktop/pkg/k8s"
+)
+
+func TestValidateParams(t *testing.T) {
+	f := NewRuntimeFactory(NewStorage(t.TempDir()))
+
+	var invalidRuntime *api.ErrInvalidRuntime
+	if err := f.ValidateParams("foo", nil); !errors.As(err, &invalidRuntime) {
+		t.Errorf("expected invalid runtime, got %v", err)
+	}
+
+	for _, tc := range []struct {
+		name    string
+		runtime string
+		values  map[string]string
+		invalid []string // keys of the expected param errors
+	}{
+		{
+			name:    "valid",
+			runtime: "grpc-k8s",
+			values:  map[string]string{k8s.ParamContext: "dev", grpcruntime.ParamGadgetNamespace: "gadget"},
+		},
+		{
+			name:    "unknown keys are ignored",
+			runtime: "grpc-ig",
+			values:  map[string]string{"whatever": "value"},
+		},
+		{
+			name:    "invalid type",
+			runtime: "grpc-ig",
+			values:  map[string]string{grpcruntime.ParamConnectionTimeout: "soon"},
+			invalid: []string{grpcruntime.ParamConnectionTimeout},
+		},
+		{
+			name:    "credential store missing",
+			runtime: "grpc-ig",
+			values:  map[string]string{grpcruntime.ParamTLSKey: credentials.RefPrefix + "0123"},
+			invalid: []string{grpcruntime.ParamTLSKey},
+		},
+		{
+			name:    "kube settings",
+			runtime: "grpc-k8s",
+			values:  map[string]string{k8s.ParamImpersonateGroups: "devs", k8s.ParamProxyURL: "ftp://proxy"},
+			invalid: []string{k8s.ParamImpersonateGroups, k8s.ParamProxyURL},
+		},
+		{
+			name:    "host kubeconfig not allowed",
+			runtime: "grpc-k8s",
+			values:  map[string]string{k8s.ParamKubeconfig: "/etc/kube/config", k8s.ParamInCluster: "true"},
+			invalid: []string{k8s.ParamInCluster, k8s.ParamKubeconfig},
+		},
+		{
+			name:    "host SSH keys not allowed",
+			runtime: "grpc-ssh",
+			values: map[string]string{
+				grpcruntime.ParamSSHHosts:           "host",
+				grpcruntime.ParamSSHKeyFile:         "/home/me/.ssh/id_ed25519",
+				grpcruntime.ParamSSHKnownHostsFile:  "/dev/null",
+				grpcruntime.ParamSSHInsecureHostKey: "true",
+			},
+			invalid: []string{grpcruntime.ParamSSHInsecureHostKey, grpcruntime.ParamSSHKeyFile, grpcruntime.ParamSSHKnownHostsFile},
+		},
+		{
+			name:    "SSH agent not allowed",
+			runtime: "grpc-ssh",
+			values:  map[string]string{grpcruntime.ParamSSHHosts: "host", grpcruntime.ParamSSHInsecureHostKey: "false"},
+			invalid: []string{grpcruntime.ParamSSHKeyFile},
+		},
+	} {
+		err := f.ValidateParams(tc.runtime, tc.values)
+		if len(tc.invalid) == 0 {
+			if err != nil {
+				t.Errorf("%s: unexpected error %v", tc.name, err)
+			}
+			continue
+		}
+		var paramErr *api.ErrInvalidParams
+		if !errors.As(err, &paramErr) {
+			t.Errorf("%s: expected param errors, got %v", tc.name, err)
+			continue
+		}
+		var keys []string
+		for _, e := range paramErr.Errors {
+			keys = append(keys, e.Key)
+		}
+		slices.Sort(keys)
+		if !slices.Equal(keys, tc.invalid) {
+			t.Errorf("%s: expected errors for %v, got %v", tc.name, tc.invalid, keys)
+		}
+	}
+}
+
+func TestHostPolicy(t *testing.T) {
+	storage := NewStorage(t.TempDir())
+	env := &Environment{
+		Name:    "in-cluster",
+		Runtime: "grpc-k8s",
+		Params:  map[string]string{k8s.ParamInCluster: "true"},
+	}
+	if err := storage.Add(env); err != nil {
+		t.Fatal(err)
+	}
+
+	f := NewRuntimeFactory(storage)
+	var invalidRequest *api.ErrInvalidRequest
+	if _, err := f.GetRuntime(env.ID); !errors.As(err, &invalidRequest) {
+		t.Errorf("expected in-cluster config to be refused, got %v", err)
+	}
+	if err := f.CheckKubeSource(k8s.ConfigSource{Context: "dev"}); err != nil {
+		t.Errorf("expected default kubeconfig to be allowed, got %v", err)
+	}
+
+	f.SetHostPolicy(HostPolicy{AllowKubeconfig: true})
+	if err := f.ValidateParams("grpc-k8s", env.Params); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	if err := f.CheckKubeSource(k8s.ConfigSourceFromParams(env.Params)); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	sshParams := map[string]string{
+		grpcruntime.ParamSSHHosts:           "host",
+		grpcruntime.ParamSSHKeyFile:         "/home/me/.ssh/id_ed25519",
+		grpcruntime.ParamSSHInsecureHostKey: "true",
+	}
+	if err := f.ValidateParams("grpc-ssh", sshParams); err == nil {
+		t.Error("expected SSH key files to be refused")
+	}
+	f.SetHostPolicy(HostPolicy{AllowSSHKeys: true})
+	if err := f.ValidateParams("grpc-ssh", sshParams); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+}
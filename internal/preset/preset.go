@@ -0,0 +1,256 @@
+// Copyright 2026 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package preset
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/google/uuid"
+
+	"github.com/inspektor-gadget/ig-desktop/pkg/api"
+	"github.com/inspektor-gadget/ig-desktop/pkg/gadget"
+)
+
+// ExportVersion is the version of the format written by Export
+const ExportVersion = 1
+
+// RedactedValue replaces the values of sink headers in exports, as they usually hold tokens or
+// API keys
+const RedactedValue = "<redacted>"
+
+// Preset is a saved gadget run configuration
+type Preset struct {
+	ID     string            `json:"id"`
+	Name   string            `json:"name"`
+	Image  string            `json:"image"`
+	Params map[string]string `json:"params"`
+
+	// EnvironmentID optionally binds the preset to an environment; if empty, the
+	// environment has to be chosen when running the preset
+	EnvironmentID string `json:"environmentID,omitempty"`
+
+	// Fields holds the selected fields to show, per datasource
+	Fields map[string][]string `json:"fields,omitempty"`
+
+	Record      bool   `json:"record,omitempty"`
+	SessionName string `json:"sessionName,omitempty"`
+
+	// Sinks the output of the preset is forwarded to
+	Sinks []gadget.SinkConfig `json:"sinks,omitempty"`
+}
+
+// Bundle is the exchange format for sharing presets
+type Bundle struct {
+	Version int       `json:"version"`
+	Presets []*Preset `json:"presets"`
+}
+
+// Storage handles persistence of presets
+type Storage struct {
+	dir string
+}
+
+// NewStorage creates a new Storage instance that persists presets
+// to the given directory.
+func NewStorage(dir string) *Storage {
+	return &Storage{dir: dir}
+}
+
+// Add creates a new preset and persists it to disk
+func (s *Storage) Add(preset *Preset) error {
+	preset.ID = uuid.New().String()
+	return s.Set(preset)
+}
+
+// Set persists a preset with its existing ID.
+func (s *Storage) Set(preset *Preset) error {
+	if err := uuid.Validate(preset.ID); err != nil {
+		return &api.ErrInvalidRequest{Reason: fmt.Sprintf("invalid preset ID: %s", preset.ID)}
+	}
+	filename := filepath.Join(s.dir, preset.ID+".json")
+	d, _ := json.Marshal(preset)
+	return os.WriteFile(filename, d, 0o644)
+}
+
+// Delete removes a preset from disk
+func (s *Storage) Delete(id string) error {
+	if err := uuid.Validate(id); err != nil {
+		return &api.ErrInvalidRequest{Reason: fmt.Sprintf("invalid preset ID: %s", id)}
+	}
+	err := os.Remove(filepath.Join(s.dir, id+".json"))
+	if os.IsNotExist(err) {
+		return &api.ErrPresetNotFound{ID: id}
+	}
+	return err
+}
+
+// Get retrieves a single preset by ID
+func (s *Storage) Get(id string) (*Preset, error) {
+	if err := uuid.Validate(id); err != nil {
+		return nil, &api.ErrInvalidRequest{Reason: fmt.Sprintf("invalid preset ID: %s", id)}
+	}
+	b, err := os.ReadFile(filepath.Join(s.dir, id+".json"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, &api.ErrPresetNotFound{ID: id}
+		}
+		return nil, fmt.Errorf("reading preset file: %w", err)
+	}
+	var preset Preset
+	err = json.Unmarshal(b, &preset)
+	if err != nil {
+		return nil, fmt.Errorf("parsing preset file: %w", err)
+	}
+	return &preset, nil
+}
+
+// List returns all persisted presets
+func (s *Storage) List() ([]*Preset, error) {
+	files, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	presets := make([]*Preset, 0, len(files))
+	for _, file := range files {
+		b, err := os.ReadFile(filepath.Join(s.dir, file.Name()))
+		if err != nil {
+			continue
+		}
+		var preset Preset
+		err = json.Unmarshal(b, &preset)
+		if err != nil {
+			// Skip invalid files
+			continue
+		}
+		presets = append(presets, &preset)
+	}
+	return presets, nil
+}
+
+// Export returns a bundle with the given presets, or all presets if ids is empty. The values of
+// sink headers are replaced with RedactedValue.
+func (s *Storage) Export(ids []string) (*Bundle, error) {
+	var presets []*Preset
+	if len(ids) == 0 {
+		var err error
+		presets, err = s.List()
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		presets = make([]*Preset, 0, len(ids))
+		for _, id := range ids {
+			preset, err := s.Get(id)
+			if err != nil {
+				return nil, err
+			}
+			presets = append(presets, preset)
+		}
+	}
+	for _, preset := range presets {
+		preset.Sinks = redactSinks(preset.Sinks)
+	}
+	return &Bundle{Version: ExportVersion, Presets: presets}, nil
+}
+
+// redactSinks returns a copy of the sinks with the values of webhook and OTLP headers replaced by
+// RedactedValue
+func redactSinks(sinks []gadget.SinkConfig) []gadget.SinkConfig {
+	redact := func(headers map[string]string) map[string]string {
+		res := make(map[string]string, len(headers))
+		for k := range headers {
+			res[k] = RedactedValue
+		}
+		return res
+	}
+	res := slices.Clone(sinks)
+	for i := range res {
+		if cfg := res[i].Webhook; cfg != nil && len(cfg.Headers) > 0 {
+			webhook := *cfg
+			webhook.Headers = redact(cfg.Headers)
+			res[i].Webhook = &webhook
+		}
+		if cfg := res[i].OTLP; cfg != nil && len(cfg.Headers) > 0 {
+			otlp := *cfg
+			otlp.Headers = redact(cfg.Headers)
+			res[i].OTLP = &otlp
+		}
+	}
+	return res
+}
+
+// redactedFields returns the sink headers of a preset whose values were redacted on export, like
+// "sinks.0.webhook.headers.Authorization"
+func redactedFields(preset *Preset) []string {
+	var res []string
+	add := func(i int, sinkType string, headers map[string]string) {
+		for _, k := range slices.Sorted(maps.Keys(headers)) {
+			if headers[k] == RedactedValue {
+				res = append(res, fmt.Sprintf("sinks.%d.%s.headers.%s", i, sinkType, k))
+			}
+		}
+	}
+	for i, sink := range preset.Sinks {
+		if sink.Webhook != nil {
+			add(i, "webhook", sink.Webhook.Headers)
+		}
+		if sink.OTLP != nil {
+			add(i, "otlp", sink.OTLP.Headers)
+		}
+	}
+	return res
+}
+
+// ImportResult lists the outcome of Import
+type ImportResult struct {
+	Imported []*Preset `json:"imported"`
+	// Redacted lists the fields per imported preset ID that have to be filled in before use
+	Redacted map[string][]string `json:"redacted"`
+}
+
+// Import adds all presets of the bundle as new presets. keepEnvironment decides
+// whether an environment binding is kept; bindings to unknown environments are
+// dropped so that the preset asks for an environment when it is run. Sink headers
+// redacted on export are reported, so that they can be filled in.
+func (s *Storage) Import(bundle *Bundle, keepEnvironment func(id string) bool) (*ImportResult, error) {
+	if bundle.Version != ExportVersion {
+		return nil, &api.ErrInvalidRequest{Reason: fmt.Sprintf("unsupported preset bundle version %d", bundle.Version)}
+	}
+	res := &ImportResult{
+		Imported: make([]*Preset, 0, len(bundle.Presets)),
+		Redacted: map[string][]string{},
+	}
+	for _, preset := range bundle.Presets {
+		if preset == nil || preset.Image == "" {
+			continue
+		}
+		if preset.EnvironmentID != "" && !keepEnvironment(preset.EnvironmentID) {
+			preset.EnvironmentID = ""
+		}
+		if err := s.Add(preset); err != nil {
+			return res, err
+		}
+		res.Imported = append(res.Imported, preset)
+		if redacted := redactedFields(preset); len(redacted) > 0 {
+			res.Redacted[preset.ID] = redacted
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,200 @@
+// Copyright 2026 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package preset
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/inspektor-gadget/ig-desktop/pkg/api"
+	"github.com/inspektor-gadget/ig-desktop/pkg/gadget"
+)
+
+func TestStorage(t *testing.T) {
+	dir := t.TempDir()
+	s := NewStorage(dir)
+
+	p := &Preset{
+		Name:   "exec",
+		Image:  "trace_exec",
+		Params: map[string]string{"operator.filter.filter": "proc.comm==bash"},
+		Fields: map[string][]string{"exec": {"proc.comm", "args"}},
+	}
+	if err := s.Add(p); err != nil {
+		t.Fatal(err)
+	}
+	if uuid.Validate(p.ID) != nil {
+		t.Fatalf("Add() didn't assign an ID: %q", p.ID)
+	}
+
+	got, err := s.Get(p.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Name != p.Name || got.Params["operator.filter.filter"] != "proc.comm==bash" || len(got.Fields["exec"]) != 2 {
+		t.Errorf("Get() = %+v, want %+v", got, p)
+	}
+
+	// Invalid files are skipped when listing
+	if err := os.WriteFile(filepath.Join(dir, uuid.New().String()+".json"), []byte("{invalid"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	list, err := s.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || list[0].ID != p.ID {
+		t.Errorf("List() = %+v, want only %s", list, p.ID)
+	}
+
+	if err := s.Delete(p.ID); err != nil {
+		t.Fatal(err)
+	}
+	var notFound *api.ErrPresetNotFound
+	if _, err := s.Get(p.ID); !errors.As(err, &notFound) {
+		t.Errorf("Get() after Delete() error = %v, want not found", err)
+	}
+	if err := s.Delete(p.ID); !errors.As(err, &notFound) {
+		t.Errorf("Delete() twice error = %v, want not found", err)
+	}
+
+	// IDs are used as file names, so anything but a UUID is rejected
+	var invalid *api.ErrInvalidRequest
+	if _, err := s.Get("../env/x"); !errors.As(err, &invalid) {
+		t.Errorf("Get() with invalid ID error = %v, want invalid request", err)
+	}
+	if err := s.Set(&Preset{ID: "../x", Image: "trace_exec"}); !errors.As(err, &invalid) {
+		t.Errorf("Set() with invalid ID error = %v, want invalid request", err)
+	}
+}
+
+func TestExportImport(t *testing.T) {
+	src := NewStorage(t.TempDir())
+	bound := &Preset{Name: "bound", Image: "trace_exec", EnvironmentID: "known", Sinks: []gadget.SinkConfig{
+		{Type: gadget.SinkFile, File: &gadget.FileSinkConfig{Path: "exec.jsonl"}},
+		{Type: gadget.SinkWebhook, Webhook: &gadget.WebhookSinkConfig{
+			URL:     "https://hooks.example.com",
+			Headers: map[string]string{"Authorization": "Bearer secret", "X-Team": "infra"},
+		}},
+		{Type: gadget.SinkOTLP, OTLP: &gadget.OTLPSinkConfig{Headers: map[string]string{"Api-Key": "secret"}}},
+	}}
+	unbound := &Preset{Name: "other", Image: "trace_open", EnvironmentID: "unknown"}
+	for _, p := range []*Preset{bound, unbound} {
+		if err := src.Add(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	bundle, err := src.Export([]string{bound.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bundle.Version != ExportVersion || len(bundle.Presets) != 1 {
+		t.Fatalf("Export() = %+v, want version %d with one preset", bundle, ExportVersion)
+	}
+	sinks := bundle.Presets[0].Sinks
+	if sinks[0].File.Path != "exec.jsonl" || sinks[1].Webhook.URL != "https://hooks.example.com" ||
+		sinks[1].Webhook.Headers["Authorization"] != RedactedValue || sinks[1].Webhook.Headers["X-Team"] != RedactedValue ||
+		sinks[2].OTLP.Headers["Api-Key"] != RedactedValue {
+		t.Errorf("Export() didn't redact sink headers: %+v, %+v", sinks[1].Webhook, sinks[2].OTLP)
+	}
+	if stored, err := src.Get(bound.ID); err != nil || stored.Sinks[1].Webhook.Headers["Authorization"] != "Bearer secret" {
+		t.Errorf("Export() changed the stored preset: %v", err)
+	}
+	if _, err := src.Export([]string{uuid.New().String()}); err == nil {
+		t.Errorf("Export() of unknown preset succeeded")
+	}
+
+	bundle, err = src.Export(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bundle.Presets) != 2 {
+		t.Fatalf("Export() of all presets returned %d presets, want 2", len(bundle.Presets))
+	}
+
+	// Round trip through the exchange format
+	data, err := json.Marshal(bundle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded Bundle
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	decoded.Presets = append(decoded.Presets, nil, &Preset{Name: "no image"})
+
+	dst := NewStorage(t.TempDir())
+	res, err := dst.Import(&decoded, func(id string) bool { return id == "known" })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Imported) != 2 {
+		t.Fatalf("Import() imported %d presets, want 2", len(res.Imported))
+	}
+	for _, p := range res.Imported {
+		if p.ID == bound.ID || p.ID == unbound.ID {
+			t.Errorf("imported preset %s kept its ID", p.Name)
+		}
+		want := ""
+		if p.Name == "bound" {
+			want = "known"
+		}
+		if p.EnvironmentID != want {
+			t.Errorf("imported preset %s has environment %q, want %q", p.Name, p.EnvironmentID, want)
+		}
+
+		wantRedacted := []string(nil)
+		if p.Name == "bound" {
+			wantRedacted = []string{
+				"sinks.1.webhook.headers.Authorization",
+				"sinks.1.webhook.headers.X-Team",
+				"sinks.2.otlp.headers.Api-Key",
+			}
+		}
+		if got := res.Redacted[p.ID]; !slices.Equal(got, wantRedacted) {
+			t.Errorf("imported preset %s has redacted fields %v, want %v", p.Name, got, wantRedacted)
+		}
+	}
+	list, err := dst.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 {
+		t.Errorf("List() after Import() returned %d presets, want 2", len(list))
+	}
+}
+
+func TestImportBadVersion(t *testing.T) {
+	s := NewStorage(t.TempDir())
+	_, err := s.Import(&Bundle{Version: ExportVersion + 1, Presets: []*Preset{{Image: "trace_exec"}}}, func(string) bool { return true })
+	var invalid *api.ErrInvalidRequest
+	if !errors.As(err, &invalid) {
+		t.Fatalf("Import() error = %v, want invalid request", err)
+	}
+	list, err := s.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 0 {
+		t.Errorf("Import() of a bad version stored %d presets", len(list))
+	}
+}
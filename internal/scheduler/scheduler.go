@@ -0,0 +1,396 @@
+// Copyright 2026 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package scheduler starts gadget runs periodically according to cron-style
+// schedules and records them into a session per schedule. It runs without any
+// connected client, so it works the same in the desktop app and in igd.
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/inspektor-gadget/ig-desktop/internal/environment"
+	"github.com/inspektor-gadget/ig-desktop/internal/session"
+	"github.com/inspektor-gadget/ig-desktop/pkg/api"
+	"github.com/inspektor-gadget/ig-desktop/pkg/gadget"
+	"github.com/inspektor-gadget/ig-desktop/pkg/metrics"
+)
+
+// maxWait is the longest the scheduler sleeps before looking at the schedules again
+const maxWait = time.Minute
+
+// Status is a schedule together with its runtime state
+type Status struct {
+	*Schedule
+	NextRun int64 `json:"nextRun,omitempty"` // unix ms
+	Running bool  `json:"running"`
+}
+
+// Scheduler runs gadgets according to the persisted schedules
+type Scheduler struct {
+	storage         *Storage
+	envStorage      *environment.Storage
+	runtimeFactory  *environment.RuntimeFactory
+	sessionService  *session.Service
+	instanceManager *gadget.InstanceManager
+	gadgetService   *gadget.Service
+
+	// startRun starts a run of a schedule and returns its instance and session ID
+	startRun func(ctx context.Context, schedule *Schedule) (string, string, error)
+
+	mu       sync.Mutex
+	next     map[string]time.Time // schedule ID -> next activation
+	running  map[string]string    // schedule ID -> instance ID of the last run
+	starting map[string]bool      // schedules whose run is being started
+	wake     chan struct{}
+}
+
+// New creates a new Scheduler. Scheduled runs use their own headless gadget
+// service; sessionService may be nil, in which case runs are not recorded.
+func New(storage *Storage, envStorage *environment.Storage, runtimeFactory *environment.RuntimeFactory, sessionService *session.Service) *Scheduler {
+	instanceManager := gadget.NewInstanceManager()
+	gadgetService := gadget.NewService(instanceManager)
+	if sessionService != nil {
+		gadgetService.SetSessionRecorder(sessionService)
+	}
+
+	s := &Scheduler{
+		storage:         storage,
+		envStorage:      envStorage,
+		runtimeFactory:  runtimeFactory,
+		sessionService:  sessionService,
+		instanceManager: instanceManager,
+		gadgetService:   gadgetService,
+		next:            make(map[string]time.Time),
+		running:         make(map[string]string),
+		starting:        make(map[string]bool),
+		wake:            make(chan struct{}, 1),
+	}
+	s.startRun = s.start
+	gadgetService.SetSendFunc(s.handleEvent)
+	return s
+}
+
+// SetAlertStore enables alert rules for scheduled runs
+func (s *Scheduler) SetAlertStore(store gadget.AlertStore) {
+	s.gadgetService.SetAlertStore(store)
+}
+
+// SetInfoCache makes scheduled runs use the given gadget info cache when validating params
+func (s *Scheduler) SetInfoCache(cache *gadget.InfoCache) {
+	s.gadgetService.SetInfoCache(cache)
+}
+
+// SetMetrics aggregates the events of scheduled runs into the given registry
+func (s *Scheduler) SetMetrics(registry *metrics.Registry) {
+	s.gadgetService.SetMetrics(registry)
+}
+
+// Start runs the scheduler until ctx is done
+func (s *Scheduler) Start(ctx context.Context) {
+	go func() {
+		for {
+			wait := s.runDue(ctx, time.Now())
+			select {
+			case <-ctx.Done():
+				return
+			case <-s.wake:
+			case <-time.After(wait):
+			}
+		}
+	}()
+}
+
+// handleEvent receives the output of scheduled runs; events are only recorded, so
+// only the end of a run is of interest here.
+func (s *Scheduler) handleEvent(ev any) {
+	if gev, ok := ev.(*api.GadgetEvent); ok && gev.Type == api.TypeGadgetStop {
+		log.Printf("scheduler: instance %s finished", gev.InstanceID)
+	}
+}
+
+// notify wakes up the scheduler loop after schedules have been changed
+func (s *Scheduler) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// runDue starts all schedules that are due at now and returns the time to wait until the next one
+func (s *Scheduler) runDue(ctx context.Context, now time.Time) time.Duration {
+	due, wait := s.due(now)
+
+	// Starting a run validates its params, which might have to fetch gadget info from the remote;
+	// schedules are started concurrently, so that one doesn't hold back the others
+	for _, schedule := range due {
+		go s.fire(ctx, schedule)
+	}
+	return wait
+}
+
+// due advances the next activation of all schedules and returns those that are due at now, along
+// with the time to wait until the next activation. A schedule's first activation is computed when
+// it is first seen, so it is never due right away.
+func (s *Scheduler) due(now time.Time) ([]*Schedule, time.Duration) {
+	schedules, err := s.storage.List()
+	if err != nil {
+		log.Printf("scheduler: listing schedules: %v", err)
+		return nil, maxWait
+	}
+
+	wait := maxWait
+	due := make([]*Schedule, 0)
+
+	s.mu.Lock()
+	known := make(map[string]bool, len(schedules))
+	for _, schedule := range schedules {
+		known[schedule.ID] = true
+		if schedule.Paused {
+			delete(s.next, schedule.ID)
+			continue
+		}
+		cron, err := ParseCron(schedule.Cron)
+		if err != nil {
+			log.Printf("scheduler: skipping schedule %s: %v", schedule.ID, err)
+			continue
+		}
+		next, ok := s.next[schedule.ID]
+		if !ok {
+			next = cron.Next(now)
+		} else if !now.Before(next) {
+			due = append(due, schedule)
+			next = cron.Next(now)
+		}
+		if next.IsZero() {
+			delete(s.next, schedule.ID)
+			continue
+		}
+		s.next[schedule.ID] = next
+		if d := next.Sub(now); d < wait {
+			wait = d
+		}
+	}
+	for id := range s.next {
+		if !known[id] {
+			delete(s.next, id)
+		}
+	}
+	s.mu.Unlock()
+	return due, wait
+}
+
+// fire starts a single run of the given schedule, unless the previous one is still being started
+// or running
+func (s *Scheduler) fire(ctx context.Context, schedule *Schedule) {
+	s.mu.Lock()
+	if s.starting[schedule.ID] {
+		s.mu.Unlock()
+		log.Printf("scheduler: schedule %q is still being started, skipping", schedule.Name)
+		return
+	}
+	if instanceID, ok := s.running[schedule.ID]; ok && s.instanceManager.IsRunning(instanceID) {
+		s.mu.Unlock()
+		log.Printf("scheduler: schedule %q is still running (instance %s), skipping", schedule.Name, instanceID)
+		return
+	}
+	s.starting[schedule.ID] = true
+	s.mu.Unlock()
+
+	instanceID, sessionID, err := s.startRun(ctx, schedule)
+	if err != nil {
+		log.Printf("scheduler: starting schedule %q: %v", schedule.Name, err)
+	} else {
+		log.Printf("scheduler: started schedule %q as instance %s", schedule.Name, instanceID)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.starting, schedule.ID)
+
+	// Reload to not overwrite changes that happened in the meantime
+	current, gerr := s.storage.Get(schedule.ID)
+	if gerr != nil {
+		// The schedule has been deleted while its run was being started
+		if instanceID != "" {
+			_ = s.instanceManager.Stop(instanceID)
+		}
+		return
+	}
+	if instanceID != "" {
+		s.running[schedule.ID] = instanceID
+	}
+	current.LastRun = time.Now().UnixMilli()
+	current.LastInstanceID = instanceID
+	current.LastError = ""
+	if err != nil {
+		current.LastError = err.Error()
+	}
+	if sessionID != "" {
+		current.SessionID = sessionID
+	}
+	if err := s.storage.Set(current); err != nil {
+		log.Printf("scheduler: updating schedule %q: %v", schedule.Name, err)
+	}
+}
+
+func (s *Scheduler) start(ctx context.Context, schedule *Schedule) (string, string, error) {
+	env, err := s.envStorage.Get(schedule.EnvironmentID)
+	if err != nil {
+		return "", "", err
+	}
+	runtime, err := s.runtimeFactory.GetRuntime(schedule.EnvironmentID)
+	if err != nil {
+		return "", "", err
+	}
+
+	sessionID, err := s.sessionFor(schedule)
+	if err != nil {
+		log.Printf("scheduler: %v (continuing without recording)", err)
+	}
+
+	runReq := gadget.RunRequest{
+		Image:         schedule.Image,
+		EnvironmentID: schedule.EnvironmentID,
+		Params:        schedule.Params,
+		DefaultParams: env.DefaultParams,
+		Record:        true,
+		SessionID:     sessionID,
+		SessionName:   schedule.Name,
+		Limits:        api.RunLimits{MaxDuration: schedule.Duration}.WithDefaults(env.Limits),
+	}
+	instanceID, err := s.gadgetService.Run(ctx, runtime, runReq)
+	if err != nil {
+		return "", sessionID, err
+	}
+	return instanceID, sessionID, nil
+}
+
+// sessionFor returns the session the schedule records into, creating it if it doesn't exist (anymore)
+func (s *Scheduler) sessionFor(schedule *Schedule) (string, error) {
+	if s.sessionService == nil {
+		return "", nil
+	}
+	if schedule.SessionID != "" {
+		sessions, err := s.sessionService.ListSessions(schedule.EnvironmentID)
+		if err != nil {
+			return "", fmt.Errorf("listing sessions: %w", err)
+		}
+		for _, sess := range sessions {
+			if sess.ID == schedule.SessionID {
+				return sess.ID, nil
+			}
+		}
+	}
+	sessionID, err := s.sessionService.CreateSession(schedule.Name, schedule.EnvironmentID)
+	if err != nil {
+		return "", fmt.Errorf("creating session: %w", err)
+	}
+	return sessionID, nil
+}
+
+// Create validates and persists a new schedule
+func (s *Scheduler) Create(schedule *Schedule) error {
+	if schedule.Image == "" {
+		return &api.ErrInvalidRequest{Reason: "image is required"}
+	}
+	if _, err := ParseCron(schedule.Cron); err != nil {
+		return &api.ErrInvalidRequest{Reason: err.Error()}
+	}
+	if schedule.Duration < 0 {
+		return &api.ErrInvalidRequest{Reason: "duration must not be negative"}
+	}
+	if _, err := s.envStorage.Get(schedule.EnvironmentID); err != nil {
+		return err
+	}
+	if schedule.Name == "" {
+		schedule.Name = fmt.Sprintf("%s (%s)", schedule.Image, schedule.Cron)
+	}
+	schedule.SessionID = ""
+	schedule.LastRun = 0
+	schedule.LastInstanceID = ""
+	schedule.LastError = ""
+
+	s.mu.Lock()
+	err := s.storage.Add(schedule)
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	s.notify()
+	return nil
+}
+
+// List returns all schedules with their runtime state
+func (s *Scheduler) List() ([]*Status, error) {
+	schedules, err := s.storage.List()
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	res := make([]*Status, 0, len(schedules))
+	for _, schedule := range schedules {
+		st := &Status{Schedule: schedule}
+		if next, ok := s.next[schedule.ID]; ok {
+			st.NextRun = next.UnixMilli()
+		}
+		if instanceID, ok := s.running[schedule.ID]; ok {
+			st.Running = s.instanceManager.IsRunning(instanceID)
+		}
+		res = append(res, st)
+	}
+	return res, nil
+}
+
+// SetPaused pauses or resumes a schedule. Pausing doesn't stop a run that is
+// already in progress; a resumed schedule continues with its next activation.
+func (s *Scheduler) SetPaused(id string, paused bool) (*Schedule, error) {
+	s.mu.Lock()
+	schedule, err := s.storage.Get(id)
+	if err == nil {
+		schedule.Paused = paused
+		err = s.storage.Set(schedule)
+	}
+	s.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	s.notify()
+	return schedule, nil
+}
+
+// Delete removes a schedule and stops its run, if any. The recorded session is kept.
+func (s *Scheduler) Delete(id string) error {
+	s.mu.Lock()
+	err := s.storage.Delete(id)
+	if err == nil {
+		if instanceID, ok := s.running[id]; ok {
+			_ = s.instanceManager.Stop(instanceID)
+			delete(s.running, id)
+		}
+		delete(s.next, id)
+	}
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	s.notify()
+	return nil
+}
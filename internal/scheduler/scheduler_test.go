@@ -0,0 +1,357 @@
+// Copyright 2026 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/inspektor-gadget/ig-desktop/pkg/api"
+)
+
+// newTestScheduler returns a scheduler on a temporary storage that starts runs using start
+func newTestScheduler(t *testing.T, start func(ctx context.Context, schedule *Schedule) (string, string, error)) *Scheduler {
+	t.Helper()
+	s := New(NewStorage(t.TempDir()), nil, nil, nil)
+	s.startRun = start
+	return s
+}
+
+func addSchedule(t *testing.T, s *Scheduler, schedule *Schedule) *Schedule {
+	t.Helper()
+	if err := s.storage.Add(schedule); err != nil {
+		t.Fatal(err)
+	}
+	return schedule
+}
+
+func TestDue(t *testing.T) {
+	// Wednesday
+	from := time.Date(2026, 3, 4, 10, 7, 30, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		schedule Schedule
+		now      time.Time
+		wantDue  bool
+		wantWait time.Duration
+		wantNext time.Time
+	}{
+		{
+			name:     "not yet due",
+			schedule: Schedule{Cron: "*/15 * * * *"},
+			now:      from.Add(7 * time.Minute),
+			wantWait: 30 * time.Second,
+			wantNext: time.Date(2026, 3, 4, 10, 15, 0, 0, time.UTC),
+		},
+		{
+			name:     "due",
+			schedule: Schedule{Cron: "*/15 * * * *"},
+			now:      time.Date(2026, 3, 4, 10, 15, 0, 0, time.UTC),
+			wantDue:  true,
+			wantWait: maxWait,
+			wantNext: time.Date(2026, 3, 4, 10, 30, 0, 0, time.UTC),
+		},
+		{
+			name:     "overdue",
+			schedule: Schedule{Cron: "@hourly"},
+			now:      time.Date(2026, 3, 4, 12, 30, 0, 0, time.UTC),
+			wantDue:  true,
+			wantWait: maxWait,
+			wantNext: time.Date(2026, 3, 4, 13, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "paused",
+			schedule: Schedule{Cron: "*/15 * * * *", Paused: true},
+			now:      time.Date(2026, 3, 4, 10, 15, 0, 0, time.UTC),
+			wantWait: maxWait,
+		},
+		{
+			name:     "invalid cron",
+			schedule: Schedule{Cron: "60 * * * *"},
+			now:      time.Date(2026, 3, 4, 10, 15, 0, 0, time.UTC),
+			wantWait: maxWait,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			s := newTestScheduler(t, nil)
+			schedule := addSchedule(t, s, &tc.schedule)
+
+			// The first activation is computed when the schedule is first seen
+			if due, _ := s.due(from); len(due) != 0 {
+				t.Fatalf("expected nothing to be due when first seen, got %d", len(due))
+			}
+
+			due, wait := s.due(tc.now)
+			if got := len(due) == 1 && due[0].ID == schedule.ID; got != tc.wantDue || len(due) > 1 {
+				t.Errorf("got %d due schedules, want due %v", len(due), tc.wantDue)
+			}
+			if wait != tc.wantWait {
+				t.Errorf("got wait %v, want %v", wait, tc.wantWait)
+			}
+			next, ok := s.next[schedule.ID]
+			if tc.wantNext.IsZero() {
+				if ok {
+					t.Errorf("expected no next activation, got %v", next)
+				}
+			} else if !next.Equal(tc.wantNext) {
+				t.Errorf("got next %v, want %v", next, tc.wantNext)
+			}
+		})
+	}
+}
+
+func TestDueForgetsDeletedSchedules(t *testing.T) {
+	s := newTestScheduler(t, nil)
+	schedule := addSchedule(t, s, &Schedule{Cron: "@hourly"})
+
+	now := time.Date(2026, 3, 4, 10, 7, 30, 0, time.UTC)
+	s.due(now)
+	if _, ok := s.next[schedule.ID]; !ok {
+		t.Fatal("expected next activation")
+	}
+	if err := s.storage.Delete(schedule.ID); err != nil {
+		t.Fatal(err)
+	}
+	s.due(now)
+	if len(s.next) != 0 {
+		t.Errorf("expected next activation to be removed, got %v", s.next)
+	}
+}
+
+func TestFire(t *testing.T) {
+	errStart := errors.New("environment not found")
+
+	tests := []struct {
+		name string
+		// prepare sets up the scheduler state before the schedule fires
+		prepare func(s *Scheduler, schedule *Schedule)
+		// start replaces starting the run; it may change the stored schedule meanwhile
+		start       func(s *Scheduler, schedule *Schedule) (string, string, error)
+		wantStarted bool
+		want        func(t *testing.T, s *Scheduler, stored *Schedule)
+	}{
+		{
+			name: "started",
+			start: func(s *Scheduler, schedule *Schedule) (string, string, error) {
+				s.instanceManager.Register("instance", func() {})
+				return "instance", "session", nil
+			},
+			wantStarted: true,
+			want: func(t *testing.T, s *Scheduler, stored *Schedule) {
+				if stored.LastRun == 0 || stored.LastInstanceID != "instance" || stored.SessionID != "session" || stored.LastError != "" {
+					t.Errorf("unexpected schedule: %+v", stored)
+				}
+				if s.running[stored.ID] != "instance" {
+					t.Errorf("expected run to be tracked, got %v", s.running)
+				}
+			},
+		},
+		{
+			name: "failed",
+			start: func(s *Scheduler, schedule *Schedule) (string, string, error) {
+				return "", "session", errStart
+			},
+			wantStarted: true,
+			want: func(t *testing.T, s *Scheduler, stored *Schedule) {
+				if stored.LastRun == 0 || stored.LastInstanceID != "" || stored.LastError != errStart.Error() || stored.SessionID != "session" {
+					t.Errorf("unexpected schedule: %+v", stored)
+				}
+				if _, ok := s.running[stored.ID]; ok {
+					t.Errorf("expected no run to be tracked")
+				}
+			},
+		},
+		{
+			name: "previous run still running",
+			prepare: func(s *Scheduler, schedule *Schedule) {
+				s.instanceManager.Register("previous", func() {})
+				s.running[schedule.ID] = "previous"
+			},
+			want: func(t *testing.T, s *Scheduler, stored *Schedule) {
+				if stored.LastRun != 0 {
+					t.Errorf("expected schedule to be unchanged, got %+v", stored)
+				}
+			},
+		},
+		{
+			name: "previous run finished",
+			prepare: func(s *Scheduler, schedule *Schedule) {
+				s.running[schedule.ID] = "previous"
+			},
+			start: func(s *Scheduler, schedule *Schedule) (string, string, error) {
+				return "instance", "", nil
+			},
+			wantStarted: true,
+			want: func(t *testing.T, s *Scheduler, stored *Schedule) {
+				if stored.LastInstanceID != "instance" || s.running[stored.ID] != "instance" {
+					t.Errorf("unexpected schedule: %+v", stored)
+				}
+			},
+		},
+		{
+			name: "previous run still being started",
+			prepare: func(s *Scheduler, schedule *Schedule) {
+				s.starting[schedule.ID] = true
+			},
+			want: func(t *testing.T, s *Scheduler, stored *Schedule) {
+				if stored.LastRun != 0 {
+					t.Errorf("expected schedule to be unchanged, got %+v", stored)
+				}
+			},
+		},
+		{
+			name: "changed while starting",
+			start: func(s *Scheduler, schedule *Schedule) (string, string, error) {
+				if _, err := s.SetPaused(schedule.ID, true); err != nil {
+					return "", "", err
+				}
+				return "instance", "session", nil
+			},
+			wantStarted: true,
+			want: func(t *testing.T, s *Scheduler, stored *Schedule) {
+				if !stored.Paused || stored.LastInstanceID != "instance" {
+					t.Errorf("expected change and run to be kept, got %+v", stored)
+				}
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			started := false
+			var s *Scheduler
+			s = newTestScheduler(t, func(ctx context.Context, schedule *Schedule) (string, string, error) {
+				started = true
+				return tc.start(s, schedule)
+			})
+			schedule := addSchedule(t, s, &Schedule{Name: "test", Cron: "@hourly"})
+			if tc.prepare != nil {
+				tc.prepare(s, schedule)
+			}
+
+			s.fire(context.Background(), schedule)
+
+			if started != tc.wantStarted {
+				t.Errorf("got started %v, want %v", started, tc.wantStarted)
+			}
+			if started && s.starting[schedule.ID] {
+				t.Errorf("expected schedule to not be starting anymore")
+			}
+			stored, err := s.storage.Get(schedule.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tc.want(t, s, stored)
+		})
+	}
+}
+
+func TestFireDeletedWhileStarting(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var s *Scheduler
+	s = newTestScheduler(t, func(_ context.Context, schedule *Schedule) (string, string, error) {
+		s.instanceManager.Register("instance", cancel)
+		if err := s.Delete(schedule.ID); err != nil {
+			return "", "", err
+		}
+		return "instance", "", nil
+	})
+	schedule := addSchedule(t, s, &Schedule{Name: "test", Cron: "@hourly"})
+
+	s.fire(context.Background(), schedule)
+
+	if ctx.Err() == nil {
+		t.Errorf("expected run of deleted schedule to be stopped")
+	}
+	var notFound *api.ErrScheduleNotFound
+	if _, err := s.storage.Get(schedule.ID); !errors.As(err, &notFound) {
+		t.Errorf("expected schedule to stay deleted, got %v", err)
+	}
+	if _, ok := s.running[schedule.ID]; ok {
+		t.Errorf("expected run to not be tracked")
+	}
+}
+
+func TestSetPaused(t *testing.T) {
+	s := newTestScheduler(t, nil)
+	schedule := addSchedule(t, s, &Schedule{Cron: "@hourly"})
+
+	now := time.Date(2026, 3, 4, 10, 7, 30, 0, time.UTC)
+	s.due(now)
+
+	paused, err := s.SetPaused(schedule.ID, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !paused.Paused {
+		t.Errorf("expected returned schedule to be paused")
+	}
+	if due, _ := s.due(now.Add(time.Hour)); len(due) != 0 {
+		t.Errorf("expected paused schedule to not be due")
+	}
+	if _, ok := s.next[schedule.ID]; ok {
+		t.Errorf("expected paused schedule to have no next activation")
+	}
+
+	// A resumed schedule continues with its next activation instead of catching up
+	if _, err := s.SetPaused(schedule.ID, false); err != nil {
+		t.Fatal(err)
+	}
+	if due, _ := s.due(now.Add(2 * time.Hour)); len(due) != 0 {
+		t.Errorf("expected resumed schedule to not be due right away")
+	}
+	if due, _ := s.due(now.Add(3 * time.Hour)); len(due) != 1 {
+		t.Errorf("expected resumed schedule to be due at its next activation")
+	}
+
+	var notFound *api.ErrScheduleNotFound
+	if _, err := s.SetPaused("00000000-0000-0000-0000-000000000000", true); !errors.As(err, &notFound) {
+		t.Errorf("expected not found error, got %v", err)
+	}
+}
+
+func TestDelete(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	s := newTestScheduler(t, nil)
+	schedule := addSchedule(t, s, &Schedule{Cron: "@hourly"})
+	s.due(time.Date(2026, 3, 4, 10, 7, 30, 0, time.UTC))
+	s.instanceManager.Register("instance", cancel)
+	s.running[schedule.ID] = "instance"
+
+	if err := s.Delete(schedule.ID); err != nil {
+		t.Fatal(err)
+	}
+	if ctx.Err() == nil {
+		t.Errorf("expected run to be stopped")
+	}
+	if _, ok := s.running[schedule.ID]; ok {
+		t.Errorf("expected run to not be tracked")
+	}
+	if _, ok := s.next[schedule.ID]; ok {
+		t.Errorf("expected next activation to be removed")
+	}
+
+	var notFound *api.ErrScheduleNotFound
+	if err := s.Delete(schedule.ID); !errors.As(err, &notFound) {
+		t.Errorf("expected not found error, got %v", err)
+	}
+}
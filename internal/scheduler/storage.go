@@ -0,0 +1,127 @@
+// Copyright 2026 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/google/uuid"
+
+	"github.com/inspektor-gadget/ig-desktop/pkg/api"
+)
+
+// Schedule describes a gadget run that is started periodically
+type Schedule struct {
+	ID            string            `json:"id"`
+	Name          string            `json:"name"`
+	EnvironmentID string            `json:"environmentID"`
+	Image         string            `json:"image"`
+	Params        map[string]string `json:"params"`
+	Cron          string            `json:"cron"`
+	Duration      int64             `json:"duration,omitempty"` // seconds; 0 lets the gadget run until it stops by itself
+	Paused        bool              `json:"paused"`
+
+	// SessionID is the session all runs of this schedule are recorded into
+	SessionID string `json:"sessionId,omitempty"`
+
+	LastRun        int64  `json:"lastRun,omitempty"` // unix ms
+	LastInstanceID string `json:"lastInstanceId,omitempty"`
+	LastError      string `json:"lastError,omitempty"`
+}
+
+// Storage handles persistence of schedules
+type Storage struct {
+	dir string
+}
+
+// NewStorage creates a new Storage instance that persists schedules
+// to the given directory.
+func NewStorage(dir string) *Storage {
+	return &Storage{dir: dir}
+}
+
+// Add creates a new schedule and persists it to disk
+func (s *Storage) Add(schedule *Schedule) error {
+	schedule.ID = uuid.New().String()
+	return s.Set(schedule)
+}
+
+// Set persists a schedule with its existing ID.
+func (s *Storage) Set(schedule *Schedule) error {
+	if err := uuid.Validate(schedule.ID); err != nil {
+		return &api.ErrInvalidRequest{Reason: fmt.Sprintf("invalid schedule ID: %s", schedule.ID)}
+	}
+	filename := filepath.Join(s.dir, schedule.ID+".json")
+	d, _ := json.Marshal(schedule)
+	return os.WriteFile(filename, d, 0o644)
+}
+
+// Delete removes a schedule from disk
+func (s *Storage) Delete(id string) error {
+	if err := uuid.Validate(id); err != nil {
+		return &api.ErrInvalidRequest{Reason: fmt.Sprintf("invalid schedule ID: %s", id)}
+	}
+	err := os.Remove(filepath.Join(s.dir, id+".json"))
+	if os.IsNotExist(err) {
+		return &api.ErrScheduleNotFound{ID: id}
+	}
+	return err
+}
+
+// Get retrieves a single schedule by ID
+func (s *Storage) Get(id string) (*Schedule, error) {
+	if err := uuid.Validate(id); err != nil {
+		return nil, &api.ErrInvalidRequest{Reason: fmt.Sprintf("invalid schedule ID: %s", id)}
+	}
+	b, err := os.ReadFile(filepath.Join(s.dir, id+".json"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, &api.ErrScheduleNotFound{ID: id}
+		}
+		return nil, fmt.Errorf("reading schedule file: %w", err)
+	}
+	var schedule Schedule
+	err = json.Unmarshal(b, &schedule)
+	if err != nil {
+		return nil, fmt.Errorf("parsing schedule file: %w", err)
+	}
+	return &schedule, nil
+}
+
+// List returns all persisted schedules
+func (s *Storage) List() ([]*Schedule, error) {
+	files, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	schedules := make([]*Schedule, 0, len(files))
+	for _, file := range files {
+		b, err := os.ReadFile(filepath.Join(s.dir, file.Name()))
+		if err != nil {
+			continue
+		}
+		var schedule Schedule
+		err = json.Unmarshal(b, &schedule)
+		if err != nil {
+			// Skip invalid files
+			continue
+		}
+		schedules = append(schedules, &schedule)
+	}
+	return schedules, nil
+}
@@ -25,6 +25,7 @@ import (
 
 	"github.com/inspektor-gadget/ig-desktop/internal/app"
 	"github.com/inspektor-gadget/ig-desktop/pkg/api/transport"
+	"github.com/inspektor-gadget/ig-desktop/pkg/metrics"
 )
 
 // Server provides HTTP and WebSocket server functionality for
@@ -38,6 +39,7 @@ type Server struct {
 	clientsMu  sync.Mutex
 	listenAddr string
 	config     []byte
+	metrics    *metrics.Registry
 
 	// AllowedOrigins specifies which origins are allowed for WebSocket connections.
 	// If empty, all origins are allowed (not recommended for production).
@@ -58,6 +60,21 @@ type Config struct {
 
 	// SingleEnvConfig is served as /config.json when configured.
 	SingleEnvConfig []byte
+
+	// Metrics, if set, is fed by all gadget runs and served as /metrics.
+	Metrics *metrics.Registry
+
+	// AllowSinks lets clients forward gadget output to files on the server and to
+	// webhook, OTLP and syslog destinations of their choice.
+	AllowSinks bool
+
+	// AllowHostKubeconfig lets clients load the Kubernetes config of environments from files on
+	// the server, inline kubeconfigs or the in-cluster config.
+	AllowHostKubeconfig bool
+
+	// AllowHostSSHKeys lets clients authenticate SSH environments with key files or the SSH agent
+	// of the server, and choose how host keys are checked.
+	AllowHostSSHKeys bool
 }
 
 // New creates a new HTTP server with the given configuration.
@@ -69,9 +86,23 @@ func New(cfg Config) *Server {
 		clients:        make(map[transport.Transport]struct{}),
 		listenAddr:     cfg.ListenAddr,
 		config:         cfg.SingleEnvConfig,
+		metrics:        cfg.Metrics,
 		AllowedOrigins: cfg.AllowedOrigins,
 	}
 
+	if s.metrics != nil {
+		s.shared.SetMetrics(s.metrics)
+	}
+	if cfg.AllowSinks {
+		s.shared.AllowSinks()
+	}
+	if cfg.AllowHostKubeconfig {
+		s.shared.AllowHostKubeconfig()
+	}
+	if cfg.AllowHostSSHKeys {
+		s.shared.AllowHostSSHKeys()
+	}
+
 	// Set up routes
 	s.setupRoutes()
 
@@ -91,6 +122,10 @@ func (s *Server) setupRoutes() {
 		})
 	}
 
+	if s.metrics != nil {
+		s.mux.Handle("/metrics", s.metrics)
+	}
+
 	// Static file server for the frontend
 	// Use a custom handler to serve index.html for SPA routing
 	s.mux.Handle("/", s.spaHandler())
@@ -0,0 +1,98 @@
+// Copyright 2026 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package session
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/inspektor-gadget/ig-desktop/pkg/api"
+)
+
+// LogFilter selects the log events of a recorded run. Empty fields don't restrict the result.
+type LogFilter struct {
+	Severity string   `json:"severity"` // minimum severity, e.g. "warning"
+	Nodes    []string `json:"nodes"`
+	Sources  []string `json:"sources"`
+}
+
+// RecordedLog is a log event of a recorded run
+type RecordedLog struct {
+	ID        int64 `json:"id"`
+	Timestamp int64 `json:"timestamp"` // unix ms
+	api.GadgetLog
+}
+
+// parseRecordedLog decodes a recorded log payload. Recordings made before node and source were
+// separate fields carry the node as a "node | msg" prefix of the message.
+func parseRecordedLog(data []byte) (api.GadgetLog, error) {
+	var entry api.GadgetLog
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return entry, err
+	}
+	if entry.Node == "" {
+		if node, msg, ok := strings.Cut(entry.Msg, " | "); ok && !strings.Contains(strings.TrimSpace(node), " ") {
+			entry.Node = strings.TrimSpace(node)
+			entry.Msg = msg
+		}
+	}
+	return entry, nil
+}
+
+// GetRunLogs retrieves the log events of a gadget run matching the filter
+func (s *Service) GetRunLogs(sessionID, runID string, filter LogFilter) ([]RecordedLog, error) {
+	maxLevel := logrus.TraceLevel
+	if filter.Severity != "" {
+		level, err := logrus.ParseLevel(filter.Severity)
+		if err != nil {
+			return nil, fmt.Errorf("invalid severity %q", filter.Severity)
+		}
+		maxLevel = level
+	}
+
+	events, err := withSessionDB(s, sessionID, func(db *SessionDB) ([]RecordedEvent, error) {
+		events, err := db.GetRunEventsOfType(runID, api.TypeGadgetLog)
+		if err != nil {
+			return nil, fmt.Errorf("getting run logs: %w", err)
+		}
+		return events, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logs := make([]RecordedLog, 0, len(events))
+	for _, event := range events {
+		entry, err := parseRecordedLog(event.Data)
+		if err != nil {
+			continue
+		}
+		if level, err := logrus.ParseLevel(entry.Severity); err == nil && level > maxLevel {
+			continue
+		}
+		if len(filter.Nodes) > 0 && !slices.Contains(filter.Nodes, entry.Node) {
+			continue
+		}
+		if len(filter.Sources) > 0 && !slices.Contains(filter.Sources, entry.Source) {
+			continue
+		}
+		logs = append(logs, RecordedLog{ID: event.ID, Timestamp: event.Timestamp, GadgetLog: entry})
+	}
+	return logs, nil
+}
@@ -446,7 +446,24 @@ func (sdb *SessionDB) GetRunEvents(runID string) ([]RecordedEvent, error) {
 		ORDER BY timestamp ASC
 	`
 
-	rows, err := sdb.db.Query(query, runID)
+	return sdb.queryEvents(query, runID)
+}
+
+// GetRunEventsOfType retrieves the events of the given type for a gadget run, ordered by timestamp
+func (sdb *SessionDB) GetRunEventsOfType(runID string, eventType int) ([]RecordedEvent, error) {
+	query := `
+		SELECT id, run_id, timestamp, type, datasource_id, data
+		FROM events
+		WHERE run_id = ? AND type = ?
+		ORDER BY timestamp ASC
+	`
+
+	return sdb.queryEvents(query, runID, eventType)
+}
+
+// queryEvents runs a query selecting rows of the events table
+func (sdb *SessionDB) queryEvents(query string, args ...any) ([]RecordedEvent, error) {
+	rows, err := sdb.db.Query(query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("querying events: %w", err)
 	}
@@ -22,6 +22,9 @@ const (
 	TypeGadgetLog          = 4
 	TypeGadgetStop         = 5
 	TypeGadgetEventArray   = 6
+	TypeGadgetReconnect    = 7
+	TypeGadgetAlert        = 8
+	TypeGadgetResult       = 9
 	TypeEnvironmentCreate  = 100
 	TypeEnvironmentDelete  = 101
 	TypeEnvironmentUpdate  = 102
@@ -14,7 +14,10 @@
 
 package api
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 // Error types for better error handling throughout the application
 
@@ -62,3 +65,71 @@ type ErrInvalidRequest struct {
 func (e *ErrInvalidRequest) Error() string {
 	return fmt.Sprintf("invalid request: %s", e.Reason)
 }
+
+// ErrScheduleNotFound indicates the requested schedule does not exist
+type ErrScheduleNotFound struct {
+	ID string
+}
+
+func (e *ErrScheduleNotFound) Error() string {
+	return fmt.Sprintf("schedule not found: %s", e.ID)
+}
+
+// ErrPresetNotFound indicates the requested preset does not exist
+type ErrPresetNotFound struct {
+	ID string
+}
+
+func (e *ErrPresetNotFound) Error() string {
+	return fmt.Sprintf("preset not found: %s", e.ID)
+}
+
+// ErrAlertRuleNotFound indicates the requested alert rule does not exist
+type ErrAlertRuleNotFound struct {
+	ID string
+}
+
+func (e *ErrAlertRuleNotFound) Error() string {
+	return fmt.Sprintf("alert rule not found: %s", e.ID)
+}
+
+// ErrCredentialNotFound indicates the requested credential does not exist
+type ErrCredentialNotFound struct {
+	ID string
+}
+
+func (e *ErrCredentialNotFound) Error() string {
+	return fmt.Sprintf("credential not found: %s", e.ID)
+}
+
+// Reasons a param value can be rejected for
+const (
+	ParamErrorRequired     = "required"
+	ParamErrorNotAllowed   = "notAllowed"
+	ParamErrorInvalidValue = "invalidValue"
+)
+
+// ParamError describes why the value of a single gadget param is invalid. Key is the
+// full key of the param, including its prefix.
+type ParamError struct {
+	Key     string `json:"key"`
+	Value   string `json:"value,omitempty"`
+	Reason  string `json:"reason"`
+	Message string `json:"message"`
+}
+
+// ErrInvalidParams indicates that gadget params failed validation
+type ErrInvalidParams struct {
+	Errors []ParamError
+}
+
+func (e *ErrInvalidParams) Error() string {
+	if len(e.Errors) == 1 {
+		return fmt.Sprintf("invalid param %s: %s", e.Errors[0].Key, e.Errors[0].Message)
+	}
+	keys := make([]string, 0, len(e.Errors))
+	for _, pe := range e.Errors {
+		keys = append(keys, pe.Key)
+	}
+	return fmt.Sprintf("invalid params: %s", strings.Join(keys, ", "))
+}
@@ -0,0 +1,45 @@
+// Copyright 2026 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+// RunLimits bounds a gadget run; the run is stopped as soon as one of the limits is hit.
+// Zero values mean unlimited.
+type RunLimits struct {
+	MaxDuration int64 `json:"maxDuration,omitempty"` // seconds
+	MaxEvents   int64 `json:"maxEvents,omitempty"`   // total events over all datasources
+	MaxBytes    int64 `json:"maxBytes,omitempty"`    // total bytes of event data sent to the client
+}
+
+// WithDefaults returns a copy of the limits where every unset limit is taken from defaults
+func (l RunLimits) WithDefaults(defaults *RunLimits) RunLimits {
+	if defaults == nil {
+		return l
+	}
+	if l.MaxDuration == 0 {
+		l.MaxDuration = defaults.MaxDuration
+	}
+	if l.MaxEvents == 0 {
+		l.MaxEvents = defaults.MaxEvents
+	}
+	if l.MaxBytes == 0 {
+		l.MaxBytes = defaults.MaxBytes
+	}
+	return l
+}
+
+// IsZero returns true if no limit is set
+func (l RunLimits) IsZero() bool {
+	return l.MaxDuration <= 0 && l.MaxEvents <= 0 && l.MaxBytes <= 0
+}
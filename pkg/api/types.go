@@ -49,7 +49,7 @@ type CommandHandler interface {
 
 // GadgetEvent represents an event from a gadget instance
 type GadgetEvent struct {
-	EnvironmentID string          `json:"environmentID,omitempty"`
+	EnvironmentID string          `json:"environmentID,omitempty"` // empty for the stop of a multi-environment run
 	InstanceID    string          `json:"instanceID,omitempty"`
 	Type          int             `json:"type"`
 	Data          json.RawMessage `json:"data"`
@@ -65,3 +65,12 @@ type SessionInfo struct {
 	RunID     string `json:"runId"`
 	IsNew     bool   `json:"isNew"` // true if new session was created
 }
+
+// GadgetLog is the payload of TypeGadgetLog events
+type GadgetLog struct {
+	Severity  string `json:"severity"` // e.g. "warning"
+	Msg       string `json:"msg"`
+	Timestamp string `json:"timestamp"`
+	Node      string `json:"node,omitempty"`   // set for messages concerning a single node
+	Source    string `json:"source,omitempty"` // "gadget" or "runtime" for node messages
+}
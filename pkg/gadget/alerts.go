@@ -0,0 +1,370 @@
+// Copyright 2026 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gadget
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/expr-lang/expr/parser"
+	"github.com/expr-lang/expr/vm"
+	"github.com/google/uuid"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/datasource"
+	dsexpr "github.com/inspektor-gadget/inspektor-gadget/pkg/datasource/expr"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-service/api"
+
+	apiTypes "github.com/inspektor-gadget/ig-desktop/pkg/api"
+)
+
+// alertDumpSize is the number of recent events of a run kept for recording dumps
+const alertDumpSize = 1000
+
+// AlertRule describes a condition on live gadget events that raises an alert
+type AlertRule struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Disabled bool   `json:"disabled,omitempty"`
+
+	// Selectors; empty values match everything. Image matches if it is contained in the image of the run.
+	Image         string `json:"image,omitempty"`
+	InstanceID    string `json:"instanceID,omitempty"`
+	EnvironmentID string `json:"environmentID,omitempty"`
+	Datasource    string `json:"datasource,omitempty"`
+
+	// Expression is a filter expression over the fields of the datasource, e.g.
+	// `proc.comm == "nc" && k8s.namespace matches "^prod-"`
+	Expression string `json:"expression"`
+
+	// Threshold is the number of matching events within Window (seconds) needed to raise the alert;
+	// without a window, matches are counted since the last alert
+	Threshold int   `json:"threshold,omitempty"`
+	Window    int64 `json:"window,omitempty"`
+	// Cooldown is the time in seconds after an alert during which the rule doesn't fire again
+	Cooldown int64 `json:"cooldown,omitempty"`
+
+	// Dump writes the most recent events of the run into a new session when the alert fires
+	Dump bool `json:"dump,omitempty"`
+}
+
+// Alert is raised when an AlertRule matched
+type Alert struct {
+	ID            string          `json:"id"`
+	RuleID        string          `json:"ruleID"`
+	RuleName      string          `json:"ruleName"`
+	InstanceID    string          `json:"instanceID"`
+	EnvironmentID string          `json:"environmentID,omitempty"`
+	Image         string          `json:"image"`
+	Datasource    string          `json:"datasource"`
+	Count         int             `json:"count"`
+	Timestamp     int64           `json:"timestamp"` // unix ms
+	Event         json.RawMessage `json:"event,omitempty"`
+	SessionID     string          `json:"sessionId,omitempty"` // session holding the dump, if any
+}
+
+// AlertStore provides alert rules and persists raised alerts
+type AlertStore interface {
+	AlertRules() ([]*AlertRule, error)
+	AddAlert(alert *Alert) error
+}
+
+// SetAlertStore sets the store alert rules are taken from; rules are evaluated for runs started afterwards
+func (s *Service) SetAlertStore(store AlertStore) {
+	s.alertStore = store
+}
+
+// matches returns true if the rule selects the given run
+func (rule *AlertRule) matches(r *run) bool {
+	if rule.Disabled || rule.Expression == "" {
+		return false
+	}
+	if rule.Image != "" && !strings.Contains(r.image, rule.Image) {
+		return false
+	}
+	if rule.InstanceID != "" && rule.InstanceID != r.instanceID {
+		return false
+	}
+	if rule.EnvironmentID != "" && rule.EnvironmentID != r.environmentID {
+		return false
+	}
+	return true
+}
+
+type ruleState struct {
+	rule      *AlertRule
+	hits      []time.Time
+	lastFired time.Time
+}
+
+type recentEvent struct {
+	eventType int
+	dsName    string
+	data      []byte
+}
+
+// alertEvaluator evaluates the alert rules that apply to a single run
+type alertEvaluator struct {
+	s     *Service
+	r     *run
+	rules []*ruleState
+	dump  bool
+
+	mu     sync.Mutex
+	recent []recentEvent
+	next   int
+}
+
+// alertProgram is a rule compiled against a datasource
+type alertProgram struct {
+	state   *ruleState
+	program *vm.Program
+}
+
+// newAlertEvaluator returns an evaluator for the rules selecting the given run, or nil if there are none
+func (s *Service) newAlertEvaluator(r *run) *alertEvaluator {
+	if s.alertStore == nil {
+		return nil
+	}
+	rules, err := s.alertStore.AlertRules()
+	if err != nil {
+		log.Printf("failed to load alert rules: %v", err)
+		return nil
+	}
+	e := &alertEvaluator{s: s, r: r}
+	for _, rule := range rules {
+		if !rule.matches(r) {
+			continue
+		}
+		e.rules = append(e.rules, &ruleState{rule: rule})
+		e.dump = e.dump || rule.Dump
+	}
+	if len(e.rules) == 0 {
+		return nil
+	}
+	return e
+}
+
+// CheckAlertRule compiles the expression of the rule. If the info of a gadget the rule applies to
+// is cached, the expression is compiled against its datasources like runs do; otherwise only its
+// syntax can be checked, as the fields it may reference aren't known.
+func (s *Service) CheckAlertRule(rule *AlertRule) error {
+	var info *api.GadgetInfo
+	if s.infoCache != nil && rule.Image != "" {
+		info = s.infoCache.Find(rule.EnvironmentID, rule.Image)
+	}
+	return compileAlertExpression(rule, info)
+}
+
+// compileAlertExpression compiles the expression of the rule against the datasources of info that
+// the rule applies to; it is valid if it compiles for at least one of them
+func compileAlertExpression(rule *AlertRule, info *api.GadgetInfo) error {
+	if info == nil {
+		if _, err := parser.Parse(rule.Expression); err != nil {
+			return fmt.Errorf("parsing filter expression: %w", err)
+		}
+		return nil
+	}
+
+	var firstErr error
+	for _, in := range info.DataSources {
+		if rule.Datasource != "" && in.Name != rule.Datasource {
+			continue
+		}
+		ds, err := datasource.NewFromAPI(in)
+		if err != nil {
+			return fmt.Errorf("creating datasource %q: %w", in.Name, err)
+		}
+		_, err = dsexpr.CompileFilterProgram(ds, rule.Expression)
+		if err == nil {
+			return nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	if firstErr == nil && rule.Datasource != "" {
+		return fmt.Errorf("gadget has no datasource %q", rule.Datasource)
+	}
+	return firstErr
+}
+
+// programs compiles the rules for the given datasource; rules referencing fields
+// the datasource doesn't have are skipped
+func (e *alertEvaluator) programs(ds datasource.DataSource) []alertProgram {
+	if e == nil {
+		return nil
+	}
+	var programs []alertProgram
+	for _, state := range e.rules {
+		if state.rule.Datasource != "" && state.rule.Datasource != ds.Name() {
+			continue
+		}
+		program, err := dsexpr.CompileFilterProgram(ds, state.rule.Expression)
+		if err != nil {
+			if state.rule.Datasource != "" {
+				log.Printf("alert rule %q: %v", state.rule.Name, err)
+			}
+			continue
+		}
+		programs = append(programs, alertProgram{state: state, program: program})
+	}
+	return programs
+}
+
+// remember keeps the event for recording dumps
+func (e *alertEvaluator) remember(eventType int, dsName string, data []byte) {
+	if e == nil || !e.dump {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	ev := recentEvent{eventType: eventType, dsName: dsName, data: data}
+	if len(e.recent) < alertDumpSize {
+		e.recent = append(e.recent, ev)
+		return
+	}
+	e.recent[e.next] = ev
+	e.next = (e.next + 1) % alertDumpSize
+}
+
+// snapshot returns the remembered events in order
+func (e *alertEvaluator) snapshot() []recentEvent {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	res := make([]recentEvent, 0, len(e.recent))
+	res = append(res, e.recent[e.next:]...)
+	return append(res, e.recent[:e.next]...)
+}
+
+// check evaluates the programs against a single event; marshal is only called if an alert fires
+func (e *alertEvaluator) check(programs []alertProgram, dsName string, data datasource.Data, marshal func() []byte) {
+	for _, p := range programs {
+		res, err := dsexpr.Run(p.program, data)
+		if err != nil {
+			continue
+		}
+		if match, _ := res.(bool); !match {
+			continue
+		}
+		if count, ok := e.hit(p.state, time.Now()); ok {
+			e.fire(p.state.rule, dsName, count, marshal())
+		}
+	}
+}
+
+// hit accounts a match of the rule and returns whether it should fire
+func (e *alertEvaluator) hit(state *ruleState, now time.Time) (int, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	rule := state.rule
+	if rule.Cooldown > 0 && !state.lastFired.IsZero() && now.Sub(state.lastFired) < time.Duration(rule.Cooldown)*time.Second {
+		return 0, false
+	}
+
+	state.hits = append(state.hits, now)
+	if rule.Window > 0 {
+		cutoff := now.Add(-time.Duration(rule.Window) * time.Second)
+		i := 0
+		for i < len(state.hits) && state.hits[i].Before(cutoff) {
+			i++
+		}
+		state.hits = state.hits[i:]
+	}
+
+	threshold := max(rule.Threshold, 1)
+	if len(state.hits) < threshold {
+		return 0, false
+	}
+	count := len(state.hits)
+	state.hits = nil
+	state.lastFired = now
+	return count, true
+}
+
+// fire sends and persists the alert; the optional recording dump happens in the background
+func (e *alertEvaluator) fire(rule *AlertRule, dsName string, count int, event []byte) {
+	alert := &Alert{
+		ID:            uuid.New().String(),
+		RuleID:        rule.ID,
+		RuleName:      rule.Name,
+		InstanceID:    e.r.instanceID,
+		EnvironmentID: e.r.environmentID,
+		Image:         e.r.image,
+		Datasource:    dsName,
+		Count:         count,
+		Timestamp:     time.Now().UnixMilli(),
+		Event:         event,
+	}
+
+	var recent []recentEvent
+	if rule.Dump {
+		recent = e.snapshot()
+	}
+
+	go func() {
+		if rule.Dump {
+			alert.SessionID = e.dumpRecording(alert, recent)
+		}
+		if err := e.s.alertStore.AddAlert(alert); err != nil {
+			log.Printf("failed to store alert: %v", err)
+		}
+
+		data, _ := json.Marshal(alert)
+		e.s.send(&apiTypes.GadgetEvent{
+			Type:          apiTypes.TypeGadgetAlert,
+			EnvironmentID: e.r.environmentID,
+			InstanceID:    e.r.instanceID,
+			Data:          data,
+		})
+		if e.r.record && e.s.sessionRecorder != nil {
+			if err := e.s.sessionRecorder.WriteEvent(e.r.recordingID, apiTypes.TypeGadgetAlert, dsName, data); err != nil {
+				log.Printf("failed to write alert to session: %v", err)
+			}
+		}
+	}()
+}
+
+// dumpRecording writes the given events into a new session and returns its ID
+func (e *alertEvaluator) dumpRecording(alert *Alert, recent []recentEvent) string {
+	if e.s.sessionRecorder == nil {
+		return ""
+	}
+	name := fmt.Sprintf("alert: %s (%s)", alert.RuleName, time.UnixMilli(alert.Timestamp).Format("2006-01-02 15:04:05"))
+	sessionID, err := e.s.sessionRecorder.CreateSession(name, e.r.environmentID)
+	if err != nil {
+		log.Printf("failed to create session for alert dump: %v", err)
+		return ""
+	}
+
+	recordingID := "alert/" + alert.ID
+	if _, err := e.s.sessionRecorder.StartGadgetRun(recordingID, sessionID, e.r.image, e.r.params, e.r.gadgetInfo); err != nil {
+		log.Printf("failed to start gadget run for alert dump: %v", err)
+		return ""
+	}
+	for _, ev := range recent {
+		if err := e.s.sessionRecorder.WriteEvent(recordingID, ev.eventType, ev.dsName, ev.data); err != nil {
+			log.Printf("failed to write event to alert dump: %v", err)
+			break
+		}
+	}
+	if err := e.s.sessionRecorder.StopGadgetRun(recordingID); err != nil {
+		log.Printf("failed to stop gadget run for alert dump: %v", err)
+	}
+	return sessionID
+}
@@ -0,0 +1,125 @@
+// Copyright 2026 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gadget
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/datasource"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-service/api"
+)
+
+func TestAlertEvaluatorHit(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		rule AlertRule
+		hits []int64 // seconds since the start
+		// counts are the expected counts of the raised alerts per hit; 0 if it doesn't fire
+		counts []int
+	}{
+		{
+			name:   "every match fires by default",
+			rule:   AlertRule{},
+			hits:   []int64{0, 1, 2},
+			counts: []int{1, 1, 1},
+		},
+		{
+			name:   "threshold without window",
+			rule:   AlertRule{Threshold: 3},
+			hits:   []int64{0, 10, 100, 101, 102, 1000},
+			counts: []int{0, 0, 3, 0, 0, 3},
+		},
+		{
+			name:   "hits outside of the window are dropped",
+			rule:   AlertRule{Threshold: 2, Window: 10},
+			hits:   []int64{0, 20, 25, 40},
+			counts: []int{0, 0, 2, 0},
+		},
+		{
+			name:   "cooldown",
+			rule:   AlertRule{Cooldown: 30},
+			hits:   []int64{0, 10, 29, 30, 31},
+			counts: []int{1, 0, 0, 1, 0},
+		},
+		{
+			name:   "matches during the cooldown aren't counted",
+			rule:   AlertRule{Threshold: 2, Cooldown: 10},
+			hits:   []int64{0, 1, 5, 20, 21},
+			counts: []int{0, 2, 0, 0, 2},
+		},
+	} {
+		e := &alertEvaluator{}
+		state := &ruleState{rule: &tc.rule}
+		start := time.Unix(1_700_000_000, 0)
+
+		var counts []int
+		for _, offset := range tc.hits {
+			count, ok := e.hit(state, start.Add(time.Duration(offset)*time.Second))
+			if ok != (count > 0) {
+				t.Fatalf("%s: count %d doesn't match fired=%v", tc.name, count, ok)
+			}
+			counts = append(counts, count)
+		}
+		if !slices.Equal(counts, tc.counts) {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.counts, counts)
+		}
+	}
+}
+
+func TestCompileAlertExpression(t *testing.T) {
+	info := &api.GadgetInfo{
+		DataSources: []*api.DataSource{
+			{
+				Name: "exec",
+				Type: uint32(datasource.TypeSingle),
+				Fields: []*api.Field{
+					{Name: "comm", FullName: "comm", Kind: api.Kind_String},
+					{Name: "pid", FullName: "pid", Kind: api.Kind_Uint32},
+				},
+			},
+			{
+				Name: "open",
+				Type: uint32(datasource.TypeSingle),
+				Fields: []*api.Field{
+					{Name: "fname", FullName: "fname", Kind: api.Kind_String},
+				},
+			},
+		},
+	}
+
+	for _, tc := range []struct {
+		rule  AlertRule
+		info  *api.GadgetInfo
+		valid bool
+	}{
+		{AlertRule{Expression: `comm == "nc" && pid > 1`}, info, true},
+		{AlertRule{Expression: `fname contains "shadow"`}, info, true},
+		{AlertRule{Expression: `fname contains "shadow"`, Datasource: "exec"}, info, false},
+		{AlertRule{Expression: `comm == "nc"`, Datasource: "missing"}, info, false},
+		{AlertRule{Expression: `unknown == 1`}, info, false},
+		{AlertRule{Expression: `comm`}, info, false},
+		// Without info, only the syntax is checked
+		{AlertRule{Expression: `unknown == 1`}, nil, true},
+		{AlertRule{Expression: `comm ==`}, nil, false},
+	} {
+		err := compileAlertExpression(&tc.rule, tc.info)
+		if (err == nil) != tc.valid {
+			t.Errorf("%q (datasource %q, info %v): expected valid=%v, got %v",
+				tc.rule.Expression, tc.rule.Datasource, tc.info != nil, tc.valid, err)
+		}
+	}
+}
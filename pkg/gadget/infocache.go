@@ -0,0 +1,296 @@
+// Copyright 2026 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gadget
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	gadgetcontext "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-context"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-service/api"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	grpcruntime "github.com/inspektor-gadget/ig-desktop/pkg/grpc-runtime"
+)
+
+// DefaultInfoCacheTTL is how long cached gadget info is used before it is fetched again
+const DefaultInfoCacheTTL = time.Hour
+
+// ociDigestKey is the extra info holding the digest of the gadget image
+const ociDigestKey = "oci.digest"
+
+// CachedInfo is a persisted gadget info of an image in an environment
+type CachedInfo struct {
+	EnvironmentID string `json:"environmentID"`
+	Image         string `json:"image"`
+	// Digest is set if the image reference is pinned to a digest or the runtime reported one
+	Digest  string          `json:"digest,omitempty"`
+	Fetched int64           `json:"fetched"` // unix ms
+	Info    json.RawMessage `json:"info"`    // protojson encoded api.GadgetInfo
+}
+
+// InfoCache caches gadget info per environment and image. Entries are persisted to a directory,
+// so that known gadgets can be viewed when the environment isn't reachable.
+//
+// Entries are keyed by the image reference as given. Resolving a tag to its digest takes the same
+// request as fetching the info, so entries of images that aren't pinned to a digest are only
+// refetched once their TTL is over; the digest reported by the refetch tells whether the image
+// changed. Params that fail validation against such an entry are checked again against freshly
+// fetched info, so that a retagged image doesn't reject params it accepts.
+type InfoCache struct {
+	dir string
+	ttl time.Duration
+
+	mu      sync.Mutex
+	entries map[string]*CachedInfo
+}
+
+// NewInfoCache creates a cache persisted to dir and loads the entries already stored there
+func NewInfoCache(dir string, ttl time.Duration) (*InfoCache, error) {
+	if ttl <= 0 {
+		ttl = DefaultInfoCacheTTL
+	}
+	c := &InfoCache{
+		dir:     dir,
+		ttl:     ttl,
+		entries: make(map[string]*CachedInfo),
+	}
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		b, err := os.ReadFile(filepath.Join(dir, file.Name()))
+		if err != nil {
+			continue
+		}
+		var entry CachedInfo
+		if err := json.Unmarshal(b, &entry); err != nil {
+			// Skip invalid files
+			continue
+		}
+		c.entries[infoCacheKey(entry.EnvironmentID, entry.Image)] = &entry
+	}
+	return c, nil
+}
+
+func infoCacheKey(environmentID, image string) string {
+	sum := sha256.Sum256([]byte(environmentID + "\n" + image))
+	return hex.EncodeToString(sum[:])
+}
+
+// imageDigest returns the digest an image reference is pinned to, if any
+func imageDigest(image string) string {
+	if _, digest, ok := strings.Cut(image, "@"); ok {
+		return digest
+	}
+	return ""
+}
+
+// Get returns the cached info of the image. fresh is false if the entry is older than the TTL;
+// entries of images pinned to a digest never go stale.
+func (c *InfoCache) Get(environmentID, image string) (info *api.GadgetInfo, fresh bool, ok bool) {
+	c.mu.Lock()
+	entry, ok := c.entries[infoCacheKey(environmentID, image)]
+	c.mu.Unlock()
+	if !ok {
+		return nil, false, false
+	}
+	info = &api.GadgetInfo{}
+	if err := protojson.Unmarshal(entry.Info, info); err != nil {
+		return nil, false, false
+	}
+	fresh = imageDigest(image) != "" || time.Since(time.UnixMilli(entry.Fetched)) < c.ttl
+	return info, fresh, true
+}
+
+// Find returns the cached info of the first image, by name, that contains the given one. If
+// environmentID is empty, entries of all environments are considered.
+func (c *InfoCache) Find(environmentID, image string) *api.GadgetInfo {
+	c.mu.Lock()
+	var found *CachedInfo
+	for _, entry := range c.entries {
+		if environmentID != "" && entry.EnvironmentID != environmentID {
+			continue
+		}
+		if strings.Contains(entry.Image, image) && (found == nil || entry.Image < found.Image) {
+			found = entry
+		}
+	}
+	c.mu.Unlock()
+	if found == nil {
+		return nil
+	}
+	info := &api.GadgetInfo{}
+	if err := protojson.Unmarshal(found.Info, info); err != nil {
+		return nil
+	}
+	return info
+}
+
+// infoDigest returns the image digest reported in the extra info of a gadget info, if any
+func infoDigest(info *api.GadgetInfo) string {
+	if addendum, ok := info.GetExtraInfo().GetData()[ociDigestKey]; ok {
+		return string(addendum.Content)
+	}
+	return ""
+}
+
+// Set stores the info of the image. The digest is taken from the image reference if it is pinned,
+// or from the extra info of the gadget info.
+func (c *InfoCache) Set(environmentID, image string, info *api.GadgetInfo) error {
+	data, err := protojson.Marshal(info)
+	if err != nil {
+		return fmt.Errorf("marshaling gadget info: %w", err)
+	}
+	entry := &CachedInfo{
+		EnvironmentID: environmentID,
+		Image:         image,
+		Digest:        imageDigest(image),
+		Fetched:       time.Now().UnixMilli(),
+		Info:          data,
+	}
+	if entry.Digest == "" {
+		entry.Digest = infoDigest(info)
+	}
+
+	key := infoCacheKey(environmentID, image)
+	c.mu.Lock()
+	previous, ok := c.entries[key]
+	c.mu.Unlock()
+	if ok && previous.Digest != "" && entry.Digest != "" && previous.Digest != entry.Digest {
+		log.Printf("gadget %s in environment %s changed from %s to %s", image, environmentID, previous.Digest, entry.Digest)
+	}
+
+	b, _ := json.Marshal(entry)
+	if err := os.WriteFile(filepath.Join(c.dir, key+".json"), b, 0o644); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	c.entries[key] = entry
+	c.mu.Unlock()
+	return nil
+}
+
+// Invalidate removes the entry of the image, or all entries of the environment if image is empty
+func (c *InfoCache) Invalidate(environmentID, image string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var keys []string
+	if image != "" {
+		keys = append(keys, infoCacheKey(environmentID, image))
+	} else {
+		for key, entry := range c.entries {
+			if entry.EnvironmentID == environmentID {
+				keys = append(keys, key)
+			}
+		}
+	}
+	for _, key := range keys {
+		delete(c.entries, key)
+		if err := os.Remove(filepath.Join(c.dir, key+".json")); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// List returns the cached entries of an environment, sorted by image
+func (c *InfoCache) List(environmentID string) []*CachedInfo {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	res := make([]*CachedInfo, 0)
+	for _, entry := range c.entries {
+		if entry.EnvironmentID == environmentID {
+			res = append(res, entry)
+		}
+	}
+	slices.SortFunc(res, func(a, b *CachedInfo) int { return strings.Compare(a.Image, b.Image) })
+	return res
+}
+
+// SetInfoCache enables caching of gadget info
+func (s *Service) SetInfoCache(cache *InfoCache) {
+	s.infoCache = cache
+}
+
+// InfoCache returns the gadget info cache, or nil if caching is disabled
+func (s *Service) InfoCache() *InfoCache {
+	return s.infoCache
+}
+
+// GetCachedInfo returns the gadget info of the image in the environment, using the cache if enabled.
+// Fresh entries are returned without contacting the environment unless refresh is set. If fetching
+// fails, a stale entry is returned instead, so that known gadgets stay usable offline.
+func (s *Service) GetCachedInfo(ctx context.Context, runtime *grpcruntime.Runtime, environmentID, image string, refresh bool) (*api.GadgetInfo, error) {
+	info, _, err := s.cachedInfo(ctx, runtime, environmentID, image, refresh)
+	return info, err
+}
+
+// cachedInfo works like GetCachedInfo and additionally returns whether the info has been fetched
+// from the environment instead of being taken from the cache
+func (s *Service) cachedInfo(ctx context.Context, runtime *grpcruntime.Runtime, environmentID, image string, refresh bool) (*api.GadgetInfo, bool, error) {
+	if s.infoCache == nil {
+		info, err := s.GetInfo(ctx, runtime, image)
+		return info, err == nil, err
+	}
+
+	cached, fresh, ok := s.infoCache.Get(environmentID, image)
+	if ok && fresh && !refresh {
+		return cached, false, nil
+	}
+
+	info, err := s.fetchInfo(ctx, runtime, image)
+	if err != nil {
+		if ok {
+			log.Printf("failed to get gadget info for %s, using cached info: %v", image, err)
+			return cached, false, nil
+		}
+		return nil, false, err
+	}
+	if err := s.infoCache.Set(environmentID, image, info); err != nil {
+		log.Printf("failed to cache gadget info for %s: %v", image, err)
+	}
+	return info, true, nil
+}
+
+// fetchInfo gets the gadget info of the image along with the digest the image resolved to. Other
+// extra info, like the manifest, is dropped.
+func (s *Service) fetchInfo(ctx context.Context, runtime *grpcruntime.Runtime, image string) (*api.GadgetInfo, error) {
+	gadgetCtx := gadgetcontext.New(ctx, image, gadgetcontext.IncludeExtraInfo(true))
+	info, err := runtime.GetGadgetInfo(gadgetCtx, runtime.ParamDescs().ToParams(), nil)
+	if err != nil {
+		return nil, err
+	}
+	digest, ok := info.GetExtraInfo().GetData()[ociDigestKey]
+	info.ExtraInfo = nil
+	if ok {
+		info.ExtraInfo = &api.ExtraInfo{Data: map[string]*api.GadgetInspectAddendum{ociDigestKey: digest}}
+	}
+	return info, nil
+}
@@ -0,0 +1,93 @@
+// Copyright 2026 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gadget
+
+import (
+	"testing"
+	"time"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-service/api"
+)
+
+func TestInfoCache(t *testing.T) {
+	dir := t.TempDir()
+	cache, err := NewInfoCache(dir, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cache.Set("env1", "trace_exec:latest", &api.GadgetInfo{Name: "trace exec"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := cache.Set("env1", "trace_open@sha256:abc", &api.GadgetInfo{Name: "trace open"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := cache.Set("env2", "trace_exec:latest", &api.GadgetInfo{Name: "trace exec"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Entries survive a restart; with a tiny TTL only the pinned image stays fresh
+	cache, err = NewInfoCache(dir, time.Nanosecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, fresh, ok := cache.Get("env1", "trace_exec:latest")
+	if !ok || fresh || info.Name != "trace exec" {
+		t.Errorf("unexpected entry: %v, fresh %v, ok %v", info, fresh, ok)
+	}
+	if _, fresh, ok := cache.Get("env1", "trace_open@sha256:abc"); !ok || !fresh {
+		t.Errorf("expected pinned image to be fresh")
+	}
+	if list := cache.List("env1"); len(list) != 2 || list[1].Digest != "sha256:abc" {
+		t.Errorf("unexpected list: %+v", list)
+	}
+
+	if err := cache.Invalidate("env1", ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, ok := cache.Get("env1", "trace_exec:latest"); ok {
+		t.Errorf("expected entry to be invalidated")
+	}
+	if _, _, ok := cache.Get("env2", "trace_exec:latest"); !ok {
+		t.Errorf("expected entry of other environment to be kept")
+	}
+}
+
+func TestInfoCacheReportedDigest(t *testing.T) {
+	cache, err := NewInfoCache(t.TempDir(), time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := &api.GadgetInfo{
+		Name: "trace exec",
+		ExtraInfo: &api.ExtraInfo{Data: map[string]*api.GadgetInspectAddendum{
+			ociDigestKey: {Content: []byte("sha256:abc")},
+		}},
+	}
+	if err := cache.Set("env1", "trace_exec:latest", info); err != nil {
+		t.Fatal(err)
+	}
+	if list := cache.List("env1"); len(list) != 1 || list[0].Digest != "sha256:abc" {
+		t.Fatalf("unexpected list: %+v", list)
+	}
+
+	// A moved tag replaces the digest
+	info.ExtraInfo.Data[ociDigestKey].Content = []byte("sha256:def")
+	if err := cache.Set("env1", "trace_exec:latest", info); err != nil {
+		t.Fatal(err)
+	}
+	if list := cache.List("env1"); len(list) != 1 || list[0].Digest != "sha256:def" {
+		t.Errorf("unexpected list: %+v", list)
+	}
+}
@@ -61,3 +61,11 @@ func (m *InstanceManager) Unregister(instanceID string) {
 	defer m.mu.Unlock()
 	delete(m.cancellers, instanceID)
 }
+
+// IsRunning returns true if the instance is registered and has not completed yet
+func (m *InstanceManager) IsRunning(instanceID string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, ok := m.cancellers[instanceID]
+	return ok
+}
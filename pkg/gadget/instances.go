@@ -0,0 +1,200 @@
+// Copyright 2026 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gadget
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	gadgetcontext "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-context"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-service/api"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/logger"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/params"
+	"gopkg.in/yaml.v3"
+
+	apiTypes "github.com/inspektor-gadget/ig-desktop/pkg/api"
+	grpcruntime "github.com/inspektor-gadget/ig-desktop/pkg/grpc-runtime"
+)
+
+// InstanceExportVersion is the version of the format written by ExportInstances
+const InstanceExportVersion = 1
+
+// InstanceFilter selects gadget instances. All given criteria have to match; an empty filter
+// matches every instance.
+type InstanceFilter struct {
+	IDs  []string `json:"ids,omitempty"`
+	Tags []string `json:"tags,omitempty"` // instances need to have all of these tags
+	Name string   `json:"name,omitempty"` // pattern as understood by path.Match, e.g. "trace-*"
+}
+
+// IsZero returns true if the filter doesn't restrict the instances
+func (f InstanceFilter) IsZero() bool {
+	return len(f.IDs) == 0 && len(f.Tags) == 0 && f.Name == ""
+}
+
+// Match returns whether the instance matches the filter
+func (f InstanceFilter) Match(instance *api.GadgetInstance) bool {
+	if len(f.IDs) > 0 && !slices.Contains(f.IDs, instance.Id) {
+		return false
+	}
+	for _, tag := range f.Tags {
+		if !slices.Contains(instance.Tags, tag) {
+			return false
+		}
+	}
+	if f.Name != "" {
+		if ok, _ := path.Match(f.Name, instance.Name); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// validate checks that the name pattern is well-formed
+func (f InstanceFilter) validate() error {
+	if _, err := path.Match(f.Name, ""); err != nil {
+		return &apiTypes.ErrInvalidRequest{Reason: fmt.Sprintf("invalid name pattern %q", f.Name)}
+	}
+	return nil
+}
+
+// InstanceSpec is the portable definition of a gadget instance
+type InstanceSpec struct {
+	Name   string            `json:"name" yaml:"name"`
+	Image  string            `json:"image" yaml:"image"`
+	Tags   []string          `json:"tags,omitempty" yaml:"tags,omitempty"`
+	Nodes  []string          `json:"nodes,omitempty" yaml:"nodes,omitempty"`
+	Params map[string]string `json:"params,omitempty" yaml:"params,omitempty"`
+}
+
+// InstanceBundle is the exchange format for gadget instances
+type InstanceBundle struct {
+	Version   int             `json:"version" yaml:"version"`
+	Instances []*InstanceSpec `json:"instances" yaml:"instances"`
+}
+
+// FindInstances returns the instances of the runtime that match the filter
+func (s *Service) FindInstances(ctx context.Context, runtime *grpcruntime.Runtime, filter InstanceFilter) ([]*api.GadgetInstance, error) {
+	if err := filter.validate(); err != nil {
+		return nil, err
+	}
+	instances, err := s.ListInstances(ctx, runtime)
+	if err != nil {
+		return nil, err
+	}
+	return slices.DeleteFunc(instances, func(instance *api.GadgetInstance) bool {
+		return !filter.Match(instance)
+	}), nil
+}
+
+// RemoveInstances removes all instances matching the filter and returns the IDs of the removed ones.
+// Instances that couldn't be removed are reported in the returned error.
+func (s *Service) RemoveInstances(ctx context.Context, runtime *grpcruntime.Runtime, filter InstanceFilter) ([]string, error) {
+	instances, err := s.FindInstances(ctx, runtime, filter)
+	if err != nil {
+		return nil, err
+	}
+	removed := make([]string, 0, len(instances))
+	var errs []error
+	for _, instance := range instances {
+		if err := s.RemoveInstance(ctx, runtime, instance.Id); err != nil {
+			errs = append(errs, fmt.Errorf("removing instance %s (%s): %w", instance.Name, instance.Id, err))
+			continue
+		}
+		removed = append(removed, instance.Id)
+	}
+	return removed, errors.Join(errs...)
+}
+
+// ExportInstances returns the definitions of all instances matching the filter as YAML
+func (s *Service) ExportInstances(ctx context.Context, runtime *grpcruntime.Runtime, filter InstanceFilter) ([]byte, error) {
+	instances, err := s.FindInstances(ctx, runtime, filter)
+	if err != nil {
+		return nil, err
+	}
+	bundle := &InstanceBundle{
+		Version:   InstanceExportVersion,
+		Instances: make([]*InstanceSpec, 0, len(instances)),
+	}
+	for _, instance := range instances {
+		spec := &InstanceSpec{
+			Name:  instance.Name,
+			Nodes: instance.Nodes,
+			Tags: slices.DeleteFunc(slices.Clone(instance.Tags), func(tag string) bool {
+				return tag == ""
+			}),
+		}
+		if cfg := instance.GadgetConfig; cfg != nil {
+			spec.Image = cfg.ImageName
+			spec.Params = cfg.ParamValues
+		}
+		bundle.Instances = append(bundle.Instances, spec)
+	}
+	return yaml.Marshal(bundle)
+}
+
+// ParseInstanceBundle parses a bundle written by ExportInstances
+func ParseInstanceBundle(data []byte) (*InstanceBundle, error) {
+	bundle := &InstanceBundle{}
+	if err := yaml.Unmarshal(data, bundle); err != nil {
+		return nil, &apiTypes.ErrInvalidRequest{Reason: fmt.Sprintf("parsing instances: %v", err)}
+	}
+	if bundle.Version != InstanceExportVersion {
+		return nil, &apiTypes.ErrInvalidRequest{Reason: fmt.Sprintf("unsupported instance bundle version %d", bundle.Version)}
+	}
+	for i, spec := range bundle.Instances {
+		if spec == nil || spec.Image == "" {
+			return nil, &apiTypes.ErrInvalidRequest{Reason: fmt.Sprintf("instance %d has no image", i)}
+		}
+	}
+	return bundle, nil
+}
+
+// CreateInstance validates the params of the spec and creates a detached gadget instance from it.
+// Unlike detached runs started by Run, it blocks until the instance has been created.
+func (s *Service) CreateInstance(ctx context.Context, runtime *grpcruntime.Runtime, environmentID string, spec *InstanceSpec) error {
+	err := s.validateRun(ctx, runtime, RunRequest{
+		Image:         spec.Image,
+		EnvironmentID: environmentID,
+		Params:        spec.Params,
+	})
+	if err != nil {
+		return err
+	}
+
+	rtParams := runtime.ParamDescs().ToParams()
+	setDetachParams(rtParams, spec.Name, spec.Tags, spec.Nodes)
+
+	gadgetCtx := gadgetcontext.New(ctx, spec.Image, gadgetcontext.WithLogger(logger.DefaultLogger()))
+	return runtime.RunGadget(gadgetCtx, rtParams, spec.Params)
+}
+
+// setDetachParams sets the runtime params to create a detached instance
+func setDetachParams(rtParams *params.Params, name string, tags []string, nodes []string) {
+	rtParams.Set(grpcruntime.ParamDetach, "true")
+	if name != "" {
+		rtParams.Set(grpcruntime.ParamName, name)
+	}
+	if len(tags) > 0 {
+		rtParams.Set(grpcruntime.ParamTags, strings.Join(tags, ","))
+	}
+	if len(nodes) > 0 {
+		rtParams.Set(grpcruntime.ParamNode, strings.Join(nodes, ","))
+	}
+}
@@ -0,0 +1,81 @@
+// Copyright 2026 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gadget
+
+import (
+	"testing"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-service/api"
+	"gopkg.in/yaml.v3"
+)
+
+func TestInstanceFilter(t *testing.T) {
+	instance := &api.GadgetInstance{Id: "abc", Name: "trace-exec-1", Tags: []string{"team-a", "prod"}}
+
+	tests := []struct {
+		name   string
+		filter InstanceFilter
+		match  bool
+	}{
+		{"empty", InstanceFilter{}, true},
+		{"id", InstanceFilter{IDs: []string{"xyz", "abc"}}, true},
+		{"other id", InstanceFilter{IDs: []string{"xyz"}}, false},
+		{"tags", InstanceFilter{Tags: []string{"prod", "team-a"}}, true},
+		{"missing tag", InstanceFilter{Tags: []string{"prod", "team-b"}}, false},
+		{"name pattern", InstanceFilter{Name: "trace-*"}, true},
+		{"other name", InstanceFilter{Name: "top-*"}, false},
+		{"combined", InstanceFilter{Name: "trace-*", Tags: []string{"dev"}}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.filter.Match(instance); got != tt.match {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.match, got)
+		}
+	}
+
+	if err := (InstanceFilter{Name: "["}).validate(); err == nil {
+		t.Error("expected error for invalid pattern")
+	}
+}
+
+func TestParseInstanceBundle(t *testing.T) {
+	bundle := &InstanceBundle{
+		Version: InstanceExportVersion,
+		Instances: []*InstanceSpec{{
+			Name:   "exec",
+			Image:  "trace_exec",
+			Tags:   []string{"prod"},
+			Params: map[string]string{"operator.oci.ebpf.paths": "true"},
+		}},
+	}
+	data, err := yaml.Marshal(bundle)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, err := ParseInstanceBundle(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(parsed.Instances) != 1 || parsed.Instances[0].Image != "trace_exec" || parsed.Instances[0].Params["operator.oci.ebpf.paths"] != "true" {
+		t.Errorf("unexpected bundle: %+v", parsed.Instances)
+	}
+
+	if _, err := ParseInstanceBundle([]byte("version: 2\ninstances: []\n")); err == nil {
+		t.Error("expected error for unsupported version")
+	}
+	if _, err := ParseInstanceBundle([]byte("version: 1\ninstances:\n- name: foo\n")); err == nil {
+		t.Error("expected error for missing image")
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright 2026 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gadget
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	apiTypes "github.com/inspektor-gadget/ig-desktop/pkg/api"
+)
+
+// Names of the limits as reported in TypeGadgetStop events
+const (
+	LimitDuration = "maxDuration"
+	LimitEvents   = "maxEvents"
+	LimitBytes    = "maxBytes"
+)
+
+// limitValue returns the configured value of the named limit
+func limitValue(l apiTypes.RunLimits, limit string) int64 {
+	switch limit {
+	case LimitDuration:
+		return l.MaxDuration
+	case LimitEvents:
+		return l.MaxEvents
+	case LimitBytes:
+		return l.MaxBytes
+	}
+	return 0
+}
+
+// limiter tracks a running instance against its RunLimits and calls stop once the first limit is hit.
+// A nil limiter never triggers.
+type limiter struct {
+	limits apiTypes.RunLimits
+	stop   func()
+	timer  *time.Timer
+
+	events atomic.Int64
+	bytes  atomic.Int64
+
+	once      sync.Once
+	triggered atomic.Value // string
+}
+
+// newLimiter returns a limiter for the given limits or nil if no limit is set
+func newLimiter(limits apiTypes.RunLimits, stop func()) *limiter {
+	if limits.IsZero() {
+		return nil
+	}
+	l := &limiter{
+		limits: limits,
+		stop:   stop,
+	}
+	if limits.MaxDuration > 0 {
+		l.timer = time.AfterFunc(time.Duration(limits.MaxDuration)*time.Second, func() {
+			l.trigger(LimitDuration)
+		})
+	}
+	return l
+}
+
+// add accounts for events that have been sent to the client
+func (l *limiter) add(events int, bytes int) {
+	if l == nil {
+		return
+	}
+	if n := l.events.Add(int64(events)); l.limits.MaxEvents > 0 && n >= l.limits.MaxEvents {
+		l.trigger(LimitEvents)
+	}
+	if n := l.bytes.Add(int64(bytes)); l.limits.MaxBytes > 0 && n >= l.limits.MaxBytes {
+		l.trigger(LimitBytes)
+	}
+}
+
+func (l *limiter) trigger(limit string) {
+	l.once.Do(func() {
+		l.triggered.Store(limit)
+		go l.stop()
+	})
+}
+
+// done releases the resources of the limiter and returns the limit that stopped the run, if any
+func (l *limiter) done() string {
+	if l == nil {
+		return ""
+	}
+	if l.timer != nil {
+		l.timer.Stop()
+	}
+	limit, _ := l.triggered.Load().(string)
+	return limit
+}
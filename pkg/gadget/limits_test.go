@@ -0,0 +1,135 @@
+// Copyright 2026 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gadget
+
+import (
+	"testing"
+	"time"
+
+	apiTypes "github.com/inspektor-gadget/ig-desktop/pkg/api"
+)
+
+func TestLimiter(t *testing.T) {
+	tests := []struct {
+		name   string
+		limits apiTypes.RunLimits
+		events int
+		bytes  int
+		want   string
+	}{
+		{
+			name:   "below limits",
+			limits: apiTypes.RunLimits{MaxEvents: 10, MaxBytes: 1000},
+			events: 9,
+			bytes:  10,
+		},
+		{
+			name:   "events",
+			limits: apiTypes.RunLimits{MaxEvents: 10},
+			events: 10,
+			bytes:  10,
+			want:   LimitEvents,
+		},
+		{
+			name:   "bytes",
+			limits: apiTypes.RunLimits{MaxBytes: 100},
+			events: 1,
+			bytes:  100,
+			want:   LimitBytes,
+		},
+		{
+			name:   "events before bytes",
+			limits: apiTypes.RunLimits{MaxEvents: 5, MaxBytes: 5},
+			events: 5,
+			bytes:  5,
+			want:   LimitEvents,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stopped := make(chan struct{}, 2)
+			l := newLimiter(tt.limits, func() { stopped <- struct{}{} })
+			for i := 0; i < tt.events; i++ {
+				l.add(1, tt.bytes/tt.events)
+			}
+			if tt.want != "" {
+				select {
+				case <-stopped:
+				case <-time.After(time.Second):
+					t.Fatalf("stop not called")
+				}
+			}
+			if got := l.done(); got != tt.want {
+				t.Errorf("done() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLimiterDuration(t *testing.T) {
+	stopped := make(chan struct{}, 2)
+	l := newLimiter(apiTypes.RunLimits{MaxDuration: 1}, func() { stopped <- struct{}{} })
+
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("stop not called after the duration")
+	}
+	// Further events don't stop the run again
+	l.add(100, 100)
+	if got := l.done(); got != LimitDuration {
+		t.Errorf("done() = %q, want %q", got, LimitDuration)
+	}
+	select {
+	case <-stopped:
+		t.Errorf("stop called twice")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestLimiterUnlimited(t *testing.T) {
+	l := newLimiter(apiTypes.RunLimits{}, func() { t.Errorf("stop called") })
+	if l != nil {
+		t.Fatalf("newLimiter() = %v, want nil", l)
+	}
+	// A nil limiter never triggers
+	l.add(1000, 1000)
+	if got := l.done(); got != "" {
+		t.Errorf("done() = %q, want none", got)
+	}
+}
+
+func TestLimiterDoneStopsTimer(t *testing.T) {
+	l := newLimiter(apiTypes.RunLimits{MaxDuration: 1}, func() { t.Errorf("stop called after done") })
+	if got := l.done(); got != "" {
+		t.Errorf("done() = %q, want none", got)
+	}
+	time.Sleep(1200 * time.Millisecond)
+}
+
+func TestRunLimitsWithDefaults(t *testing.T) {
+	defaults := &apiTypes.RunLimits{MaxDuration: 60, MaxEvents: 100}
+	got := apiTypes.RunLimits{MaxEvents: 5, MaxBytes: 10}.WithDefaults(defaults)
+	want := apiTypes.RunLimits{MaxDuration: 60, MaxEvents: 5, MaxBytes: 10}
+	if got != want {
+		t.Errorf("WithDefaults() = %+v, want %+v", got, want)
+	}
+	if got := want.WithDefaults(nil); got != want {
+		t.Errorf("WithDefaults(nil) = %+v, want %+v", got, want)
+	}
+	if limitValue(want, LimitBytes) != 10 {
+		t.Errorf("limitValue(%s) = %d, want 10", LimitBytes, limitValue(want, LimitBytes))
+	}
+}
@@ -21,14 +21,34 @@ import (
 	"time"
 
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/logger"
+	"github.com/sirupsen/logrus"
 
 	"github.com/inspektor-gadget/ig-desktop/pkg/api"
+	grpcruntime "github.com/inspektor-gadget/ig-desktop/pkg/grpc-runtime"
 )
 
+// DefaultLogLevel is the log level of runs that don't set one
+const DefaultLogLevel = logger.DebugLevel
+
+// ParseLogLevel parses the name of a log level as given in run requests; an empty name selects
+// DefaultLogLevel
+func ParseLogLevel(name string) (logger.Level, error) {
+	if name == "" {
+		return DefaultLogLevel, nil
+	}
+	level, err := logrus.ParseLevel(name)
+	if err != nil {
+		return 0, &api.ErrInvalidRequest{Reason: fmt.Sprintf("invalid log level %q", name)}
+	}
+	return level, nil
+}
+
 // GenericLogger implements logger.GenericLogger for gadget instances
 type GenericLogger struct {
 	send            func(any)
 	instanceID      string
+	environmentID   string
+	recordingID     string
 	level           logger.Level
 	sessionRecorder SessionRecorder
 }
@@ -36,9 +56,10 @@ type GenericLogger struct {
 // NewLogger creates a new GenericLogger
 func NewLogger(send func(any), instanceID string, level logger.Level) *GenericLogger {
 	return &GenericLogger{
-		send:       send,
-		instanceID: instanceID,
-		level:      level,
+		send:        send,
+		instanceID:  instanceID,
+		recordingID: instanceID,
+		level:       level,
 	}
 }
 
@@ -47,6 +68,13 @@ func (l *GenericLogger) SetSessionRecorder(sr SessionRecorder) {
 	l.sessionRecorder = sr
 }
 
+// SetEnvironment tags log events with the given environment and records them under
+// recordingID instead of the instance ID; used by multi-environment runs
+func (l *GenericLogger) SetEnvironment(environmentID string, recordingID string) {
+	l.environmentID = environmentID
+	l.recordingID = recordingID
+}
+
 // SetLevel sets the logging level
 func (l *GenericLogger) SetLevel(level logger.Level) {
 	l.level = level
@@ -60,33 +88,42 @@ func (l *GenericLogger) GetLevel() logger.Level {
 // sendLogEvent sends a log event and records it to session if active.
 func (l *GenericLogger) sendLogEvent(data []byte) {
 	l.send(&api.GadgetEvent{
-		InstanceID: l.instanceID,
-		Type:       api.TypeGadgetLog,
-		Data:       data,
+		EnvironmentID: l.environmentID,
+		InstanceID:    l.instanceID,
+		Type:          api.TypeGadgetLog,
+		Data:          data,
 	})
 	if l.sessionRecorder != nil {
-		if err := l.sessionRecorder.WriteEvent(l.instanceID, api.TypeGadgetLog, "", data); err != nil {
+		if err := l.sessionRecorder.WriteEvent(l.recordingID, api.TypeGadgetLog, "", data); err != nil {
 			log.Printf("failed to write log to session: %v", err)
 		}
 	}
 }
 
-// Log logs a message with the given severity
-func (l *GenericLogger) Log(severity logger.Level, params ...any) {
-	d, _ := json.Marshal(map[string]any{
-		"severity":  severity,
-		"msg":       fmt.Sprint(params...),
-		"timestamp": time.Now().Format("2006-01-02 15:04:05"),
-	})
+// log sends a log event if severity is enabled by the level of the logger
+func (l *GenericLogger) log(severity logger.Level, entry api.GadgetLog) {
+	if severity > l.level {
+		return
+	}
+	entry.Severity = severity.String()
+	entry.Timestamp = time.Now().Format("2006-01-02 15:04:05")
+	d, _ := json.Marshal(entry)
 	l.sendLogEvent(d)
 }
 
+// Log logs a message with the given severity. Messages of the runtime concerning a single node
+// keep the node and source as separate fields.
+func (l *GenericLogger) Log(severity logger.Level, params ...any) {
+	if len(params) == 1 {
+		if nodeLog, ok := params[0].(grpcruntime.NodeLog); ok {
+			l.log(severity, api.GadgetLog{Msg: nodeLog.Msg, Node: nodeLog.Node, Source: nodeLog.Source})
+			return
+		}
+	}
+	l.log(severity, api.GadgetLog{Msg: fmt.Sprint(params...)})
+}
+
 // Logf logs a formatted message with the given severity
 func (l *GenericLogger) Logf(severity logger.Level, format string, params ...any) {
-	d, _ := json.Marshal(map[string]any{
-		"severity":  severity,
-		"msg":       fmt.Sprintf(format, params...),
-		"timestamp": time.Now().Format("2006-01-02 15:04:05"),
-	})
-	l.sendLogEvent(d)
+	l.log(severity, api.GadgetLog{Msg: fmt.Sprintf(format, params...)})
 }
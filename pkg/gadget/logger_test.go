@@ -0,0 +1,59 @@
+// Copyright 2026 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gadget
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/logger"
+
+	"github.com/inspektor-gadget/ig-desktop/pkg/api"
+	grpcruntime "github.com/inspektor-gadget/ig-desktop/pkg/grpc-runtime"
+)
+
+func TestGenericLogger(t *testing.T) {
+	var logs []api.GadgetLog
+	send := func(ev any) {
+		var entry api.GadgetLog
+		if err := json.Unmarshal(ev.(*api.GadgetEvent).Data, &entry); err != nil {
+			t.Fatal(err)
+		}
+		logs = append(logs, entry)
+	}
+
+	level, err := ParseLogLevel("info")
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := NewLogger(send, "instance", level)
+	l.Log(logger.DebugLevel, "dropped")
+	l.Logf(logger.WarnLevel, "local %d", 1)
+	l.Log(logger.ErrorLevel, grpcruntime.NodeLog{Node: "node-1", Source: grpcruntime.LogSourceGadget, Msg: "remote"})
+
+	if len(logs) != 2 {
+		t.Fatalf("expected 2 logs, got %d", len(logs))
+	}
+	if logs[0].Severity != "warning" || logs[0].Msg != "local 1" || logs[0].Node != "" {
+		t.Errorf("unexpected local log: %+v", logs[0])
+	}
+	if logs[1].Severity != "error" || logs[1].Msg != "remote" || logs[1].Node != "node-1" || logs[1].Source != grpcruntime.LogSourceGadget {
+		t.Errorf("unexpected node log: %+v", logs[1])
+	}
+
+	if _, err := ParseLogLevel("verbose"); err == nil {
+		t.Error("expected error for invalid level")
+	}
+}
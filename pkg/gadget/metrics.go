@@ -0,0 +1,157 @@
+// Copyright 2026 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gadget
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/datasource"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-service/api"
+
+	"github.com/inspektor-gadget/ig-desktop/pkg/metrics"
+)
+
+// SetMetrics sets the registry that events of subsequently started runs are aggregated into
+func (s *Service) SetMetrics(registry *metrics.Registry) {
+	s.metrics = registry
+}
+
+// SkipAttachedMetrics keeps runs attached to existing instances out of metrics; used when a
+// collector already aggregates all instances
+func (s *Service) SkipAttachedMetrics() {
+	s.skipAttachedMetrics = true
+}
+
+// metricObserver feeds a metric from the events of one datasource
+type metricObserver struct {
+	metric *metrics.Metric
+	labels []datasource.FieldAccessor
+	value  datasource.FieldAccessor // nil counts events
+}
+
+// metricObservers returns the observers for all metrics that apply to the given run and datasource.
+// Metrics referencing fields the datasource doesn't have are skipped.
+func (s *Service) metricObservers(r *run, ds datasource.DataSource) []*metricObserver {
+	if s.metrics == nil || (r.attached && s.skipAttachedMetrics) {
+		return nil
+	}
+	var observers []*metricObserver
+	for _, m := range s.metrics.Metrics() {
+		def := m.Definition()
+		if def.Image != "" && !strings.Contains(r.image, def.Image) {
+			continue
+		}
+		if def.Datasource != "" && def.Datasource != ds.Name() {
+			continue
+		}
+
+		o := &metricObserver{metric: m}
+		missing := ""
+		for _, l := range def.Labels {
+			f := ds.GetField(l)
+			if f == nil {
+				missing = l
+				break
+			}
+			o.labels = append(o.labels, f)
+		}
+		if missing == "" && def.Field != "" {
+			if o.value = ds.GetField(def.Field); o.value == nil {
+				missing = def.Field
+			}
+		}
+		if missing != "" {
+			if def.Datasource != "" {
+				log.Printf("metric %s: datasource %s has no field %q", def.Name, ds.Name(), missing)
+			}
+			continue
+		}
+		observers = append(observers, o)
+	}
+	return observers
+}
+
+// observeMetrics records a single event on all observers
+func observeMetrics(observers []*metricObserver, data datasource.Data) {
+	for _, o := range observers {
+		labelValues := make([]string, len(o.labels))
+		for i, f := range o.labels {
+			labelValues[i] = fieldString(f, data)
+		}
+		value := 1.0
+		if o.value != nil {
+			var err error
+			if value, err = fieldFloat(o.value, data); err != nil {
+				continue
+			}
+		}
+		o.metric.Observe(labelValues, value)
+	}
+}
+
+// fieldString returns the value of a scalar field as string
+func fieldString(f datasource.FieldAccessor, data datasource.Data) string {
+	switch f.Type() {
+	case api.Kind_String, api.Kind_CString:
+		v, _ := f.String(data)
+		return v
+	case api.Kind_Bool:
+		v, _ := f.Bool(data)
+		return strconv.FormatBool(v)
+	}
+	if v, err := fieldFloat(f, data); err == nil {
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return ""
+}
+
+// fieldFloat returns the value of a numeric field
+func fieldFloat(f datasource.FieldAccessor, data datasource.Data) (float64, error) {
+	switch f.Type() {
+	case api.Kind_Int8:
+		v, err := f.Int8(data)
+		return float64(v), err
+	case api.Kind_Int16:
+		v, err := f.Int16(data)
+		return float64(v), err
+	case api.Kind_Int32:
+		v, err := f.Int32(data)
+		return float64(v), err
+	case api.Kind_Int64:
+		v, err := f.Int64(data)
+		return float64(v), err
+	case api.Kind_Uint8:
+		v, err := f.Uint8(data)
+		return float64(v), err
+	case api.Kind_Uint16:
+		v, err := f.Uint16(data)
+		return float64(v), err
+	case api.Kind_Uint32:
+		v, err := f.Uint32(data)
+		return float64(v), err
+	case api.Kind_Uint64:
+		v, err := f.Uint64(data)
+		return float64(v), err
+	case api.Kind_Float32:
+		v, err := f.Float32(data)
+		return float64(v), err
+	case api.Kind_Float64:
+		return f.Float64(data)
+	}
+	return 0, fmt.Errorf("field %s is not numeric", f.FullName())
+}
@@ -0,0 +1,60 @@
+// Copyright 2026 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gadget
+
+import (
+	"maps"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/params"
+
+	grpcruntime "github.com/inspektor-gadget/ig-desktop/pkg/grpc-runtime"
+)
+
+// runtimeParamKeys are the runtime params a run or the defaults of its environment can set, like the
+// nodes to run on. Other runtime params describe a detached instance and are set by setDetachParams.
+var runtimeParamKeys = []string{grpcruntime.ParamNode}
+
+// WithDefaultParams returns a copy of params where every param that isn't set is taken from
+// defaults. A param set to an empty value counts as set, so that a run can clear a default.
+func WithDefaultParams(params, defaults map[string]string) map[string]string {
+	if len(defaults) == 0 {
+		return params
+	}
+	res := make(map[string]string, len(params)+len(defaults))
+	maps.Copy(res, defaults)
+	maps.Copy(res, params)
+	return res
+}
+
+// splitRuntimeParams sets the runtime params found in values and returns the remaining values, which
+// are meant for the gadget. Params the runtime doesn't have, like the nodes outside of Kubernetes,
+// are dropped.
+func splitRuntimeParams(rtParams *params.Params, values map[string]string) (map[string]string, error) {
+	res := maps.Clone(values)
+	for _, key := range runtimeParamKeys {
+		value, ok := values[key]
+		if !ok {
+			continue
+		}
+		delete(res, key)
+		if rtParams.Get(key) == nil {
+			continue
+		}
+		if err := rtParams.Set(key, value); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
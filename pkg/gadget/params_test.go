@@ -0,0 +1,208 @@
+// Copyright 2026 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gadget
+
+import (
+	"context"
+	"encoding/json"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-service/api"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+
+	apiTypes "github.com/inspektor-gadget/ig-desktop/pkg/api"
+	grpcruntime "github.com/inspektor-gadget/ig-desktop/pkg/grpc-runtime"
+)
+
+func TestWithDefaultParams(t *testing.T) {
+	defaults := map[string]string{
+		"operator.KubeManager.namespace": "default",
+		"operator.KubeManager.node":      "worker-1",
+	}
+	params := map[string]string{
+		"operator.KubeManager.namespace": "kube-system",
+		"operator.KubeManager.node":      "",
+	}
+	res := WithDefaultParams(params, defaults)
+	expected := map[string]string{
+		"operator.KubeManager.namespace": "kube-system",
+		"operator.KubeManager.node":      "",
+	}
+	if !maps.Equal(res, expected) {
+		t.Errorf("run params don't override defaults: %v", res)
+	}
+
+	res = WithDefaultParams(nil, defaults)
+	if !maps.Equal(res, defaults) {
+		t.Errorf("defaults not applied: %v", res)
+	}
+	res["operator.KubeManager.node"] = "worker-2"
+	if defaults["operator.KubeManager.node"] != "worker-1" {
+		t.Error("defaults were modified")
+	}
+}
+
+// newGadgetPodsServer fakes an API server with a gadget pod per node. It records the pods a
+// port-forward was requested for and fails these requests; retries of a pod are only reported once.
+func newGadgetPodsServer(t *testing.T, nodes ...string) (*rest.Config, func() []string) {
+	t.Helper()
+	pods := &corev1.PodList{TypeMeta: metav1.TypeMeta{Kind: "PodList", APIVersion: "v1"}}
+	for _, node := range nodes {
+		pods.Items = append(pods.Items, corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "gadget-" + node, Namespace: grpcruntime.DefaultGadgetNamespace},
+			Spec:       corev1.PodSpec{NodeName: node},
+		})
+	}
+
+	var mu sync.Mutex
+	var forwarded []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if pod, ok := strings.CutSuffix(r.URL.Path, "/portforward"); ok {
+			mu.Lock()
+			forwarded = append(forwarded, pod[strings.LastIndex(pod, "/")+1:])
+			mu.Unlock()
+			http.Error(w, "port-forward not supported", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(pods)
+	}))
+	t.Cleanup(srv.Close)
+
+	return &rest.Config{Host: srv.URL}, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		res := slices.Clone(forwarded)
+		slices.Sort(res)
+		return slices.Compact(res)
+	}
+}
+
+func TestRunDefaultNode(t *testing.T) {
+	tests := []struct {
+		name     string
+		params   map[string]string
+		defaults map[string]string
+		want     []string
+	}{
+		{
+			name: "all nodes",
+			want: []string{"gadget-node-1", "gadget-node-2", "gadget-node-3"},
+		},
+		{
+			name:     "default node",
+			defaults: map[string]string{grpcruntime.ParamNode: "node-2"},
+			want:     []string{"gadget-node-2"},
+		},
+		{
+			name:     "run overrides default",
+			params:   map[string]string{grpcruntime.ParamNode: "node-1,node-3"},
+			defaults: map[string]string{grpcruntime.ParamNode: "node-2"},
+			want:     []string{"gadget-node-1", "gadget-node-3"},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			config, forwarded := newGadgetPodsServer(t, "node-1", "node-2", "node-3")
+			runtime := grpcruntime.New(grpcruntime.WithConnectUsingK8SProxy)
+			globalParams := runtime.GlobalParamDescs().ToParams()
+			if err := globalParams.Set(grpcruntime.ParamConnectionTimeout, "1"); err != nil {
+				t.Fatal(err)
+			}
+			if err := runtime.Init(globalParams); err != nil {
+				t.Fatal(err)
+			}
+			runtime.SetRestConfig(config)
+
+			// The info of the pinned image is cached, so only the run connects to the pods
+			cache, err := NewInfoCache(t.TempDir(), time.Hour)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := cache.Set("env", "trace_exec@sha256:abc", &api.GadgetInfo{}); err != nil {
+				t.Fatal(err)
+			}
+
+			stopped := make(chan struct{})
+			s := NewService(NewInstanceManager())
+			s.SetInfoCache(cache)
+			s.SetSendFunc(func(ev any) {
+				if gev, ok := ev.(*apiTypes.GadgetEvent); ok && gev.Type == apiTypes.TypeGadgetStop {
+					close(stopped)
+				}
+			})
+
+			_, err = s.Run(context.Background(), runtime, RunRequest{
+				Image:         "trace_exec@sha256:abc",
+				EnvironmentID: "env",
+				Params:        tc.params,
+				DefaultParams: tc.defaults,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			select {
+			case <-stopped:
+			case <-time.After(10 * time.Second):
+				t.Fatal("run didn't stop")
+			}
+
+			if got := forwarded(); !slices.Equal(got, tc.want) {
+				t.Errorf("got targets %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSplitRuntimeParams(t *testing.T) {
+	values := map[string]string{
+		grpcruntime.ParamNode:            "node-1",
+		"operator.KubeManager.namespace": "default",
+	}
+
+	// Outside of Kubernetes, there are no nodes to select
+	rtParams := grpcruntime.New().ParamDescs().ToParams()
+	gadgetParams, err := splitRuntimeParams(rtParams, values)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !maps.Equal(gadgetParams, map[string]string{"operator.KubeManager.namespace": "default"}) {
+		t.Errorf("unexpected gadget params: %v", gadgetParams)
+	}
+	if values[grpcruntime.ParamNode] != "node-1" {
+		t.Error("values were modified")
+	}
+
+	rtParams = grpcruntime.New(grpcruntime.WithConnectUsingK8SProxy).ParamDescs().ToParams()
+	if _, err := splitRuntimeParams(rtParams, values); err != nil {
+		t.Fatal(err)
+	}
+	if node := rtParams.Get(grpcruntime.ParamNode).AsString(); node != "node-1" {
+		t.Errorf("expected node to be set, got %q", node)
+	}
+
+	if _, err := splitRuntimeParams(rtParams, map[string]string{grpcruntime.ParamNode: "node-1,node-1"}); err == nil {
+		t.Error("expected duplicate nodes to be rejected")
+	}
+}
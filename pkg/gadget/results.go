@@ -0,0 +1,77 @@
+// Copyright 2026 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gadget
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"log"
+	"unicode/utf8"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/runtime"
+
+	apiTypes "github.com/inspektor-gadget/ig-desktop/pkg/api"
+)
+
+// Result is the final output a gadget produced on a single node
+type Result struct {
+	Payload  string `json:"payload,omitempty"`
+	Encoding string `json:"encoding,omitempty"` // "base64" if the payload isn't valid UTF-8
+	Error    string `json:"error,omitempty"`
+}
+
+// sendResults sends the per-node results of a finished run as TypeGadgetResult and records them
+// to the session of the run. Nothing is sent if no node produced a payload.
+func (s *Service) sendResults(r *run, combined runtime.CombinedGadgetResult) {
+	results := make(map[string]Result, len(combined))
+	hasPayload := false
+	for node, res := range combined {
+		if res == nil {
+			continue
+		}
+		var result Result
+		if len(res.Payload) > 0 {
+			hasPayload = true
+			if utf8.Valid(res.Payload) {
+				result.Payload = string(res.Payload)
+			} else {
+				result.Payload = base64.StdEncoding.EncodeToString(res.Payload)
+				result.Encoding = "base64"
+			}
+		}
+		if res.Error != nil {
+			result.Error = res.Error.Error()
+		}
+		results[node] = result
+	}
+	if !hasPayload {
+		return
+	}
+
+	data, _ := json.Marshal(map[string]any{
+		"results": results,
+	})
+	s.send(&apiTypes.GadgetEvent{
+		Type:          apiTypes.TypeGadgetResult,
+		EnvironmentID: r.environmentID,
+		InstanceID:    r.instanceID,
+		Data:          data,
+	})
+	if r.record && s.sessionRecorder != nil {
+		if err := s.sessionRecorder.WriteEvent(r.recordingID, apiTypes.TypeGadgetResult, "", data); err != nil {
+			log.Printf("failed to write result to session: %v", err)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+// Copyright 2026 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gadget
+
+import (
+	"encoding/json"
+	"errors"
+	"maps"
+	"testing"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/runtime"
+
+	"github.com/inspektor-gadget/ig-desktop/pkg/api"
+)
+
+// resultRecorder records the events written to sessions
+type resultRecorder struct {
+	SessionRecorder
+	events map[string][][]byte // recording ID -> data
+}
+
+func (r *resultRecorder) WriteEvent(instanceID string, eventType int, dsName string, data []byte) error {
+	if eventType != api.TypeGadgetResult {
+		return errors.New("unexpected event type")
+	}
+	r.events[instanceID] = append(r.events[instanceID], data)
+	return nil
+}
+
+func TestSendResults(t *testing.T) {
+	tests := []struct {
+		name     string
+		combined runtime.CombinedGadgetResult
+		record   bool
+		// want are the results of the event; nil if no event is expected
+		want map[string]Result
+	}{
+		{
+			name:     "no results",
+			combined: nil,
+			record:   true,
+		},
+		{
+			name: "no payloads",
+			combined: runtime.CombinedGadgetResult{
+				"node-1": {},
+				"node-2": {Error: errors.New("failed")},
+			},
+			record: true,
+		},
+		{
+			name: "all nodes",
+			combined: runtime.CombinedGadgetResult{
+				"node-1": {Payload: []byte(`{"files":1}`)},
+				"node-2": {Payload: []byte{0xff, 0x00}},
+			},
+			record: true,
+			want: map[string]Result{
+				"node-1": {Payload: `{"files":1}`},
+				"node-2": {Payload: "/wA=", Encoding: "base64"},
+			},
+		},
+		{
+			name: "some nodes",
+			combined: runtime.CombinedGadgetResult{
+				"node-1": {Payload: []byte("report")},
+				"node-2": nil,
+				"node-3": {Error: errors.New("node unreachable")},
+			},
+			record: true,
+			want: map[string]Result{
+				"node-1": {Payload: "report"},
+				"node-3": {Error: "node unreachable"},
+			},
+		},
+		{
+			name: "not recorded",
+			combined: runtime.CombinedGadgetResult{
+				"node-1": {Payload: []byte("report")},
+			},
+			want: map[string]Result{
+				"node-1": {Payload: "report"},
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var events []*api.GadgetEvent
+			recorder := &resultRecorder{events: make(map[string][][]byte)}
+
+			s := NewService(NewInstanceManager())
+			s.SetSendFunc(func(ev any) {
+				events = append(events, ev.(*api.GadgetEvent))
+			})
+			s.SetSessionRecorder(recorder)
+
+			r := &run{instanceID: "instance", environmentID: "env", recordingID: "recording", record: tc.record}
+			s.sendResults(r, tc.combined)
+
+			if tc.want == nil {
+				if len(events) != 0 || len(recorder.events) != 0 {
+					t.Fatalf("expected nothing to be sent or recorded, got %d events, %d recorded", len(events), len(recorder.events))
+				}
+				return
+			}
+
+			if len(events) != 1 {
+				t.Fatalf("expected a single event, got %d", len(events))
+			}
+			ev := events[0]
+			if ev.Type != api.TypeGadgetResult || ev.EnvironmentID != "env" || ev.InstanceID != "instance" {
+				t.Errorf("unexpected event: %+v", ev)
+			}
+			var data struct {
+				Results map[string]Result `json:"results"`
+			}
+			if err := json.Unmarshal(ev.Data, &data); err != nil {
+				t.Fatal(err)
+			}
+			if !maps.Equal(data.Results, tc.want) {
+				t.Errorf("got results %+v, want %+v", data.Results, tc.want)
+			}
+
+			recorded := recorder.events["recording"]
+			if !tc.record {
+				if len(recorder.events) != 0 {
+					t.Errorf("expected results to not be recorded")
+				}
+				return
+			}
+			if len(recorded) != 1 || string(recorded[0]) != string(ev.Data) {
+				t.Errorf("expected the sent payload to be recorded, got %q", recorded)
+			}
+		})
+	}
+}
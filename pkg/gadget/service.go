@@ -16,7 +16,11 @@ package gadget
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"log"
+	"sync"
+	"time"
 
 	"github.com/google/uuid"
 	gadgetcontext "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-context"
@@ -29,6 +33,7 @@ import (
 	apiTypes "github.com/inspektor-gadget/ig-desktop/pkg/api"
 	grpcruntime "github.com/inspektor-gadget/ig-desktop/pkg/grpc-runtime"
 	json2 "github.com/inspektor-gadget/ig-desktop/pkg/json"
+	"github.com/inspektor-gadget/ig-desktop/pkg/metrics"
 	"github.com/inspektor-gadget/ig-desktop/pkg/operators/virtual"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/datasource"
 )
@@ -46,13 +51,23 @@ type SessionRecorder interface {
 type Service struct {
 	instanceManager *InstanceManager
 	sessionRecorder SessionRecorder
+	alertStore      AlertStore
+	metrics         *metrics.Registry
+	infoCache       *InfoCache
+	sinkPolicy      SinkPolicy
+	newSink         func(cfg SinkConfig, policy SinkPolicy) (Sink, error)
 	send            func(any)
+
+	// skipAttachedMetrics keeps attached runs out of metrics, if those are
+	// already fed by a collector attached to the same instances
+	skipAttachedMetrics bool
 }
 
 // NewService creates a new gadget service
 func NewService(instanceManager *InstanceManager) *Service {
 	return &Service{
 		instanceManager: instanceManager,
+		newSink:         NewSink,
 	}
 }
 
@@ -61,6 +76,11 @@ func (s *Service) SetSendFunc(send func(any)) {
 	s.send = send
 }
 
+// SetSinkPolicy sets which sinks runs may forward their output to; without a policy, all sinks are disabled
+func (s *Service) SetSinkPolicy(policy SinkPolicy) {
+	s.sinkPolicy = policy
+}
+
 // SetSessionRecorder sets the session recorder for the service
 func (s *Service) SetSessionRecorder(sr SessionRecorder) {
 	s.sessionRecorder = sr
@@ -68,7 +88,7 @@ func (s *Service) SetSessionRecorder(sr SessionRecorder) {
 
 // setupSessionRecording creates or uses an existing session for recording.
 // Returns nil if recording setup fails (gadget will continue without recording).
-func (s *Service) setupSessionRecording(instanceID string, req RunRequest, gi *api.GadgetInfo) *apiTypes.SessionInfo {
+func (s *Service) setupSessionRecording(recordingID string, req RunRequest, gi *api.GadgetInfo) *apiTypes.SessionInfo {
 	sessionID := req.SessionID
 	isNew := false
 
@@ -88,7 +108,7 @@ func (s *Service) setupSessionRecording(instanceID string, req RunRequest, gi *a
 		return nil
 	}
 
-	runID, err := s.sessionRecorder.StartGadgetRun(instanceID, sessionID, req.Image, req.Params, gadgetInfoBytes)
+	runID, err := s.sessionRecorder.StartGadgetRun(recordingID, sessionID, req.Image, req.Params, gadgetInfoBytes)
 	if err != nil {
 		log.Printf("failed to start gadget run: %v (continuing without recording)", err)
 		return nil
@@ -101,47 +121,102 @@ func (s *Service) setupSessionRecording(instanceID string, req RunRequest, gi *a
 	}
 }
 
+// run holds the state of a single gadget run on one runtime. A multi-environment
+// run consists of several runs that share the same instance ID.
+type run struct {
+	instanceID    string
+	environmentID string
+	// recordingID is the key of the run in the session recorder; it equals the
+	// instance ID unless several runs share the instance
+	recordingID string
+	record      bool
+	attached    bool
+	limiter     *limiter
+	sinks       sinks
+	logLevel    logger.Level
+
+	image      string
+	params     map[string]string
+	gadgetInfo []byte // protojson encoded
+}
+
+// sinkEvent wraps output of the run for sinks
+func (r *run) sinkEvent(eventType int, dsName string, data []byte) *SinkEvent {
+	return &SinkEvent{
+		InstanceID:    r.instanceID,
+		EnvironmentID: r.environmentID,
+		Image:         r.image,
+		Datasource:    dsName,
+		Type:          eventType,
+		Timestamp:     time.Now().UnixMilli(),
+		Data:          data,
+	}
+}
+
 // subscribeToDataSources subscribes to all data sources and sends events to the frontend.
-// If withRecording is true, events are also written to the session recorder.
-func (s *Service) subscribeToDataSources(gadgetCtx operators.GadgetContext, instanceID string, withRecording bool) error {
+// If the run is recorded, events are also written to the session recorder. Sent events
+// are accounted on the limiter of the run, which may be nil.
+func (s *Service) subscribeToDataSources(gadgetCtx operators.GadgetContext, r *run) error {
+	alerts := s.newAlertEvaluator(r)
 	for _, ds := range gadgetCtx.GetDataSources() {
 		formatter, err := json2.New(ds, json2.WithFlatten(true), json2.WithShowAll(true))
 		if err != nil {
 			return err
 		}
 		dsName := ds.Name()
+		alertPrograms := alerts.programs(ds)
+		metricObservers := s.metricObservers(r, ds)
 
 		switch ds.Type() {
 		case datasource.TypeSingle:
 			ds.Subscribe(func(ds datasource.DataSource, data datasource.Data) error {
 				jsonData := formatter.Marshal(data)
 				s.send(&apiTypes.GadgetEvent{
-					Type:         apiTypes.TypeGadgetEvent,
-					InstanceID:   instanceID,
-					Data:         jsonData,
-					DatasourceID: dsName,
+					Type:          apiTypes.TypeGadgetEvent,
+					EnvironmentID: r.environmentID,
+					InstanceID:    r.instanceID,
+					Data:          jsonData,
+					DatasourceID:  dsName,
 				})
-				if withRecording && s.sessionRecorder != nil {
-					if err := s.sessionRecorder.WriteEvent(instanceID, apiTypes.TypeGadgetEvent, dsName, jsonData); err != nil {
+				if r.record && s.sessionRecorder != nil {
+					if err := s.sessionRecorder.WriteEvent(r.recordingID, apiTypes.TypeGadgetEvent, dsName, jsonData); err != nil {
 						log.Printf("failed to write event to session: %v", err)
 					}
 				}
+				r.limiter.add(1, len(jsonData))
+				r.sinks.write(r.sinkEvent(apiTypes.TypeGadgetEvent, dsName, jsonData))
+				alerts.remember(apiTypes.TypeGadgetEvent, dsName, jsonData)
+				if len(alertPrograms) > 0 {
+					alerts.check(alertPrograms, dsName, data, func() []byte { return jsonData })
+				}
+				observeMetrics(metricObservers, data)
 				return nil
 			}, 1000)
 		case datasource.TypeArray:
 			ds.SubscribeArray(func(ds datasource.DataSource, data datasource.DataArray) error {
 				jsonData := formatter.MarshalArray(data)
 				s.send(&apiTypes.GadgetEvent{
-					Type:         apiTypes.TypeGadgetEventArray,
-					InstanceID:   instanceID,
-					Data:         jsonData,
-					DatasourceID: dsName,
+					Type:          apiTypes.TypeGadgetEventArray,
+					EnvironmentID: r.environmentID,
+					InstanceID:    r.instanceID,
+					Data:          jsonData,
+					DatasourceID:  dsName,
 				})
-				if withRecording && s.sessionRecorder != nil {
-					if err := s.sessionRecorder.WriteEvent(instanceID, apiTypes.TypeGadgetEventArray, dsName, jsonData); err != nil {
+				if r.record && s.sessionRecorder != nil {
+					if err := s.sessionRecorder.WriteEvent(r.recordingID, apiTypes.TypeGadgetEventArray, dsName, jsonData); err != nil {
 						log.Printf("failed to write event to session: %v", err)
 					}
 				}
+				r.limiter.add(data.Len(), len(jsonData))
+				r.sinks.write(r.sinkEvent(apiTypes.TypeGadgetEventArray, dsName, jsonData))
+				alerts.remember(apiTypes.TypeGadgetEventArray, dsName, jsonData)
+				for i := 0; i < data.Len() && len(alertPrograms) > 0; i++ {
+					elem := data.Get(i)
+					alerts.check(alertPrograms, dsName, elem, func() []byte { return formatter.Marshal(elem) })
+				}
+				for i := 0; i < data.Len() && len(metricObservers) > 0; i++ {
+					observeMetrics(metricObservers, data.Get(i))
+				}
 				return nil
 			}, 1000)
 		}
@@ -149,17 +224,56 @@ func (s *Service) subscribeToDataSources(gadgetCtx operators.GadgetContext, inst
 	return nil
 }
 
+// reconnectHandler returns a handler that puts a marker into the stream of the given run (and its recording,
+// if any) whenever the runtime had to re-establish the connection to a target.
+func (s *Service) reconnectHandler(r *run) grpcruntime.ReconnectHandler {
+	return func(node string, missing time.Duration) {
+		seconds := int(missing.Seconds())
+		data, _ := json.Marshal(map[string]any{
+			"node":           node,
+			"missingSeconds": seconds,
+			"msg":            fmt.Sprintf("reconnected, %d seconds missing", seconds),
+			"timestamp":      time.Now().Format("2006-01-02 15:04:05"),
+		})
+		s.send(&apiTypes.GadgetEvent{
+			Type:          apiTypes.TypeGadgetReconnect,
+			EnvironmentID: r.environmentID,
+			InstanceID:    r.instanceID,
+			Data:          data,
+		})
+		if r.record && s.sessionRecorder != nil {
+			if err := s.sessionRecorder.WriteEvent(r.recordingID, apiTypes.TypeGadgetReconnect, "", data); err != nil {
+				log.Printf("failed to write reconnect marker to session: %v", err)
+			}
+		}
+	}
+}
+
 // RunRequest contains parameters for running a gadget
 type RunRequest struct {
 	ID            string
 	Image         string
 	EnvironmentID string
 	Params        map[string]string
+	DefaultParams map[string]string // defaults of the environment for params not in Params, including runtime params like "node"
 	Detached      bool
 	InstanceName  string
-	Record        bool   `json:"record"`      // enable recording
-	SessionID     string `json:"sessionId"`   // existing session (empty = new)
-	SessionName   string `json:"sessionName"` // name for new session
+	Record        bool               `json:"record"`      // enable recording
+	SessionID     string             `json:"sessionId"`   // existing session (empty = new)
+	SessionName   string             `json:"sessionName"` // name for new session
+	Limits        apiTypes.RunLimits `json:"limits"`      // auto-stop limits; ignored for detached runs
+	Sinks         []SinkConfig       `json:"sinks"`       // forward output to external destinations; ignored for detached runs
+	Tags          []string           `json:"tags"`        // tags of the created instance; only for detached runs
+	Nodes         []string           `json:"nodes"`       // nodes the created instance runs on (all if empty); only for detached runs
+	LogLevel      string             `json:"logLevel"`    // e.g. "info"; DefaultLogLevel if empty
+}
+
+// EnvironmentRun is the part of a multi-environment run that targets a single environment
+type EnvironmentRun struct {
+	EnvironmentID string
+	Runtime       *grpcruntime.Runtime
+	Limits        apiTypes.RunLimits
+	DefaultParams map[string]string
 }
 
 // AttachRequest contains parameters for attaching to an instance
@@ -169,12 +283,11 @@ type AttachRequest struct {
 	EnvironmentID string
 	Params        map[string]string
 	InstanceName  string
+	LogLevel      string // e.g. "info"; DefaultLogLevel if empty
 }
 
-// Run starts a new gadget instance
-func (s *Service) Run(ctx context.Context, runtime *grpcruntime.Runtime, req RunRequest) (string, error) {
-	instanceID := uuid.New().String()
-
+// execute runs the gadget of req on the given runtime and blocks until the run is done
+func (s *Service) execute(ctx context.Context, runtime *grpcruntime.Runtime, req RunRequest, r *run) error {
 	xop := simple.New("exp", simple.WithPriority(1000), simple.OnPreStart(func(gadgetCtx operators.GadgetContext) error {
 		gi, err := gadgetCtx.SerializeGadgetInfo(false)
 		if err != nil {
@@ -187,25 +300,28 @@ func (s *Service) Run(ctx context.Context, runtime *grpcruntime.Runtime, req Run
 			return err
 		}
 
+		r.gadgetInfo = gid
+
 		var sessionInfo *apiTypes.SessionInfo
-		if req.Record && s.sessionRecorder != nil {
-			sessionInfo = s.setupSessionRecording(instanceID, req, gi)
+		if r.record && s.sessionRecorder != nil {
+			sessionInfo = s.setupSessionRecording(r.recordingID, req, gi)
 		}
 
 		s.send(&apiTypes.GadgetEvent{
 			Type:          apiTypes.TypeGadgetInfo,
-			EnvironmentID: req.EnvironmentID,
-			InstanceID:    instanceID,
+			EnvironmentID: r.environmentID,
+			InstanceID:    r.instanceID,
 			Data:          gid,
 			SessionInfo:   sessionInfo,
 		})
 
-		return s.subscribeToDataSources(gadgetCtx, instanceID, req.Record)
+		return s.subscribeToDataSources(gadgetCtx, r)
 	}))
 
 	// Create logger and set session recorder if available
-	gadgetLogger := NewLogger(s.send, instanceID, logger.DebugLevel)
-	if s.sessionRecorder != nil {
+	gadgetLogger := NewLogger(s.send, r.instanceID, r.logLevel)
+	gadgetLogger.SetEnvironment(r.environmentID, r.recordingID)
+	if r.record && s.sessionRecorder != nil {
 		gadgetLogger.SetSessionRecorder(s.sessionRecorder)
 	}
 
@@ -215,39 +331,219 @@ func (s *Service) Run(ctx context.Context, runtime *grpcruntime.Runtime, req Run
 		gadgetcontext.WithUseInstance(false),
 	}
 
+	// Runtime params, like the nodes, may be set by the run or the defaults of the environment
 	rtParams := runtime.ParamDescs().ToParams()
+	gadgetParams, err := splitRuntimeParams(rtParams, req.Params)
+	if err != nil {
+		return err
+	}
 	if req.Detached {
-		rtParams.Set("detach", "true")
-		if req.InstanceName != "" {
-			rtParams.Set("name", req.InstanceName)
+		setDetachParams(rtParams, req.InstanceName, req.Tags, req.Nodes)
+	}
+
+	gadgetCtx := gadgetcontext.New(ctx, req.Image, options...)
+	gadgetCtx.SetVar(grpcruntime.VarReconnectHandler, s.reconnectHandler(r))
+
+	results, err := runtime.RunGadgetWithResults(gadgetCtx, rtParams, gadgetParams)
+	s.sendResults(r, results)
+
+	// Stop session recording if active
+	if s.sessionRecorder != nil {
+		if err := s.sessionRecorder.StopGadgetRun(r.recordingID); err != nil {
+			log.Printf("failed to stop gadget run: %v", err)
 		}
 	}
+	return err
+}
+
+// Run starts a new gadget instance
+func (s *Service) Run(ctx context.Context, runtime *grpcruntime.Runtime, req RunRequest) (string, error) {
+	logLevel, err := ParseLogLevel(req.LogLevel)
+	if err != nil {
+		return "", err
+	}
+
+	req.Params = WithDefaultParams(req.Params, req.DefaultParams)
+	if err := s.validateRun(ctx, runtime, req); err != nil {
+		return "", err
+	}
+
+	instanceID := uuid.New().String()
+
+	r := &run{
+		instanceID:    instanceID,
+		environmentID: req.EnvironmentID,
+		recordingID:   instanceID,
+		record:        req.Record,
+		logLevel:      logLevel,
+		image:         req.Image,
+		params:        req.Params,
+	}
+	if !req.Detached {
+		sinks, err := newSinks(req.Sinks, s.sinkPolicy, s.newSink)
+		if err != nil {
+			return "", err
+		}
+		r.sinks = sinks
+		r.limiter = newLimiter(req.Limits, func() {
+			if err := s.instanceManager.Stop(instanceID); err == nil {
+				log.Printf("instance %s reached its run limits, stopping", instanceID)
+			}
+		})
+	}
 
 	nctx, cancel := context.WithCancel(ctx)
 	s.instanceManager.Register(instanceID, cancel)
 
-	gadgetCtx := gadgetcontext.New(nctx, req.Image, options...)
-
 	go func() {
-		err := runtime.RunGadget(gadgetCtx, rtParams, req.Params)
+		err := s.execute(nctx, runtime, req, r)
 		if err != nil {
 			log.Printf("gadget error: %v", err)
 		}
-
-		// Stop session recording if active
-		if s.sessionRecorder != nil {
-			if err := s.sessionRecorder.StopGadgetRun(instanceID); err != nil {
-				log.Printf("failed to stop gadget run: %v", err)
-			}
+		if err := r.sinks.close(); err != nil {
+			log.Printf("failed to close sinks: %v", err)
 		}
 
 		s.instanceManager.Unregister(instanceID)
 		cancel()
 
-		s.send(&apiTypes.GadgetEvent{
+		stopEvent := &apiTypes.GadgetEvent{
 			Type:          apiTypes.TypeGadgetStop,
 			EnvironmentID: req.EnvironmentID,
 			InstanceID:    instanceID,
+		}
+		if limit := r.limiter.done(); limit != "" {
+			stopEvent.Data, _ = json.Marshal(map[string]any{
+				"limit": limit,
+				"value": limitValue(req.Limits, limit),
+			})
+		}
+		s.send(stopEvent)
+	}()
+
+	return instanceID, nil
+}
+
+// RunMulti starts the gadget of req in several environments at once. All runs share a single instance ID,
+// so they are stopped together; their events are tagged with the environment they come from. Limits apply
+// per environment and only stop the run in that environment. If recording is enabled, every environment
+// gets its own run in one common session.
+//
+// Every environment sends its own TypeGadgetInfo under the shared instance ID, tagged with its environment.
+// A single TypeGadgetStop without environment is sent once all runs are done; its data holds the outcome
+// per environment under "environments".
+func (s *Service) RunMulti(ctx context.Context, targets []EnvironmentRun, req RunRequest) (string, error) {
+	if len(targets) == 0 {
+		return "", &apiTypes.ErrInvalidRequest{Reason: "no environments given"}
+	}
+	if req.Detached {
+		return "", &apiTypes.ErrInvalidRequest{Reason: "multi-environment runs can't be detached"}
+	}
+	logLevel, err := ParseLogLevel(req.LogLevel)
+	if err != nil {
+		return "", err
+	}
+
+	for _, target := range targets {
+		envReq := req
+		envReq.EnvironmentID = target.EnvironmentID
+		envReq.Params = WithDefaultParams(req.Params, target.DefaultParams)
+		if err := s.validateRun(ctx, target.Runtime, envReq); err != nil {
+			return "", fmt.Errorf("environment %s: %w", target.EnvironmentID, err)
+		}
+	}
+
+	instanceID := uuid.New().String()
+
+	// Sinks are shared by the runs of all environments; events carry their environment
+	sinks, err := newSinks(req.Sinks, s.sinkPolicy, s.newSink)
+	if err != nil {
+		return "", err
+	}
+
+	if req.Record && s.sessionRecorder != nil && req.SessionID == "" {
+		sessionID, err := s.sessionRecorder.CreateSession(req.SessionName, targets[0].EnvironmentID)
+		if err != nil {
+			log.Printf("failed to create session: %v (continuing without recording)", err)
+			req.Record = false
+		}
+		req.SessionID = sessionID
+	}
+
+	nctx, cancel := context.WithCancel(ctx)
+	s.instanceManager.Register(instanceID, cancel)
+
+	type result struct {
+		Error string `json:"error,omitempty"`
+		Limit string `json:"limit,omitempty"`
+		Value int64  `json:"value,omitempty"`
+	}
+	var mu sync.Mutex
+	results := make(map[string]result, len(targets))
+
+	var wg sync.WaitGroup
+	for _, target := range targets {
+		envCtx, envCancel := context.WithCancel(nctx)
+		envReq := req
+		envReq.EnvironmentID = target.EnvironmentID
+		envReq.Limits = target.Limits
+		envReq.Params = WithDefaultParams(req.Params, target.DefaultParams)
+
+		r := &run{
+			instanceID:    instanceID,
+			environmentID: target.EnvironmentID,
+			recordingID:   instanceID + "/" + target.EnvironmentID,
+			record:        req.Record,
+			sinks:         sinks,
+			logLevel:      logLevel,
+			image:         req.Image,
+			params:        envReq.Params,
+		}
+		r.limiter = newLimiter(target.Limits, func() {
+			log.Printf("instance %s reached its run limits in environment %s, stopping", instanceID, target.EnvironmentID)
+			envCancel()
+		})
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer envCancel()
+
+			var res result
+			err := s.execute(envCtx, target.Runtime, envReq, r)
+			if err != nil {
+				log.Printf("gadget error in environment %s: %v", target.EnvironmentID, err)
+				res.Error = err.Error()
+			}
+			if limit := r.limiter.done(); limit != "" {
+				res.Limit = limit
+				res.Value = limitValue(target.Limits, limit)
+			}
+
+			mu.Lock()
+			results[target.EnvironmentID] = res
+			mu.Unlock()
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		if err := sinks.close(); err != nil {
+			log.Printf("failed to close sinks: %v", err)
+		}
+
+		s.instanceManager.Unregister(instanceID)
+		cancel()
+
+		// The stop covers the runs in all environments, so it isn't tagged with one; the outcome
+		// of every environment is in its data
+		data, _ := json.Marshal(map[string]any{
+			"environments": results,
+		})
+		s.send(&apiTypes.GadgetEvent{
+			Type:       apiTypes.TypeGadgetStop,
+			InstanceID: instanceID,
+			Data:       data,
 		})
 	}()
 
@@ -256,8 +552,23 @@ func (s *Service) Run(ctx context.Context, runtime *grpcruntime.Runtime, req Run
 
 // Attach attaches to an existing gadget instance
 func (s *Service) Attach(ctx context.Context, runtime *grpcruntime.Runtime, req AttachRequest) (string, error) {
+	logLevel, err := ParseLogLevel(req.LogLevel)
+	if err != nil {
+		return "", err
+	}
+
 	instanceID := uuid.New().String()
 
+	r := &run{
+		instanceID:    instanceID,
+		environmentID: req.EnvironmentID,
+		recordingID:   instanceID,
+		attached:      true,
+		logLevel:      logLevel,
+		image:         req.Image,
+		params:        req.Params,
+	}
+
 	xop := simple.New("exp", simple.WithPriority(1000), simple.OnPreStart(func(gadgetCtx operators.GadgetContext) error {
 		gi, err := gadgetCtx.SerializeGadgetInfo(false)
 		if err != nil {
@@ -270,6 +581,12 @@ func (s *Service) Attach(ctx context.Context, runtime *grpcruntime.Runtime, req
 			return err
 		}
 
+		r.gadgetInfo = gid
+		// req.Image is the ID of the instance; use its image so that metrics and sinks can match it
+		if gi.ImageName != "" {
+			r.image = gi.ImageName
+		}
+
 		s.send(&apiTypes.GadgetEvent{
 			Type:          apiTypes.TypeGadgetInfo,
 			EnvironmentID: req.EnvironmentID,
@@ -279,12 +596,15 @@ func (s *Service) Attach(ctx context.Context, runtime *grpcruntime.Runtime, req
 			InstanceName:  req.InstanceName,
 		})
 
-		return s.subscribeToDataSources(gadgetCtx, instanceID, false)
+		return s.subscribeToDataSources(gadgetCtx, r)
 	}))
 
+	gadgetLogger := NewLogger(s.send, instanceID, r.logLevel)
+	gadgetLogger.SetEnvironment(req.EnvironmentID, r.recordingID)
+
 	options := []gadgetcontext.Option{
 		gadgetcontext.WithDataOperators(virtual.New(), xop),
-		gadgetcontext.WithLogger(logger.NewFromGenericLogger(NewLogger(s.send, instanceID, logger.DebugLevel))),
+		gadgetcontext.WithLogger(logger.NewFromGenericLogger(gadgetLogger)),
 		gadgetcontext.WithUseInstance(true),
 	}
 
@@ -294,12 +614,14 @@ func (s *Service) Attach(ctx context.Context, runtime *grpcruntime.Runtime, req
 	s.instanceManager.Register(instanceID, cancel)
 
 	gadgetCtx := gadgetcontext.New(nctx, req.Image, options...)
+	gadgetCtx.SetVar(grpcruntime.VarReconnectHandler, s.reconnectHandler(r))
 
 	go func() {
-		err := runtime.RunGadget(gadgetCtx, rtParams, req.Params)
+		results, err := runtime.RunGadgetWithResults(gadgetCtx, rtParams, req.Params)
 		if err != nil {
 			log.Printf("gadget error: %v", err)
 		}
+		s.sendResults(r, results)
 
 		s.instanceManager.Unregister(instanceID)
 		cancel()
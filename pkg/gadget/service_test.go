@@ -0,0 +1,465 @@
+// Copyright 2026 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gadget
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/datasource"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-service/api"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/logger"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+
+	apiTypes "github.com/inspektor-gadget/ig-desktop/pkg/api"
+	grpcruntime "github.com/inspektor-gadget/ig-desktop/pkg/grpc-runtime"
+)
+
+// testImage is a pinned image, so that its cached info is used without asking the runtime
+const testImage = "trace_exec@sha256:abc"
+
+// gadgetServer runs every gadget by sending its info, a warning if set, a number of events and a result
+type gadgetServer struct {
+	api.UnimplementedGadgetManagerServer
+	events  int
+	warning string
+}
+
+func (g *gadgetServer) RunGadget(stream api.GadgetManager_RunGadgetServer) error {
+	if _, err := stream.Recv(); err != nil {
+		return err
+	}
+
+	ds, err := datasource.New(datasource.TypeSingle, "exec")
+	if err != nil {
+		return err
+	}
+	comm, err := ds.AddField("comm", api.Kind_String)
+	if err != nil {
+		return err
+	}
+	info, err := proto.Marshal(&api.GadgetInfo{
+		Name:        "trace exec",
+		ImageName:   testImage,
+		DataSources: []*api.DataSource{{Id: 1, Name: "exec", Type: uint32(datasource.TypeSingle), Fields: ds.Fields()}},
+	})
+	if err != nil {
+		return err
+	}
+	if err := stream.Send(&api.GadgetEvent{Type: api.EventTypeGadgetInfo, Payload: info}); err != nil {
+		return err
+	}
+	if g.warning != "" {
+		if err := stream.Send(&api.GadgetEvent{Type: uint32(logger.WarnLevel) << api.EventLogShift, Payload: []byte(g.warning)}); err != nil {
+			return err
+		}
+	}
+
+	for i := range g.events {
+		p, err := ds.NewPacketSingle()
+		if err != nil {
+			return err
+		}
+		comm.PutString(p, "sh")
+		payload, err := proto.Marshal(p.Raw())
+		if err != nil {
+			return err
+		}
+		if err := stream.Send(&api.GadgetEvent{Type: api.EventTypeGadgetPayload, Seq: uint32(i + 1), DataSourceID: 1, Payload: payload}); err != nil {
+			return err
+		}
+	}
+	return stream.Send(&api.GadgetEvent{Type: api.EventTypeGadgetResult, Payload: []byte("done")})
+}
+
+// newTestRuntime returns a runtime connected to a gadget server sending the given number of events
+func newTestRuntime(t *testing.T, events int) *grpcruntime.Runtime {
+	t.Helper()
+	return newTestRuntimeFor(t, &gadgetServer{events: events})
+}
+
+// newTestRuntimeFor returns a runtime connected to the given gadget server
+func newTestRuntimeFor(t *testing.T, server *gadgetServer) *grpcruntime.Runtime {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := grpc.NewServer()
+	api.RegisterGadgetManagerServer(srv, server)
+	go srv.Serve(l)
+	t.Cleanup(srv.Stop)
+
+	runtime := grpcruntime.New()
+	globalParams := runtime.GlobalParamDescs().ToParams()
+	if err := globalParams.Set(grpcruntime.ParamRemoteAddress, "tcp://"+l.Addr().String()); err != nil {
+		t.Fatal(err)
+	}
+	if err := runtime.Init(globalParams); err != nil {
+		t.Fatal(err)
+	}
+	return runtime
+}
+
+// testRecorder is a session recorder keeping the runs it started and the events written to them
+type testRecorder struct {
+	mu       sync.Mutex
+	sessions []string
+	runs     map[string]testRecording // recording ID -> run
+	writes   map[string]int           // recording ID -> written events, including those of unknown runs
+}
+
+type testRecording struct {
+	sessionID string
+	params    map[string]string
+	events    int
+	stopped   bool
+}
+
+func newTestRecorder() *testRecorder {
+	return &testRecorder{runs: make(map[string]testRecording), writes: make(map[string]int)}
+}
+
+func (r *testRecorder) CreateSession(name, envID string) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sessions = append(r.sessions, name)
+	return "session", nil
+}
+
+func (r *testRecorder) StartGadgetRun(instanceID, sessionID, image string, params map[string]string, gadgetInfo []byte) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.runs[instanceID] = testRecording{sessionID: sessionID, params: params}
+	return "run-" + instanceID, nil
+}
+
+func (r *testRecorder) WriteEvent(instanceID string, eventType int, dsName string, data []byte) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.writes[instanceID]++
+	run, ok := r.runs[instanceID]
+	if !ok {
+		return errors.New("run not started")
+	}
+	if eventType == apiTypes.TypeGadgetEvent {
+		run.events++
+	}
+	r.runs[instanceID] = run
+	return nil
+}
+
+func (r *testRecorder) StopGadgetRun(instanceID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	run, ok := r.runs[instanceID]
+	if !ok {
+		return errors.New("run not started")
+	}
+	run.stopped = true
+	r.runs[instanceID] = run
+	return nil
+}
+
+// testSink counts the events per environment and how often it has been closed
+type testSink struct {
+	mu     sync.Mutex
+	events map[string]int
+	closed int
+}
+
+func (s *testSink) Write(ev *SinkEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events[ev.EnvironmentID]++
+	return nil
+}
+
+func (s *testSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed++
+	return nil
+}
+
+// newMultiService returns a service with a cached info of testImage requiring a target param. It
+// collects all sent events and closes stopped once a stop event has been sent.
+func newMultiService(t *testing.T) (s *Service, events func() []*apiTypes.GadgetEvent, stopped chan struct{}) {
+	t.Helper()
+	cache, err := NewInfoCache(t.TempDir(), time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := &api.GadgetInfo{
+		Params: api.Params{{Prefix: "operator.oci.", Key: "target", IsMandatory: true}},
+	}
+	for _, env := range []string{"env-a", "env-b"} {
+		if err := cache.Set(env, testImage, info); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var mu sync.Mutex
+	var sent []*apiTypes.GadgetEvent
+	stopped = make(chan struct{})
+	s = NewService(NewInstanceManager())
+	s.SetInfoCache(cache)
+	s.SetSendFunc(func(ev any) {
+		gev, ok := ev.(*apiTypes.GadgetEvent)
+		if !ok {
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		sent = append(sent, gev)
+		if gev.Type == apiTypes.TypeGadgetStop {
+			close(stopped)
+		}
+	})
+	return s, func() []*apiTypes.GadgetEvent {
+		mu.Lock()
+		defer mu.Unlock()
+		return slices.Clone(sent)
+	}, stopped
+}
+
+func TestRunMulti(t *testing.T) {
+	s, events, stopped := newMultiService(t)
+	recorder := newTestRecorder()
+	s.SetSessionRecorder(recorder)
+	sink := &testSink{events: make(map[string]int)}
+	created := 0
+	s.newSink = func(cfg SinkConfig, policy SinkPolicy) (Sink, error) {
+		created++
+		return sink, nil
+	}
+
+	targets := []EnvironmentRun{
+		{
+			EnvironmentID: "env-a",
+			Runtime:       newTestRuntime(t, 5),
+			Limits:        apiTypes.RunLimits{MaxEvents: 2},
+			DefaultParams: map[string]string{"operator.oci.target": "a"},
+		},
+		{
+			EnvironmentID: "env-b",
+			Runtime:       newTestRuntime(t, 5),
+			DefaultParams: map[string]string{"operator.oci.target": "b"},
+		},
+	}
+	id, err := s.RunMulti(context.Background(), targets, RunRequest{
+		Image:       testImage,
+		Record:      true,
+		SessionName: "multi",
+		Sinks:       []SinkConfig{{Type: "test"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-stopped:
+	case <-time.After(10 * time.Second):
+		t.Fatal("run didn't stop")
+	}
+
+	infos := make(map[string]bool)
+	var stops []*apiTypes.GadgetEvent
+	received := make(map[string]int)
+	for _, ev := range events() {
+		if ev.InstanceID != id {
+			t.Errorf("event of type %d has instance ID %q, want %q", ev.Type, ev.InstanceID, id)
+		}
+		switch ev.Type {
+		case apiTypes.TypeGadgetInfo:
+			infos[ev.EnvironmentID] = true
+		case apiTypes.TypeGadgetEvent:
+			received[ev.EnvironmentID]++
+		case apiTypes.TypeGadgetStop:
+			stops = append(stops, ev)
+		}
+	}
+	if !infos["env-a"] || !infos["env-b"] || len(infos) != 2 {
+		t.Errorf("expected gadget info of both environments, got %v", infos)
+	}
+	if received["env-b"] != 5 || received["env-a"] < 2 {
+		t.Errorf("unexpected events per environment: %v", received)
+	}
+
+	// A single stop covers all environments and holds the outcome of each of them
+	if len(stops) != 1 {
+		t.Fatalf("expected a single stop event, got %d", len(stops))
+	}
+	if stops[0].EnvironmentID != "" {
+		t.Errorf("expected stop event without environment, got %q", stops[0].EnvironmentID)
+	}
+	var stop struct {
+		Environments map[string]struct {
+			Error string `json:"error"`
+			Limit string `json:"limit"`
+			Value int64  `json:"value"`
+		} `json:"environments"`
+	}
+	if err := json.Unmarshal(stops[0].Data, &stop); err != nil {
+		t.Fatal(err)
+	}
+	if res := stop.Environments["env-a"]; res.Limit != LimitEvents || res.Value != 2 || res.Error != "" {
+		t.Errorf("expected env-a to reach its event limit, got %+v", res)
+	}
+	if res, ok := stop.Environments["env-b"]; !ok || res.Limit != "" || res.Error != "" {
+		t.Errorf("expected env-b to end without limit, got %+v", res)
+	}
+
+	// Every environment records its own run into one session
+	if len(recorder.sessions) != 1 || recorder.sessions[0] != "multi" {
+		t.Errorf("expected a single session to be created, got %v", recorder.sessions)
+	}
+	for _, env := range []string{"env-a", "env-b"} {
+		recording, ok := recorder.runs[id+"/"+env]
+		if !ok {
+			t.Errorf("no recording %s/%s, got %v", id, env, recorder.runs)
+			continue
+		}
+		if recording.sessionID != "session" || recording.params["operator.oci.target"] != strings.TrimPrefix(env, "env-") || !recording.stopped {
+			t.Errorf("unexpected recording of %s: %+v", env, recording)
+		}
+		if recording.events != received[env] {
+			t.Errorf("recorded %d events of %s, sent %d", recording.events, env, received[env])
+		}
+	}
+	if len(recorder.runs) != 2 {
+		t.Errorf("expected 2 recordings, got %d", len(recorder.runs))
+	}
+
+	// Sinks are shared by the environments and closed once all of them are done
+	if created != 1 || sink.closed != 1 {
+		t.Errorf("expected the sink to be created and closed once, got %d and %d", created, sink.closed)
+	}
+	if sink.events["env-a"] != received["env-a"] || sink.events["env-b"] != received["env-b"] {
+		t.Errorf("expected sink to get the events of all environments, got %v", sink.events)
+	}
+}
+
+func TestRunMultiValidatesEnvironments(t *testing.T) {
+	s, _, _ := newMultiService(t)
+	created := 0
+	s.newSink = func(cfg SinkConfig, policy SinkPolicy) (Sink, error) {
+		created++
+		return &testSink{}, nil
+	}
+
+	// Only env-a has a default for the required param
+	targets := []EnvironmentRun{
+		{EnvironmentID: "env-a", DefaultParams: map[string]string{"operator.oci.target": "a"}},
+		{EnvironmentID: "env-b"},
+	}
+	_, err := s.RunMulti(context.Background(), targets, RunRequest{Image: testImage, Sinks: []SinkConfig{{Type: "test"}}})
+	var paramErr *apiTypes.ErrInvalidParams
+	if !errors.As(err, &paramErr) || !strings.Contains(err.Error(), "env-b") {
+		t.Fatalf("expected param errors of env-b, got %v", err)
+	}
+	if len(paramErr.Errors) != 1 || paramErr.Errors[0].Key != "operator.oci.target" {
+		t.Errorf("unexpected param errors: %+v", paramErr.Errors)
+	}
+	if created != 0 {
+		t.Errorf("expected no sinks to be created for a rejected run")
+	}
+
+	// Detached instances only exist in a single environment
+	_, err = s.RunMulti(context.Background(), targets, RunRequest{Image: testImage, Detached: true})
+	if err == nil {
+		t.Error("expected detached multi-environment runs to be rejected")
+	}
+}
+
+func TestReconnectMarker(t *testing.T) {
+	for _, record := range []bool{false, true} {
+		var sent []*apiTypes.GadgetEvent
+		s := NewService(NewInstanceManager())
+		s.SetSendFunc(func(ev any) {
+			sent = append(sent, ev.(*apiTypes.GadgetEvent))
+		})
+		recorder := newTestRecorder()
+		s.SetSessionRecorder(recorder)
+		if record {
+			recorder.StartGadgetRun("recording", "session", testImage, nil, nil)
+		}
+
+		r := &run{instanceID: "instance", environmentID: "env", recordingID: "recording", record: record}
+		s.reconnectHandler(r)("node-1", 3*time.Second)
+
+		if len(sent) != 1 || sent[0].Type != apiTypes.TypeGadgetReconnect || sent[0].EnvironmentID != "env" {
+			t.Errorf("record %v: unexpected events %+v", record, sent)
+		}
+		// Markers of runs that aren't recorded must not be written to a recording that was never started
+		want := 0
+		if record {
+			want = 1
+		}
+		if writes := recorder.writes["recording"]; writes != want {
+			t.Errorf("record %v: got %d written markers, want %d", record, writes, want)
+		}
+	}
+}
+
+func TestRunRecording(t *testing.T) {
+	for _, record := range []bool{false, true} {
+		s, events, stopped := newMultiService(t)
+		recorder := newTestRecorder()
+		s.SetSessionRecorder(recorder)
+
+		runtime := newTestRuntimeFor(t, &gadgetServer{events: 2, warning: "low memory"})
+		id, err := s.Run(context.Background(), runtime, RunRequest{
+			Image:         testImage,
+			EnvironmentID: "env-a",
+			Params:        map[string]string{"operator.oci.target": "a"},
+			Record:        record,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case <-stopped:
+		case <-time.After(10 * time.Second):
+			t.Fatal("run didn't stop")
+		}
+
+		logs := 0
+		for _, ev := range events() {
+			if ev.Type == apiTypes.TypeGadgetLog {
+				logs++
+			}
+		}
+		if logs == 0 {
+			t.Errorf("record %v: expected the warning to be sent", record)
+		}
+
+		// Runs that aren't recorded must not write to a recording that was never started
+		writes := recorder.writes[id]
+		if !record && len(recorder.writes) != 0 {
+			t.Errorf("expected no writes for a run that isn't recorded, got %v", recorder.writes)
+		}
+		if record && writes < logs+2 {
+			t.Errorf("expected the events and logs to be recorded, got %d writes", writes)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+// Copyright 2026 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gadget
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+
+	apiTypes "github.com/inspektor-gadget/ig-desktop/pkg/api"
+)
+
+// Sink types
+const (
+	SinkWebhook = "webhook"
+	SinkFile    = "file"
+	SinkSyslog  = "syslog"
+	SinkOTLP    = "otlp"
+)
+
+// SinkEvent is a single piece of gadget output as handed to sinks. Data holds the same
+// formatted JSON that is sent to the frontend.
+type SinkEvent struct {
+	InstanceID    string          `json:"instanceID"`
+	EnvironmentID string          `json:"environmentID,omitempty"`
+	Image         string          `json:"image"`
+	Datasource    string          `json:"datasource"`
+	Type          int             `json:"type"`      // TypeGadgetEvent or TypeGadgetEventArray
+	Timestamp     int64           `json:"timestamp"` // unix ms
+	Data          json.RawMessage `json:"data"`
+}
+
+// Sink forwards gadget output to an external destination. Write must not block the
+// event pipeline for long; Close flushes pending output and releases resources.
+type Sink interface {
+	Write(ev *SinkEvent) error
+	Close() error
+}
+
+// SinkConfig configures a sink of the given type; only the settings matching the type are used
+type SinkConfig struct {
+	Type    string             `json:"type"`
+	Webhook *WebhookSinkConfig `json:"webhook,omitempty"`
+	File    *FileSinkConfig    `json:"file,omitempty"`
+	Syslog  *SyslogSinkConfig  `json:"syslog,omitempty"`
+	OTLP    *OTLPSinkConfig    `json:"otlp,omitempty"`
+}
+
+// SinkPolicy restricts the sinks clients can configure. The zero value disables all sinks, as
+// clients of a server must not be able to write files or send requests on behalf of the host.
+type SinkPolicy struct {
+	// FileDir is the directory file sink paths are resolved in; file sinks are disabled if empty
+	FileDir string
+	// AllowNetwork enables sinks that send events to an address given by the client, that is
+	// webhook, OTLP and syslog sinks
+	AllowNetwork bool
+}
+
+// NewSink creates a sink from its configuration if the policy allows it
+func NewSink(cfg SinkConfig, policy SinkPolicy) (Sink, error) {
+	switch cfg.Type {
+	case SinkWebhook, SinkSyslog, SinkOTLP:
+		if !policy.AllowNetwork {
+			return nil, &apiTypes.ErrInvalidRequest{Reason: fmt.Sprintf("%s sinks are disabled", cfg.Type)}
+		}
+	case SinkFile:
+		if policy.FileDir == "" {
+			return nil, &apiTypes.ErrInvalidRequest{Reason: "file sinks are disabled"}
+		}
+	}
+
+	switch cfg.Type {
+	case SinkWebhook:
+		if cfg.Webhook == nil {
+			return nil, &apiTypes.ErrInvalidRequest{Reason: "webhook sink requires webhook settings"}
+		}
+		return newWebhookSink(*cfg.Webhook)
+	case SinkFile:
+		if cfg.File == nil {
+			return nil, &apiTypes.ErrInvalidRequest{Reason: "file sink requires file settings"}
+		}
+		return newFileSink(*cfg.File, policy.FileDir)
+	case SinkSyslog:
+		if cfg.Syslog == nil {
+			return nil, &apiTypes.ErrInvalidRequest{Reason: "syslog sink requires syslog settings"}
+		}
+		return newSyslogSink(*cfg.Syslog)
+	case SinkOTLP:
+		if cfg.OTLP == nil {
+			return nil, &apiTypes.ErrInvalidRequest{Reason: "otlp sink requires otlp settings"}
+		}
+		return newOTLPSink(*cfg.OTLP)
+	}
+	return nil, &apiTypes.ErrInvalidRequest{Reason: fmt.Sprintf("unknown sink type %q", cfg.Type)}
+}
+
+// sinks fans out events to several sinks
+type sinks []Sink
+
+// newSinks creates all configured sinks using newSink; if one of them fails, the ones already created
+// are closed again
+func newSinks(configs []SinkConfig, policy SinkPolicy, newSink func(SinkConfig, SinkPolicy) (Sink, error)) (sinks, error) {
+	res := make(sinks, 0, len(configs))
+	for _, cfg := range configs {
+		sink, err := newSink(cfg, policy)
+		if err != nil {
+			res.close()
+			return nil, fmt.Errorf("creating %s sink: %w", cfg.Type, err)
+		}
+		res = append(res, sink)
+	}
+	return res, nil
+}
+
+func (s sinks) write(ev *SinkEvent) {
+	for _, sink := range s {
+		if err := sink.Write(ev); err != nil {
+			log.Printf("failed to write event to sink: %v", err)
+		}
+	}
+}
+
+func (s sinks) close() error {
+	var errs []error
+	for _, sink := range s {
+		errs = append(errs, sink.Close())
+	}
+	return errors.Join(errs...)
+}
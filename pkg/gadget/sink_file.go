@@ -0,0 +1,147 @@
+// Copyright 2026 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gadget
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	apiTypes "github.com/inspektor-gadget/ig-desktop/pkg/api"
+)
+
+// Defaults of the file sink
+const (
+	DefaultFileSinkMaxSize  = 100 * 1024 * 1024 // bytes
+	DefaultFileSinkMaxFiles = 5
+)
+
+// FileSinkConfig configures a sink that appends events as NDJSON to a file. Once the file
+// reaches MaxSize it is rotated to <path>.1, <path>.2, ... keeping at most MaxFiles old files.
+type FileSinkConfig struct {
+	Path     string `json:"path"`              // relative to the sink directory of the app
+	MaxSize  int64  `json:"maxSize,omitempty"` // bytes
+	MaxFiles int    `json:"maxFiles,omitempty"`
+}
+
+type fileSink struct {
+	cfg FileSinkConfig
+
+	mu   sync.Mutex
+	f    *os.File
+	size int64
+}
+
+// resolveSinkPath returns the location of path in dir; path has to be relative and must not
+// leave dir
+func resolveSinkPath(dir string, path string) (string, error) {
+	if path == "" {
+		return "", &apiTypes.ErrInvalidRequest{Reason: "file sink requires a path"}
+	}
+	if !filepath.IsLocal(path) {
+		return "", &apiTypes.ErrInvalidRequest{Reason: fmt.Sprintf("file sink path %q must be relative and must not contain \"..\"", path)}
+	}
+	return filepath.Join(dir, path), nil
+}
+
+func newFileSink(cfg FileSinkConfig, dir string) (*fileSink, error) {
+	path, err := resolveSinkPath(dir, cfg.Path)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Path = path
+	if cfg.MaxSize <= 0 {
+		cfg.MaxSize = DefaultFileSinkMaxSize
+	}
+	if cfg.MaxFiles <= 0 {
+		cfg.MaxFiles = DefaultFileSinkMaxFiles
+	}
+	if err := os.MkdirAll(filepath.Dir(cfg.Path), 0o755); err != nil {
+		return nil, fmt.Errorf("creating directory: %w", err)
+	}
+
+	s := &fileSink{cfg: cfg}
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *fileSink) open() error {
+	f, err := os.OpenFile(s.cfg.Path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("opening file: %w", err)
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("opening file: %w", err)
+	}
+	s.f = f
+	s.size = fi.Size()
+	return nil
+}
+
+// rotate shifts the existing files by one and starts a new file
+func (s *fileSink) rotate() error {
+	if err := s.f.Close(); err != nil {
+		return err
+	}
+	s.f = nil
+	os.Remove(fmt.Sprintf("%s.%d", s.cfg.Path, s.cfg.MaxFiles))
+	for i := s.cfg.MaxFiles - 1; i >= 1; i-- {
+		os.Rename(fmt.Sprintf("%s.%d", s.cfg.Path, i), fmt.Sprintf("%s.%d", s.cfg.Path, i+1))
+	}
+	if err := os.Rename(s.cfg.Path, s.cfg.Path+".1"); err != nil {
+		return err
+	}
+	return s.open()
+}
+
+func (s *fileSink) Write(ev *SinkEvent) error {
+	line, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.f == nil {
+		return errors.New("file sink closed")
+	}
+	if s.size > 0 && s.size+int64(len(line)) > s.cfg.MaxSize {
+		if err := s.rotate(); err != nil {
+			return fmt.Errorf("rotating %s: %w", s.cfg.Path, err)
+		}
+	}
+	n, err := s.f.Write(line)
+	s.size += int64(n)
+	return err
+}
+
+func (s *fileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.f == nil {
+		return nil
+	}
+	err := s.f.Close()
+	s.f = nil
+	return err
+}
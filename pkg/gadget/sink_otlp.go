@@ -0,0 +1,217 @@
+// Copyright 2026 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gadget
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	"google.golang.org/protobuf/proto"
+
+	apiTypes "github.com/inspektor-gadget/ig-desktop/pkg/api"
+	grpcruntime "github.com/inspektor-gadget/ig-desktop/pkg/grpc-runtime"
+)
+
+// DefaultOTLPEndpoint is the logs endpoint of a collector running on the local machine
+const DefaultOTLPEndpoint = "http://localhost:4318/v1/logs"
+
+// Resource attributes set on exported log records
+const (
+	OTLPAttrServiceName = "service.name"
+	OTLPAttrImage       = "gadget.image"
+	OTLPAttrDatasource  = "gadget.datasource"
+	OTLPAttrInstanceID  = "gadget.instance_id"
+	OTLPAttrEnvironment = "gadget.environment_id"
+	OTLPAttrNode        = "gadget.node"
+	OTLPAttrTarget      = "gadget.target"
+)
+
+// OTLPSinkConfig configures a sink that exports events as OTLP log records over HTTP/protobuf.
+// Batching and retries behave like the webhook sink.
+type OTLPSinkConfig struct {
+	Endpoint      string            `json:"endpoint,omitempty"` // defaults to DefaultOTLPEndpoint
+	Headers       map[string]string `json:"headers,omitempty"`
+	ServiceName   string            `json:"serviceName,omitempty"`
+	BatchSize     int               `json:"batchSize,omitempty"`
+	FlushInterval int64             `json:"flushInterval,omitempty"` // seconds
+	MaxRetries    int               `json:"maxRetries,omitempty"`    // negative disables retries
+	Timeout       int64             `json:"timeout,omitempty"`       // seconds, per request
+}
+
+func newOTLPSink(cfg OTLPSinkConfig) (*webhookSink, error) {
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = DefaultOTLPEndpoint
+	}
+	if cfg.ServiceName == "" {
+		cfg.ServiceName = "inspektor-gadget"
+	}
+	s, err := newWebhookSink(WebhookSinkConfig{
+		URL:           cfg.Endpoint,
+		Headers:       cfg.Headers,
+		BatchSize:     cfg.BatchSize,
+		FlushInterval: cfg.FlushInterval,
+		MaxRetries:    cfg.MaxRetries,
+		Timeout:       cfg.Timeout,
+	})
+	if err != nil {
+		return nil, &apiTypes.ErrInvalidRequest{Reason: fmt.Sprintf("invalid OTLP endpoint %q", cfg.Endpoint)}
+	}
+	s.encode = func(batch []*SinkEvent) ([]byte, error) {
+		return proto.Marshal(otlpLogsRequest(cfg.ServiceName, batch))
+	}
+	s.contentType = "application/x-protobuf"
+	return s, nil
+}
+
+// otlpResource identifies the resource log records are grouped by
+type otlpResource struct {
+	instanceID    string
+	environmentID string
+	image         string
+	datasource    string
+	node          string
+	target        string
+}
+
+// otlpLogsRequest converts a batch of events into an export request with one log record per
+// event (or array element), grouped by resource
+func otlpLogsRequest(serviceName string, batch []*SinkEvent) *collogspb.ExportLogsServiceRequest {
+	req := &collogspb.ExportLogsServiceRequest{}
+	scopes := make(map[otlpResource]*logspb.ScopeLogs)
+
+	for _, ev := range batch {
+		var records []map[string]any
+		if ev.Type == apiTypes.TypeGadgetEventArray {
+			if err := decodeJSON(ev.Data, &records); err != nil {
+				continue
+			}
+		} else {
+			var record map[string]any
+			if err := decodeJSON(ev.Data, &record); err != nil {
+				continue
+			}
+			records = append(records, record)
+		}
+
+		for _, record := range records {
+			res := otlpResource{
+				instanceID:    ev.InstanceID,
+				environmentID: ev.EnvironmentID,
+				image:         ev.Image,
+				datasource:    ev.Datasource,
+			}
+			if node, ok := record[grpcruntime.TargetFieldNode].(string); ok {
+				res.node = node
+			} else if node, ok := record["k8s.node"].(string); ok {
+				res.node = node
+			}
+			if target, ok := record[grpcruntime.TargetFieldTarget].(string); ok {
+				res.target = target
+			}
+
+			scope, ok := scopes[res]
+			if !ok {
+				scope = &logspb.ScopeLogs{Scope: &commonpb.InstrumentationScope{Name: "ig-desktop"}}
+				scopes[res] = scope
+				req.ResourceLogs = append(req.ResourceLogs, &logspb.ResourceLogs{
+					Resource:  &resourcepb.Resource{Attributes: res.attributes(serviceName)},
+					ScopeLogs: []*logspb.ScopeLogs{scope},
+				})
+			}
+
+			ts := uint64(ev.Timestamp) * 1e6
+			scope.LogRecords = append(scope.LogRecords, &logspb.LogRecord{
+				TimeUnixNano:         ts,
+				ObservedTimeUnixNano: ts,
+				SeverityNumber:       logspb.SeverityNumber_SEVERITY_NUMBER_INFO,
+				SeverityText:         "INFO",
+				Body:                 &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: ev.Datasource}},
+				Attributes:           otlpAttributes(record),
+			})
+		}
+	}
+	return req
+}
+
+func (r otlpResource) attributes(serviceName string) []*commonpb.KeyValue {
+	attrs := []*commonpb.KeyValue{
+		otlpString(OTLPAttrServiceName, serviceName),
+		otlpString(OTLPAttrImage, r.image),
+		otlpString(OTLPAttrDatasource, r.datasource),
+		otlpString(OTLPAttrInstanceID, r.instanceID),
+	}
+	if r.environmentID != "" {
+		attrs = append(attrs, otlpString(OTLPAttrEnvironment, r.environmentID))
+	}
+	if r.node != "" {
+		attrs = append(attrs, otlpString(OTLPAttrNode, r.node))
+	}
+	if r.target != "" {
+		attrs = append(attrs, otlpString(OTLPAttrTarget, r.target))
+	}
+	return attrs
+}
+
+// otlpAttributes converts the flattened fields of an event into attributes, sorted by key
+func otlpAttributes(record map[string]any) []*commonpb.KeyValue {
+	attrs := make([]*commonpb.KeyValue, 0, len(record))
+	for k, v := range record {
+		if value := otlpValue(v); value != nil {
+			attrs = append(attrs, &commonpb.KeyValue{Key: k, Value: value})
+		}
+	}
+	slices.SortFunc(attrs, func(a, b *commonpb.KeyValue) int { return strings.Compare(a.Key, b.Key) })
+	return attrs
+}
+
+func otlpValue(v any) *commonpb.AnyValue {
+	switch v := v.(type) {
+	case string:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: v}}
+	case bool:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_BoolValue{BoolValue: v}}
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: i}}
+		}
+		if f, err := v.Float64(); err == nil {
+			return &commonpb.AnyValue{Value: &commonpb.AnyValue_DoubleValue{DoubleValue: f}}
+		}
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: v.String()}}
+	case nil:
+		return nil
+	}
+	// Nested values don't occur in flattened output, but keep them readable if they do
+	b, _ := json.Marshal(v)
+	return &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: string(b)}}
+}
+
+// decodeJSON decodes keeping numbers as json.Number, so that integers don't lose precision
+func decodeJSON(data []byte, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	return dec.Decode(v)
+}
+
+func otlpString(key, value string) *commonpb.KeyValue {
+	return &commonpb.KeyValue{Key: key, Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: value}}}
+}
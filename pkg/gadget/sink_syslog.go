@@ -0,0 +1,268 @@
+// Copyright 2026 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gadget
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	apiTypes "github.com/inspektor-gadget/ig-desktop/pkg/api"
+)
+
+// Defaults of the syslog sink
+const (
+	DefaultSyslogAppName  = "ig-desktop"
+	DefaultSyslogFacility = 1 // user-level messages
+
+	syslogSeverityInfo  = 6
+	syslogDialTimeout   = 10 * time.Second
+	syslogWriteTimeout  = 5 * time.Second
+	syslogQueueSize     = 10000
+	syslogMaxRedialWait = time.Minute
+)
+
+// syslogRedialBackoff is the wait before redialing after a failed dial; it doubles with every
+// failed dial up to syslogMaxRedialWait
+var syslogRedialBackoff = time.Second
+
+// SyslogSinkConfig configures a sink that sends every event as RFC 5424 message; the message
+// body is the JSON encoded event. Over TCP, messages are framed using octet counting (RFC 6587).
+type SyslogSinkConfig struct {
+	Network  string `json:"network,omitempty"` // "udp" (default) or "tcp"
+	Address  string `json:"address"`           // host:port
+	AppName  string `json:"appName,omitempty"`
+	Facility int    `json:"facility,omitempty"`
+}
+
+// syslogSink queues messages and sends them from a goroutine, so that a slow or unreachable
+// collector doesn't block the event pipeline. While the collector can't be reached, messages are
+// dropped and the sink redials with a growing backoff.
+type syslogSink struct {
+	cfg      SyslogSinkConfig
+	hostname string
+
+	// Owned by loop once the sink has been created
+	conn     net.Conn
+	nextDial time.Time     // earliest time to redial after a failed dial
+	dialWait time.Duration // wait after the last failed dial
+	failed   int           // messages dropped because sending failed
+
+	mu      sync.Mutex
+	closed  bool
+	queue   chan []byte
+	dropped int // messages dropped because the queue was full
+	done    chan struct{}
+}
+
+func newSyslogSink(cfg SyslogSinkConfig) (*syslogSink, error) {
+	if cfg.Network == "" {
+		cfg.Network = "udp"
+	}
+	if cfg.Network != "udp" && cfg.Network != "tcp" {
+		return nil, &apiTypes.ErrInvalidRequest{Reason: fmt.Sprintf("unsupported syslog network %q", cfg.Network)}
+	}
+	if cfg.Address == "" {
+		return nil, &apiTypes.ErrInvalidRequest{Reason: "syslog sink requires an address"}
+	}
+	if cfg.AppName == "" {
+		cfg.AppName = DefaultSyslogAppName
+	}
+	if cfg.Facility == 0 {
+		cfg.Facility = DefaultSyslogFacility
+	}
+	if cfg.Facility < 0 || cfg.Facility > 23 {
+		return nil, &apiTypes.ErrInvalidRequest{Reason: fmt.Sprintf("invalid syslog facility %d", cfg.Facility)}
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "-"
+	}
+
+	s := &syslogSink{
+		cfg:      cfg,
+		hostname: hostname,
+		queue:    make(chan []byte, syslogQueueSize),
+		done:     make(chan struct{}),
+	}
+	// Dial once up front, so that a wrong address is reported when the run starts
+	if err := s.dial(); err != nil {
+		return nil, err
+	}
+	go s.loop()
+	return s, nil
+}
+
+func (s *syslogSink) dial() error {
+	conn, err := net.DialTimeout(s.cfg.Network, s.cfg.Address, syslogDialTimeout)
+	if err != nil {
+		return fmt.Errorf("connecting to syslog at %s: %w", s.cfg.Address, err)
+	}
+	s.conn = conn
+	return nil
+}
+
+// redial connects again unless the previous attempt failed too recently
+func (s *syslogSink) redial() error {
+	if now := time.Now(); now.Before(s.nextDial) {
+		return fmt.Errorf("waiting %s before reconnecting to syslog at %s", s.nextDial.Sub(now).Round(time.Second), s.cfg.Address)
+	}
+	if err := s.dial(); err != nil {
+		if s.dialWait == 0 {
+			s.dialWait = syslogRedialBackoff
+		} else {
+			s.dialWait = min(s.dialWait*2, syslogMaxRedialWait)
+		}
+		s.nextDial = time.Now().Add(s.dialWait)
+		return err
+	}
+	s.dialWait = 0
+	return nil
+}
+
+// syslogHeaderValue returns v as printable US-ASCII without spaces, limited to max characters,
+// or the NILVALUE if nothing remains
+func syslogHeaderValue(v string, max int) string {
+	v = strings.Map(func(r rune) rune {
+		if r < 33 || r > 126 {
+			return -1
+		}
+		return r
+	}, v)
+	if len(v) > max {
+		v = v[:max]
+	}
+	if v == "" {
+		return "-"
+	}
+	return v
+}
+
+// format returns the RFC 5424 message for the event
+func (s *syslogSink) format(ev *SinkEvent) ([]byte, error) {
+	msg, err := json.Marshal(ev)
+	if err != nil {
+		return nil, err
+	}
+	header := fmt.Sprintf("<%d>1 %s %s %s %d %s - ",
+		s.cfg.Facility*8+syslogSeverityInfo,
+		time.UnixMilli(ev.Timestamp).UTC().Format("2006-01-02T15:04:05.000Z07:00"),
+		syslogHeaderValue(s.hostname, 255),
+		syslogHeaderValue(s.cfg.AppName, 48),
+		os.Getpid(),
+		syslogHeaderValue(ev.Datasource, 32),
+	)
+	return append([]byte(header), msg...), nil
+}
+
+// Write queues the message of the event; if the queue is full because the collector is too slow
+// or unreachable, the event is dropped
+func (s *syslogSink) Write(ev *SinkEvent) error {
+	msg, err := s.format(ev)
+	if err != nil {
+		return err
+	}
+	if s.cfg.Network == "tcp" {
+		msg = append([]byte(fmt.Sprintf("%d ", len(msg))), msg...)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return errors.New("syslog sink closed")
+	}
+	select {
+	case s.queue <- msg:
+	default:
+		s.dropped++
+	}
+	return nil
+}
+
+func (s *syslogSink) loop() {
+	defer close(s.done)
+	for msg := range s.queue {
+		if err := s.send(msg); err != nil {
+			if s.failed == 0 {
+				log.Printf("syslog sink %s: dropping events: %v", s.cfg.Address, err)
+			}
+			s.failed++
+		}
+	}
+	if s.conn != nil {
+		s.conn.Close()
+		s.conn = nil
+	}
+}
+
+// send writes a message, reconnecting once, e.g. if the TCP connection has been closed by the server
+func (s *syslogSink) send(msg []byte) error {
+	if s.conn == nil {
+		if err := s.redial(); err != nil {
+			return err
+		}
+	}
+	if err := s.write(msg); err == nil {
+		return nil
+	}
+	s.conn.Close()
+	s.conn = nil
+	if err := s.redial(); err != nil {
+		return err
+	}
+	if err := s.write(msg); err != nil {
+		s.conn.Close()
+		s.conn = nil
+		return err
+	}
+	return nil
+}
+
+func (s *syslogSink) write(msg []byte) error {
+	if err := s.conn.SetWriteDeadline(time.Now().Add(syslogWriteTimeout)); err != nil {
+		return err
+	}
+	_, err := s.conn.Write(msg)
+	return err
+}
+
+// Close sends the queued messages and closes the connection
+func (s *syslogSink) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	close(s.queue)
+	dropped := s.dropped
+	s.mu.Unlock()
+
+	<-s.done
+	if dropped > 0 {
+		log.Printf("syslog sink %s: dropped %d events because the collector was too slow", s.cfg.Address, dropped)
+	}
+	if s.failed > 0 {
+		log.Printf("syslog sink %s: dropped %d events that couldn't be sent", s.cfg.Address, s.failed)
+	}
+	return nil
+}
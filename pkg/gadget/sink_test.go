@@ -0,0 +1,368 @@
+// Copyright 2026 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gadget
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	"google.golang.org/protobuf/proto"
+
+	apiTypes "github.com/inspektor-gadget/ig-desktop/pkg/api"
+)
+
+func TestWebhookSinkBatchesAndRetries(t *testing.T) {
+	backoff := webhookRetryBackoff
+	webhookRetryBackoff = time.Millisecond
+	t.Cleanup(func() { webhookRetryBackoff = backoff })
+
+	var mu sync.Mutex
+	var requests int
+	var batches [][]SinkEvent
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		requests++
+		if requests == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var batch []SinkEvent
+		if err := json.NewDecoder(r.Body).Decode(&batch); err != nil {
+			t.Error(err)
+		}
+		batches = append(batches, batch)
+	}))
+	defer srv.Close()
+
+	sink, err := NewSink(SinkConfig{Type: SinkWebhook, Webhook: &WebhookSinkConfig{URL: srv.URL, BatchSize: 2, FlushInterval: 60}}, SinkPolicy{AllowNetwork: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		if err := sink.Write(&SinkEvent{Datasource: "exec", Data: json.RawMessage(`{}`)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if requests != 3 || len(batches) != 2 || len(batches[0]) != 2 || len(batches[1]) != 1 {
+		t.Fatalf("unexpected delivery: %d requests, batches %v", requests, batches)
+	}
+}
+
+func TestFileSinkRotates(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "out", "events.ndjson")
+	sink, err := NewSink(SinkConfig{Type: SinkFile, File: &FileSinkConfig{Path: "out/events.ndjson", MaxSize: 200, MaxFiles: 2}}, SinkPolicy{FileDir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 10; i++ {
+		if err := sink.Write(&SinkEvent{Datasource: "exec", Data: json.RawMessage(`{"comm":"nc"}`)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{path, path + ".1", path + ".2"} {
+		fi, err := os.Stat(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fi.Size() > 200 {
+			t.Errorf("%s exceeds max size: %d", name, fi.Size())
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("expected at most 2 rotated files")
+	}
+}
+
+func TestSinkPolicy(t *testing.T) {
+	dir := t.TempDir()
+	for _, tc := range []struct {
+		name   string
+		cfg    SinkConfig
+		policy SinkPolicy
+	}{
+		{"file without dir", SinkConfig{Type: SinkFile, File: &FileSinkConfig{Path: "events.ndjson"}}, SinkPolicy{AllowNetwork: true}},
+		{"absolute path", SinkConfig{Type: SinkFile, File: &FileSinkConfig{Path: filepath.Join(dir, "events.ndjson")}}, SinkPolicy{FileDir: dir}},
+		{"parent dir", SinkConfig{Type: SinkFile, File: &FileSinkConfig{Path: "../events.ndjson"}}, SinkPolicy{FileDir: dir}},
+		{"nested parent dir", SinkConfig{Type: SinkFile, File: &FileSinkConfig{Path: "out/../../events.ndjson"}}, SinkPolicy{FileDir: dir}},
+		{"webhook", SinkConfig{Type: SinkWebhook, Webhook: &WebhookSinkConfig{URL: "http://127.0.0.1"}}, SinkPolicy{FileDir: dir}},
+		{"otlp", SinkConfig{Type: SinkOTLP, OTLP: &OTLPSinkConfig{Endpoint: "http://127.0.0.1"}}, SinkPolicy{FileDir: dir}},
+		{"syslog", SinkConfig{Type: SinkSyslog, Syslog: &SyslogSinkConfig{Address: "127.0.0.1:514"}}, SinkPolicy{}},
+	} {
+		var invalid *apiTypes.ErrInvalidRequest
+		if _, err := NewSink(tc.cfg, tc.policy); !errors.As(err, &invalid) {
+			t.Errorf("%s: expected sink to be rejected, got %v", tc.name, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(dir), "events.ndjson")); !os.IsNotExist(err) {
+		t.Error("file created outside of the sink directory")
+	}
+}
+
+func TestOTLPSinkExportsLogRecords(t *testing.T) {
+	received := make(chan *collogspb.ExportLogsServiceRequest, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/logs" || r.Header.Get("Content-Type") != "application/x-protobuf" {
+			t.Errorf("unexpected request %s %s", r.URL.Path, r.Header.Get("Content-Type"))
+		}
+		body, _ := io.ReadAll(r.Body)
+		req := &collogspb.ExportLogsServiceRequest{}
+		if err := proto.Unmarshal(body, req); err != nil {
+			t.Error(err)
+		}
+		received <- req
+	}))
+	defer srv.Close()
+
+	sink, err := NewSink(SinkConfig{Type: SinkOTLP, OTLP: &OTLPSinkConfig{Endpoint: srv.URL + "/v1/logs"}}, SinkPolicy{AllowNetwork: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sink.Write(&SinkEvent{InstanceID: "i", Image: "trace_exec", Datasource: "exec", Type: apiTypes.TypeGadgetEvent,
+		Data: json.RawMessage(`{"runtime.node":"n1","proc.comm":"sh","proc.pid":42}`)})
+	sink.Write(&SinkEvent{InstanceID: "i", Image: "trace_exec", Datasource: "exec", Type: apiTypes.TypeGadgetEventArray,
+		Data: json.RawMessage(`[{"k8s.node":"n1","proc.comm":"nc"},{"k8s.node":"n2","proc.comm":"ls"}]`)})
+	if err := sink.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	req := <-received
+	if len(req.ResourceLogs) != 2 {
+		t.Fatalf("expected a resource per node, got %d", len(req.ResourceLogs))
+	}
+	nodes := map[string]int{}
+	for _, rl := range req.ResourceLogs {
+		var node string
+		for _, attr := range rl.Resource.Attributes {
+			if attr.Key == OTLPAttrNode {
+				node = attr.Value.GetStringValue()
+			}
+		}
+		nodes[node] = len(rl.ScopeLogs[0].LogRecords)
+	}
+	if nodes["n1"] != 2 || nodes["n2"] != 1 {
+		t.Fatalf("unexpected records per node: %v", nodes)
+	}
+	attrs := req.ResourceLogs[0].ScopeLogs[0].LogRecords[0].Attributes
+	if len(attrs) != 3 || attrs[1].Key != "proc.pid" || attrs[1].Value.GetIntValue() != 42 {
+		t.Fatalf("unexpected attributes: %v", attrs)
+	}
+}
+
+// parseSyslogMessage checks the RFC 5424 header of msg and returns the event of its body
+func parseSyslogMessage(t *testing.T, msg string) SinkEvent {
+	t.Helper()
+	// PRI VERSION TIMESTAMP HOSTNAME APP-NAME PROCID MSGID STRUCTURED-DATA MSG
+	parts := strings.SplitN(msg, " ", 8)
+	if len(parts) != 8 {
+		t.Fatalf("malformed syslog message %q", msg)
+	}
+	want := []string{"<30>1", "2026-01-02T03:04:05.000Z", parts[2], "myapp", strconv.Itoa(os.Getpid()), "exec", "-"}
+	for i, w := range want {
+		if parts[i] != w {
+			t.Errorf("header field %d = %q, want %q", i, parts[i], w)
+		}
+	}
+	var ev SinkEvent
+	if err := json.Unmarshal([]byte(parts[7]), &ev); err != nil {
+		t.Fatalf("parsing message body: %v", err)
+	}
+	return ev
+}
+
+func TestSyslogSink(t *testing.T) {
+	ts := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC).UnixMilli()
+	ev := &SinkEvent{InstanceID: "i", Datasource: "exec", Timestamp: ts, Data: json.RawMessage(`{"proc.comm":"sh"}`)}
+
+	t.Run("udp", func(t *testing.T) {
+		pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer pc.Close()
+
+		sink, err := NewSink(SinkConfig{Type: SinkSyslog, Syslog: &SyslogSinkConfig{Address: pc.LocalAddr().String(), AppName: "my app", Facility: 3}}, SinkPolicy{AllowNetwork: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer sink.Close()
+		if err := sink.Write(ev); err != nil {
+			t.Fatal(err)
+		}
+
+		buf := make([]byte, 64*1024)
+		pc.SetReadDeadline(time.Now().Add(5 * time.Second))
+		n, _, err := pc.ReadFrom(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := parseSyslogMessage(t, string(buf[:n])); got.InstanceID != "i" || string(got.Data) != `{"proc.comm":"sh"}` {
+			t.Errorf("unexpected event %+v", got)
+		}
+	})
+
+	t.Run("tcp", func(t *testing.T) {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer l.Close()
+
+		received := make(chan []string, 1)
+		go func() {
+			conn, err := l.Accept()
+			if err != nil {
+				t.Error(err)
+				received <- nil
+				return
+			}
+			defer conn.Close()
+			// Messages are framed by their length (octet counting)
+			r := bufio.NewReader(conn)
+			var msgs []string
+			for len(msgs) < 2 {
+				var size int
+				if _, err := fmt.Fscanf(r, "%d ", &size); err != nil {
+					t.Error(err)
+					break
+				}
+				msg := make([]byte, size)
+				if _, err := io.ReadFull(r, msg); err != nil {
+					t.Error(err)
+					break
+				}
+				msgs = append(msgs, string(msg))
+			}
+			received <- msgs
+		}()
+
+		sink, err := NewSink(SinkConfig{Type: SinkSyslog, Syslog: &SyslogSinkConfig{Network: "tcp", Address: l.Addr().String(), AppName: "myapp", Facility: 3}}, SinkPolicy{AllowNetwork: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < 2; i++ {
+			if err := sink.Write(ev); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		var msgs []string
+		select {
+		case msgs = <-received:
+		case <-time.After(5 * time.Second):
+			t.Fatal("syslog messages not received")
+		}
+		if len(msgs) != 2 {
+			t.Fatalf("received %d messages, want 2", len(msgs))
+		}
+		for _, msg := range msgs {
+			parseSyslogMessage(t, msg)
+		}
+
+		if err := sink.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if err := sink.Write(ev); err == nil {
+			t.Error("write after close succeeded")
+		}
+	})
+}
+
+func TestSyslogSinkInvalidConfig(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		cfg  SyslogSinkConfig
+	}{
+		{"network", SyslogSinkConfig{Network: "unix", Address: "/dev/log"}},
+		{"no address", SyslogSinkConfig{}},
+		{"facility", SyslogSinkConfig{Address: "127.0.0.1:514", Facility: 24}},
+	} {
+		var invalid *apiTypes.ErrInvalidRequest
+		if _, err := NewSink(SinkConfig{Type: SinkSyslog, Syslog: &tc.cfg}, SinkPolicy{AllowNetwork: true}); !errors.As(err, &invalid) {
+			t.Errorf("%s: expected sink to be rejected, got %v", tc.name, err)
+		}
+	}
+}
+
+func TestSyslogSinkUnreachable(t *testing.T) {
+	backoff := syslogRedialBackoff
+	syslogRedialBackoff = time.Hour
+	t.Cleanup(func() { syslogRedialBackoff = backoff })
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		if conn, err := l.Accept(); err == nil {
+			conn.Close()
+		}
+	}()
+
+	sink, err := newSyslogSink(SyslogSinkConfig{Network: "tcp", Address: l.Addr().String()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The collector goes away after the sink connected
+	l.Close()
+
+	ev := &SinkEvent{InstanceID: "i", Datasource: "exec", Data: json.RawMessage(`{}`)}
+	start := time.Now()
+	for i := 0; i < 100; i++ {
+		if err := sink.Write(ev); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("writing to an unreachable collector took %s", d)
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Writes to the closed connection might still succeed, but not all of them
+	if sink.failed == 0 {
+		t.Error("expected undeliverable events to be counted")
+	}
+	// Failed dials back off instead of redialing for every event
+	if sink.dialWait != syslogRedialBackoff {
+		t.Errorf("expected a single failed dial, waiting %s", sink.dialWait)
+	}
+}
@@ -0,0 +1,220 @@
+// Copyright 2026 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gadget
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	apiTypes "github.com/inspektor-gadget/ig-desktop/pkg/api"
+)
+
+// Defaults of the webhook sink
+const (
+	DefaultWebhookBatchSize     = 100
+	DefaultWebhookFlushInterval = 1 // seconds
+	DefaultWebhookMaxRetries    = 3
+	DefaultWebhookTimeout       = 10 // seconds
+
+	webhookQueueSize = 10000
+)
+
+// webhookRetryBackoff is the wait before the first retry; it doubles with every retry
+var webhookRetryBackoff = time.Second
+
+// WebhookSinkConfig configures a sink that POSTs batches of events as JSON array to a URL
+type WebhookSinkConfig struct {
+	URL           string            `json:"url"`
+	Headers       map[string]string `json:"headers,omitempty"`
+	BatchSize     int               `json:"batchSize,omitempty"`
+	FlushInterval int64             `json:"flushInterval,omitempty"` // seconds
+	MaxRetries    int               `json:"maxRetries,omitempty"`    // negative disables retries
+	Timeout       int64             `json:"timeout,omitempty"`       // seconds, per request
+}
+
+type webhookSink struct {
+	cfg    WebhookSinkConfig
+	client *http.Client
+
+	// encode turns a batch into the request body sent with contentType
+	encode      func(batch []*SinkEvent) ([]byte, error)
+	contentType string
+
+	mu      sync.Mutex
+	closed  bool
+	queue   chan *SinkEvent
+	dropped int
+	done    chan struct{}
+}
+
+func newWebhookSink(cfg WebhookSinkConfig) (*webhookSink, error) {
+	u, err := url.Parse(cfg.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, &apiTypes.ErrInvalidRequest{Reason: fmt.Sprintf("invalid webhook URL %q", cfg.URL)}
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = DefaultWebhookBatchSize
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = DefaultWebhookFlushInterval
+	}
+	if cfg.MaxRetries < 0 {
+		cfg.MaxRetries = 0
+	} else if cfg.MaxRetries == 0 {
+		cfg.MaxRetries = DefaultWebhookMaxRetries
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultWebhookTimeout
+	}
+
+	s := &webhookSink{
+		cfg:         cfg,
+		client:      &http.Client{Timeout: time.Duration(cfg.Timeout) * time.Second},
+		encode:      func(batch []*SinkEvent) ([]byte, error) { return json.Marshal(batch) },
+		contentType: "application/json",
+		queue:       make(chan *SinkEvent, webhookQueueSize),
+		done:        make(chan struct{}),
+	}
+	go s.loop()
+	return s, nil
+}
+
+// Write queues the event; if the queue is full because the endpoint is too slow, the event is dropped
+func (s *webhookSink) Write(ev *SinkEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return errors.New("webhook sink closed")
+	}
+	select {
+	case s.queue <- ev:
+	default:
+		s.dropped++
+	}
+	return nil
+}
+
+func (s *webhookSink) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	close(s.queue)
+	dropped := s.dropped
+	s.mu.Unlock()
+
+	<-s.done
+	if dropped > 0 {
+		log.Printf("webhook sink %s: dropped %d events because the endpoint was too slow", s.cfg.URL, dropped)
+	}
+	return nil
+}
+
+func (s *webhookSink) loop() {
+	defer close(s.done)
+
+	ticker := time.NewTicker(time.Duration(s.cfg.FlushInterval) * time.Second)
+	defer ticker.Stop()
+
+	batch := make([]*SinkEvent, 0, s.cfg.BatchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := s.post(batch); err != nil {
+			log.Printf("webhook sink %s: dropping %d events: %v", s.cfg.URL, len(batch), err)
+		}
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case ev, ok := <-s.queue:
+			if !ok {
+				flush()
+				return
+			}
+			batch = append(batch, ev)
+			if len(batch) >= s.cfg.BatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+// post sends a batch, retrying with exponential backoff on network errors, 429 and 5xx responses
+func (s *webhookSink) post(batch []*SinkEvent) error {
+	body, err := s.encode(batch)
+	if err != nil {
+		return err
+	}
+
+	wait := webhookRetryBackoff
+	for attempt := 0; ; attempt++ {
+		err = s.send(body)
+		var retry *retryableError
+		if err == nil || !errors.As(err, &retry) || attempt >= s.cfg.MaxRetries {
+			return err
+		}
+		time.Sleep(wait)
+		wait *= 2
+	}
+}
+
+type retryableError struct {
+	err error
+}
+
+func (e *retryableError) Error() string {
+	return e.err.Error()
+}
+
+func (s *webhookSink) send(body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, s.cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", s.contentType)
+	for k, v := range s.cfg.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return &retryableError{err: err}
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	switch {
+	case resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return &retryableError{err: fmt.Errorf("unexpected status %s", resp.Status)}
+	}
+	return fmt.Errorf("unexpected status %s", resp.Status)
+}
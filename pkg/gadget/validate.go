@@ -0,0 +1,120 @@
+// Copyright 2026 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gadget
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-service/api"
+	apihelpers "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-service/api-helpers"
+
+	apiTypes "github.com/inspektor-gadget/ig-desktop/pkg/api"
+	grpcruntime "github.com/inspektor-gadget/ig-desktop/pkg/grpc-runtime"
+)
+
+// ValidateParams checks the given param values against the param descriptors the runtime reports
+// for the image. It returns one error per invalid param; an error is only returned if the
+// descriptors couldn't be fetched.
+//
+// Cached descriptors of images that aren't pinned to a digest may be outdated if the tag moved. If
+// validating against them fails, the descriptors are fetched again and the values revalidated.
+func (s *Service) ValidateParams(ctx context.Context, runtime *grpcruntime.Runtime, environmentID, image string, values map[string]string) ([]apiTypes.ParamError, error) {
+	info, fetched, err := s.cachedInfo(ctx, runtime, environmentID, image, false)
+	if err != nil {
+		return nil, fmt.Errorf("getting gadget info: %w", err)
+	}
+	paramErrors := validateParams(info.Params, values)
+	if len(paramErrors) == 0 || fetched || imageDigest(image) != "" {
+		return paramErrors, nil
+	}
+
+	info, fetched, err = s.cachedInfo(ctx, runtime, environmentID, image, true)
+	if err != nil || !fetched {
+		// Keep the result of the cached descriptors if the environment can't be reached
+		return paramErrors, nil
+	}
+	return validateParams(info.Params, values), nil
+}
+
+// validateRun validates the params of a run request. If the param descriptors can't be fetched,
+// the request isn't validated, leaving it to the run to fail if the params are wrong.
+func (s *Service) validateRun(ctx context.Context, runtime *grpcruntime.Runtime, req RunRequest) error {
+	paramErrors, err := s.ValidateParams(ctx, runtime, req.EnvironmentID, req.Image, req.Params)
+	if err != nil {
+		log.Printf("not validating params of %s: %v", req.Image, err)
+		return nil
+	}
+	if len(paramErrors) > 0 {
+		return &apiTypes.ErrInvalidParams{Errors: paramErrors}
+	}
+	return nil
+}
+
+// validateParams checks values against the given descriptors: required params must be set (or have a
+// default), values must be one of the possible values if there are any, and must match the type hint.
+func validateParams(descs api.Params, values map[string]string) []apiTypes.ParamError {
+	var res []apiTypes.ParamError
+	for _, p := range descs {
+		key := p.Prefix + p.Key
+		value, ok := values[key]
+		if !ok && p.AlternativeKey != "" {
+			value = values[p.Prefix+p.AlternativeKey]
+		}
+
+		if value == "" {
+			if p.IsMandatory && p.DefaultValue == "" {
+				res = append(res, apiTypes.ParamError{
+					Key:     key,
+					Reason:  apiTypes.ParamErrorRequired,
+					Message: "a value is required",
+				})
+			}
+			continue
+		}
+
+		if len(p.PossibleValues) > 0 && !slices.Contains(p.PossibleValues, value) {
+			res = append(res, apiTypes.ParamError{
+				Key:     key,
+				Value:   value,
+				Reason:  apiTypes.ParamErrorNotAllowed,
+				Message: fmt.Sprintf("valid values are: %s", strings.Join(p.PossibleValues, ", ")),
+			})
+			continue
+		}
+
+		// Possible values and mandatory params are handled above, leaving type hints and validators
+		desc := apihelpers.ParamToParamDesc(p)
+		desc.PossibleValues = nil
+		desc.IsMandatory = false
+		if err := desc.Validate(value); err != nil {
+			// Drop the "invalid value ... as ..." wrapping, the key and value are reported separately
+			if inner := errors.Unwrap(err); inner != nil {
+				err = inner
+			}
+			res = append(res, apiTypes.ParamError{
+				Key:     key,
+				Value:   value,
+				Reason:  apiTypes.ParamErrorInvalidValue,
+				Message: err.Error(),
+			})
+		}
+	}
+	return res
+}
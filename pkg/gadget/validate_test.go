@@ -0,0 +1,93 @@
+// Copyright 2026 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gadget
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-service/api"
+
+	apiTypes "github.com/inspektor-gadget/ig-desktop/pkg/api"
+)
+
+func TestValidateParams(t *testing.T) {
+	descs := api.Params{
+		{Prefix: "operator.oci.ebpf.", Key: "pid", TypeHint: "uint32"},
+		{Prefix: "operator.oci.ebpf.", Key: "mode", PossibleValues: []string{"a", "b"}},
+		{Prefix: "operator.oci.", Key: "target", IsMandatory: true},
+		{Prefix: "operator.oci.", Key: "verify", IsMandatory: true, DefaultValue: "true", TypeHint: "bool"},
+	}
+
+	errs := validateParams(descs, map[string]string{
+		"operator.oci.ebpf.pid":  "-1",
+		"operator.oci.ebpf.mode": "c",
+	})
+	reasons := map[string]string{}
+	for _, e := range errs {
+		reasons[e.Key] = e.Reason
+	}
+	expected := map[string]string{
+		"operator.oci.ebpf.pid":  apiTypes.ParamErrorInvalidValue,
+		"operator.oci.ebpf.mode": apiTypes.ParamErrorNotAllowed,
+		"operator.oci.target":    apiTypes.ParamErrorRequired,
+	}
+	if len(reasons) != len(expected) {
+		t.Fatalf("unexpected errors: %+v", errs)
+	}
+	for k, v := range expected {
+		if reasons[k] != v {
+			t.Errorf("%s: expected %q, got %q", k, v, reasons[k])
+		}
+	}
+
+	if errs := validateParams(descs, map[string]string{
+		"operator.oci.ebpf.pid":  "42",
+		"operator.oci.ebpf.mode": "b",
+		"operator.oci.target":    "x",
+	}); len(errs) != 0 {
+		t.Errorf("expected valid params, got %+v", errs)
+	}
+}
+
+func TestRunValidatesParams(t *testing.T) {
+	cache, err := NewInfoCache(t.TempDir(), time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := &api.GadgetInfo{
+		Params: api.Params{{Prefix: "operator.oci.", Key: "target", IsMandatory: true}},
+	}
+	if err := cache.Set("env", "trace_exec@sha256:abc", info); err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewService(NewInstanceManager())
+	s.SetInfoCache(cache)
+
+	// The cached info of the pinned image is used, so no runtime is needed to reject the request
+	id, err := s.Run(context.Background(), nil, RunRequest{Image: "trace_exec@sha256:abc", EnvironmentID: "env"})
+	var paramErr *apiTypes.ErrInvalidParams
+	if !errors.As(err, &paramErr) || id != "" {
+		t.Fatalf("expected param errors before the run starts, got %q, %v", id, err)
+	}
+
+	_, err = s.RunMulti(context.Background(), []EnvironmentRun{{EnvironmentID: "env"}}, RunRequest{Image: "trace_exec@sha256:abc"})
+	if !errors.As(err, &paramErr) {
+		t.Fatalf("expected param errors before the runs start, got %v", err)
+	}
+}
@@ -0,0 +1,174 @@
+// Copyright 2026 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpcruntime
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-service/api"
+)
+
+// Steps of a connectivity check
+const (
+	CheckStepLoadConfig  = "load-config"
+	CheckStepListPods    = "list-gadget-pods"
+	CheckStepPortForward = "port-forward"
+	CheckStepSSH         = "ssh"
+	CheckStepConnect     = "connect"
+)
+
+// CheckStep is the outcome of a single step of a connectivity check
+type CheckStep struct {
+	Step    string `json:"step"`
+	Target  string `json:"target,omitempty"` // pod or address
+	Node    string `json:"node,omitempty"`
+	Latency int64  `json:"latency"`           // ms
+	Version string `json:"version,omitempty"` // server version; only for connect steps
+	Error   string `json:"error,omitempty"`
+}
+
+// OK returns true if the step succeeded
+func (s *CheckStep) OK() bool {
+	return s.Error == ""
+}
+
+// timeStep runs fn and returns its outcome as step
+func timeStep(step string, t *target, fn func() error) *CheckStep {
+	res := &CheckStep{Step: step}
+	if t != nil {
+		res.Target = t.addressOrPod
+		res.Node = t.node
+	}
+	start := time.Now()
+	err := fn()
+	res.Latency = time.Since(start).Milliseconds()
+	if err != nil {
+		res.Error = err.Error()
+	}
+	return res
+}
+
+// Check walks the connection path of the initialized runtime and reports every step. In
+// Kubernetes mode, the gadget pods are listed and each of them is reached by a port-forward
+// first; in SSH mode, the daemon socket of each host is forwarded first. Then every target is
+// connected to and asked for its version. Every step is bounded by the connection timeout.
+// Steps of different targets run concurrently; the result is ordered by target.
+func (r *Runtime) Check(ctx context.Context) []*CheckStep {
+	var targets []target
+	var steps []*CheckStep
+
+	timeout := time.Second * time.Duration(r.globalParams.Get(ParamConnectionTimeout).AsUint16())
+
+	switch r.connectionMode {
+	case ConnectionModeKubernetesProxy:
+		step := timeStep(CheckStepListPods, nil, func() (err error) {
+			listCtx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			gadgetNamespace := r.globalParams.Get(ParamGadgetNamespace).AsString()
+			targets, err = getGadgetPods(listCtx, r.restConfig, nil, gadgetNamespace)
+			return err
+		})
+		steps = append(steps, step)
+		if !step.OK() {
+			return steps
+		}
+	default:
+		var err error
+		targets, err = r.getTargets(ctx, r.globalParams)
+		if err != nil {
+			return append(steps, &CheckStep{Step: CheckStepConnect, Error: err.Error()})
+		}
+		if len(targets) == 0 {
+			return append(steps, &CheckStep{Step: CheckStepConnect, Error: "no remote addresses configured"})
+		}
+	}
+
+	targetSteps := make([][]*CheckStep, len(targets))
+	var wg sync.WaitGroup
+	for i, t := range targets {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			targetSteps[i] = r.checkTarget(ctx, t, timeout)
+		}()
+	}
+	wg.Wait()
+
+	for _, s := range targetSteps {
+		steps = append(steps, s...)
+	}
+	return steps
+}
+
+// checkTarget connects to a single target and gets its version
+func (r *Runtime) checkTarget(ctx context.Context, t target, timeout time.Duration) []*CheckStep {
+	var steps []*CheckStep
+
+	if r.connectionMode == ConnectionModeKubernetesProxy {
+		// Check the port-forward on its own, so that failures of the API server and of the
+		// gadget service can be told apart
+		step := timeStep(CheckStepPortForward, &t, func() error {
+			port := r.globalParams.Get(ParamGadgetServiceTCPPort).AsUint16()
+			gadgetNamespace := r.globalParams.Get(ParamGadgetNamespace).AsString()
+			conn, err := NewK8SPortFwdConn(ctx, r.restConfig, gadgetNamespace, t, port, timeout)
+			if err != nil {
+				return err
+			}
+			return conn.Close()
+		})
+		steps = append(steps, step)
+		if !step.OK() {
+			return steps
+		}
+	}
+
+	if r.connectionMode == ConnectionModeSSH {
+		// Same for the SSH connection and the daemon socket
+		step := timeStep(CheckStepSSH, &t, func() error {
+			conn, err := r.newSSHConn(ctx, t, timeout)
+			if err != nil {
+				return err
+			}
+			return conn.Close()
+		})
+		steps = append(steps, step)
+		if !step.OK() {
+			return steps
+		}
+	}
+
+	var version string
+	step := timeStep(CheckStepConnect, &t, func() error {
+		conn, err := r.dialContext(ctx, t, timeout)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		infoCtx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		info, err := api.NewBuiltInGadgetManagerClient(conn).GetInfo(infoCtx, &api.InfoRequest{Version: "1.0"})
+		if err != nil {
+			return fmt.Errorf("getting server info: %w", err)
+		}
+		version = info.ServerVersion
+		return nil
+	})
+	step.Version = version
+	return append(steps, step)
+}
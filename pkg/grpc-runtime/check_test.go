@@ -0,0 +1,55 @@
+// Copyright 2026 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpcruntime
+
+import (
+	"context"
+	"net"
+	"testing"
+)
+
+func TestCheckDirect(t *testing.T) {
+	// Reserve a port and close it again, so that connecting to it fails
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+
+	rt := New()
+	params := rt.GlobalParamDescs().ToParams()
+	if err := params.Set(ParamRemoteAddress, "tcp://"+addr); err != nil {
+		t.Fatal(err)
+	}
+	if err := params.Set(ParamConnectionTimeout, "1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := rt.Init(params); err != nil {
+		t.Fatal(err)
+	}
+
+	steps := rt.Check(context.Background())
+	if len(steps) != 1 {
+		t.Fatalf("expected 1 step, got %d", len(steps))
+	}
+	step := steps[0]
+	if step.Step != CheckStepConnect || step.Target != addr || step.Node != "127.0.0.1" {
+		t.Errorf("unexpected step: %+v", step)
+	}
+	if step.OK() {
+		t.Error("expected connect step to fail")
+	}
+}
@@ -17,10 +17,13 @@ package grpcruntime
 import (
 	"context"
 	"crypto/tls"
+	"crypto/x509"
 	_ "embed"
+	"errors"
 	"fmt"
 	"net"
 	"net/url"
+	"os"
 	"strings"
 	"time"
 
@@ -48,6 +51,10 @@ const (
 	// up an appropriate target node using the kubernetes API, then using the port forward
 	// endpoint of the Kubernetes API to forward the gRPC connection to the service listener (see gadgettracermgr).
 	ConnectionModeKubernetesProxy
+
+	// ConnectionModeSSH will connect to the unix socket of the IG daemon on one or more hosts by forwarding it
+	// through SSH
+	ConnectionModeSSH
 )
 
 const (
@@ -55,6 +62,9 @@ const (
 	ParamRemoteAddress     = "remote-address"
 	ParamConnectionMethod  = "connection-method"
 	ParamConnectionTimeout = "connection-timeout"
+	ParamReconnectRetries  = "reconnect-max-retries"
+	ParamReconnectBackoff  = "reconnect-backoff"
+	ParamReconnectMaxWait  = "reconnect-max-backoff"
 	ParamID                = "id"
 	ParamDetach            = "detach"
 	ParamTags              = "tags"
@@ -66,6 +76,13 @@ const (
 	ParamTLSServerCA   = "tls-server-ca-file"
 	ParamTLSServerName = "tls-server-name"
 
+	ParamSSHHosts           = "ssh-hosts"
+	ParamSSHUser            = "ssh-user"
+	ParamSSHKeyFile         = "ssh-key-file"
+	ParamSSHKnownHostsFile  = "ssh-known-hosts-file"
+	ParamSSHInsecureHostKey = "ssh-insecure-ignore-host-key"
+	ParamSSHRemoteSocket    = "ssh-remote-socket"
+
 	// ParamGadgetServiceTCPPort is only used in combination with KubernetesProxyConnectionMethodTCP
 	ParamGadgetServiceTCPPort = "tcp-port"
 
@@ -77,6 +94,18 @@ const (
 	// after sending a Stop command
 	ResultTimeout = 30
 
+	// ReconnectRetries is the default number of times we try to re-establish a dropped
+	// stream to a target before giving up
+	ReconnectRetries = 5
+
+	// ReconnectBackoff is the default time in seconds we wait before the first reconnection
+	// attempt; the wait doubles with every failed attempt
+	ReconnectBackoff = 1
+
+	// ReconnectMaxBackoff is the default upper bound in seconds for the wait between
+	// reconnection attempts
+	ReconnectMaxBackoff = 30
+
 	ParamGadgetNamespace   string = "gadget-namespace"
 	DefaultGadgetNamespace string = "gadget"
 )
@@ -86,6 +115,10 @@ type Runtime struct {
 	globalParams   *params.Params
 	restConfig     *rest.Config
 	connectionMode ConnectionMode
+	pool           *connPool         // nil if connections aren't pooled
+	tlsData        map[string][]byte // PEM material by TLS param, see WithTLSData
+	sshKey         []byte            // private key, see WithSSHKeyData
+	ssh            sshClients        // only used in SSH mode
 }
 
 type RunClient interface {
@@ -123,6 +156,10 @@ func (r *Runtime) SetRestConfig(config *rest.Config) {
 }
 
 func (r *Runtime) Close() error {
+	if r.pool != nil {
+		r.pool.close()
+	}
+	r.ssh.close()
 	return nil
 }
 
@@ -178,7 +215,7 @@ func (r *Runtime) ParamDescs() params.ParamDescs {
 		},
 	}...)
 	switch r.connectionMode {
-	case ConnectionModeDirect:
+	case ConnectionModeDirect, ConnectionModeSSH:
 		return p
 	case ConnectionModeKubernetesProxy:
 		p.Add(params.ParamDescs{
@@ -201,6 +238,24 @@ func (r *Runtime) GlobalParamDescs() params.ParamDescs {
 			DefaultValue: fmt.Sprintf("%d", ConnectTimeout),
 			TypeHint:     params.TypeUint16,
 		},
+		{
+			Key:          ParamReconnectRetries,
+			Description:  "Maximum number of attempts to re-establish a dropped gadget stream; 0 disables reconnection",
+			DefaultValue: fmt.Sprintf("%d", ReconnectRetries),
+			TypeHint:     params.TypeUint16,
+		},
+		{
+			Key:          ParamReconnectBackoff,
+			Description:  "Time in seconds to wait before the first reconnection attempt; doubled after every failed attempt",
+			DefaultValue: fmt.Sprintf("%d", ReconnectBackoff),
+			TypeHint:     params.TypeUint16,
+		},
+		{
+			Key:          ParamReconnectMaxWait,
+			Description:  "Maximum time in seconds to wait between reconnection attempts",
+			DefaultValue: fmt.Sprintf("%d", ReconnectMaxBackoff),
+			TypeHint:     params.TypeUint16,
+		},
 	}
 	switch r.connectionMode {
 	case ConnectionModeDirect:
@@ -249,6 +304,42 @@ func (r *Runtime) GlobalParamDescs() params.ParamDescs {
 			},
 		}...)
 		return p
+	case ConnectionModeSSH:
+		p.Add(params.ParamDescs{
+			{
+				Key:         ParamSSHHosts,
+				Description: "Comma-separated list of SSH hosts ([user@]host[:port]) running the IG daemon",
+				Validator:   checkForDuplicates("host"),
+			},
+			{
+				Key:         ParamSSHUser,
+				Description: "User to log in as on hosts without a user; if omitted, using the current user",
+				TypeHint:    params.TypeString,
+			},
+			{
+				Key:         ParamSSHKeyFile,
+				Description: "Private key to authenticate with; if omitted, using the keys of the SSH agent",
+				TypeHint:    params.TypeString,
+			},
+			{
+				Key:         ParamSSHKnownHostsFile,
+				Description: "File with the known host keys (if omitted, using ~/.ssh/known_hosts)",
+				TypeHint:    params.TypeString,
+			},
+			{
+				Key:          ParamSSHInsecureHostKey,
+				Description:  "Don't verify the host keys",
+				DefaultValue: "false",
+				TypeHint:     params.TypeBool,
+			},
+			{
+				Key:          ParamSSHRemoteSocket,
+				Description:  "Socket of the IG daemon on the hosts",
+				DefaultValue: api.DefaultDaemonPath,
+				TypeHint:     params.TypeString,
+			},
+		}...)
+		return p
 	}
 	panic("invalid connection mode set for grpc-runtime")
 }
@@ -335,38 +426,91 @@ func (r *Runtime) getTargets(ctx context.Context, params *params.Params) ([]targ
 			targets = append(targets, tg)
 		}
 		return targets, nil
+	case ConnectionModeSSH:
+		return r.getSSHTargets()
 	}
 	return nil, fmt.Errorf("unsupported connection mode")
 }
 
-func (r *Runtime) getConnToRandomTarget(ctx context.Context, runtimeParams *params.Params) (*grpc.ClientConn, error) {
+// getConnToRandomTarget returns a connection to one of the targets. The returned function has to
+// be called once the connection isn't used anymore.
+func (r *Runtime) getConnToRandomTarget(ctx context.Context, runtimeParams *params.Params) (*grpc.ClientConn, func(), error) {
 	targets, err := r.getTargets(ctx, runtimeParams)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	if len(targets) == 0 {
-		return nil, fmt.Errorf("no valid targets")
+		return nil, nil, fmt.Errorf("no valid targets")
 	}
-	target := targets[0]
+	return r.getConnFromTarget(ctx, runtimeParams, targets[0])
+}
+
+// getConnFromTarget returns a connection to the given target, shared with other callers if
+// connections are pooled. The returned function has to be called once the connection isn't
+// used anymore.
+func (r *Runtime) getConnFromTarget(ctx context.Context, runtimeParams *params.Params, target target) (*grpc.ClientConn, func(), error) {
 	log.Debugf("using target %q (%q)", target.addressOrPod, target.node)
 
 	timeout := time.Second * time.Duration(r.globalParams.Get(ParamConnectionTimeout).AsUint16())
-	conn, err := r.dialContext(ctx, target, timeout)
+	dial := func() (*grpc.ClientConn, error) {
+		conn, err := r.dialContext(ctx, target, timeout)
+		if err != nil {
+			return nil, fmt.Errorf("dialing %q (%q): %w", target.addressOrPod, target.node, err)
+		}
+		return conn, nil
+	}
+
+	if r.pool != nil {
+		return r.pool.get(target, dial)
+	}
+	conn, err := dial()
 	if err != nil {
-		return nil, fmt.Errorf("dialing %q (%q): %w", target.addressOrPod, target.node, err)
+		return nil, nil, err
 	}
-	return conn, nil
+	return conn, func() { conn.Close() }, nil
 }
 
-func (r *Runtime) getConnFromTarget(ctx context.Context, runtimeParams *params.Params, target target) (*grpc.ClientConn, error) {
-	log.Debugf("using target %q (%q)", target.addressOrPod, target.node)
+// tlsMaterial returns the PEM material of a TLS param, either as provided by WithTLSData or
+// read from the file the param points to
+func (r *Runtime) tlsMaterial(param string) ([]byte, error) {
+	if data, ok := r.tlsData[param]; ok {
+		return data, nil
+	}
+	return os.ReadFile(r.globalParams.Get(param).String())
+}
 
-	timeout := time.Second * time.Duration(r.globalParams.Get(ParamConnectionTimeout).AsUint16())
-	conn, err := r.dialContext(ctx, target, timeout)
+func (r *Runtime) loadTLSCert() (tls.Certificate, error) {
+	if r.tlsData == nil {
+		return gadgettls.LoadTLSCert(r.globalParams.Get(ParamTLSCert).String(), r.globalParams.Get(ParamTLSKey).String())
+	}
+	cert, err := r.tlsMaterial(ParamTLSCert)
 	if err != nil {
-		return nil, fmt.Errorf("dialing %q (%q): %w", target.addressOrPod, target.node, err)
+		return tls.Certificate{}, fmt.Errorf("loading TLS certificate: %w", err)
 	}
-	return conn, nil
+	key, err := r.tlsMaterial(ParamTLSKey)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("loading TLS key: %w", err)
+	}
+	pair, err := tls.X509KeyPair(cert, key)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("loading TLS keypair: %w", err)
+	}
+	return pair, nil
+}
+
+func (r *Runtime) loadTLSCA() (*x509.CertPool, error) {
+	if r.tlsData == nil {
+		return gadgettls.LoadTLSCA(r.globalParams.Get(ParamTLSServerCA).String())
+	}
+	data, err := r.tlsMaterial(ParamTLSServerCA)
+	if err != nil {
+		return nil, fmt.Errorf("loading server CA certificate: %w", err)
+	}
+	ca := x509.NewCertPool()
+	if !ca.AppendCertsFromPEM(data) {
+		return nil, errors.New("parsing server CA certificate")
+	}
+	return ca, nil
 }
 
 func (r *Runtime) dialContext(dialCtx context.Context, target target, timeout time.Duration) (*grpc.ClientConn, error) {
@@ -401,12 +545,12 @@ All these options should be set at the same time to enable TLS connection`,
 	}
 
 	if tlsOptionsSet == 3 {
-		cert, err := gadgettls.LoadTLSCert(tlsCert, tlsKey)
+		cert, err := r.loadTLSCert()
 		if err != nil {
 			return nil, fmt.Errorf("creating TLS certificate: %w", err)
 		}
 
-		ca, err := gadgettls.LoadTLSCA(tlsCA)
+		ca, err := r.loadTLSCA()
 		if err != nil {
 			return nil, fmt.Errorf("creating TLS certificate authority: %w", err)
 		}
@@ -435,14 +579,22 @@ All these options should be set at the same time to enable TLS connection`,
 		opts = append(opts, grpc.WithTransportCredentials(insecure.NewCredentials()))
 	}
 
-	// If we're in Kubernetes connection mode, we need a custom dialer
-	if r.connectionMode == ConnectionModeKubernetesProxy {
+	// If we're in Kubernetes or SSH connection mode, we need a custom dialer
+	switch r.connectionMode {
+	case ConnectionModeKubernetesProxy:
 		opts = append(opts, grpc.WithContextDialer(func(ctx context.Context, s string) (net.Conn, error) {
 			port := r.globalParams.Get(ParamGadgetServiceTCPPort).AsUint16()
 			gadgetNamespace := r.globalParams.Get(ParamGadgetNamespace).AsString()
 			return NewK8SPortFwdConn(ctx, r.restConfig, gadgetNamespace, target, port, timeout)
 		}))
-	} else {
+	case ConnectionModeSSH:
+		opts = append(opts, grpc.WithContextDialer(func(ctx context.Context, s string) (net.Conn, error) {
+			return r.newSSHConn(ctx, target, timeout)
+		}))
+		newCtx, cancel := context.WithTimeout(dialCtx, timeout)
+		defer cancel()
+		dialCtx = newCtx
+	default:
 		newCtx, cancel := context.WithTimeout(dialCtx, timeout)
 		defer cancel()
 		dialCtx = newCtx
@@ -91,13 +91,14 @@ func (r *Runtime) runInstanceManagerClientForTargets(ctx context.Context, runtim
 		wg.Add(1)
 		go func(target target) {
 			defer wg.Done()
-			conn, err := r.getConnFromTarget(ctx, runtimeParams, target)
+			conn, release, err := r.getConnFromTarget(ctx, runtimeParams, target)
 			if err != nil {
 				merrMutex.Lock()
 				errs = append(errs, fmt.Errorf("connecting to target %q: %w", target.node, err))
 				merrMutex.Unlock()
 				return
 			}
+			defer release()
 			client := api.NewGadgetInstanceManagerClient(conn)
 			err = fn(target, client)
 			if err != nil {
@@ -46,6 +46,10 @@ type k8sPortFwdDialer struct {
 func NewK8SPortFwdConn(ctx context.Context, config *rest.Config, namespace string, pod target, targetPort uint16, timeout time.Duration) (net.Conn, error) {
 	conn := &k8sPortFwdDialer{}
 
+	// The config is shared by all connections of the runtime; don't modify it. The copy keeps
+	// impersonation and proxy settings, which the SPDY round tripper honours.
+	config = rest.CopyConfig(config)
+
 	// set GroupVersion and NegotiatedSerializer for RESTClient
 	factory.SetKubernetesDefaults(config)
 
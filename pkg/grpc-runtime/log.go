@@ -0,0 +1,45 @@
+// Copyright 2026 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpcruntime
+
+import (
+	"fmt"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/logger"
+)
+
+// Sources of node logs
+const (
+	LogSourceGadget  = "gadget"  // sent by the gadget running on the node
+	LogSourceRuntime = "runtime" // written by the runtime about its connection to the node
+)
+
+// NodeLog is a log message concerning a single node. It is passed as the only param to the
+// logger of the gadget context; loggers that know the type can keep node and source as separate
+// fields, all others print it using String.
+type NodeLog struct {
+	Node   string
+	Source string
+	Msg    string
+}
+
+func (l NodeLog) String() string {
+	return fmt.Sprintf("%-20s | %s", l.Node, l.Msg)
+}
+
+// logNode logs a message of the runtime concerning the given node
+func logNode(l logger.Logger, severity logger.Level, node string, format string, params ...any) {
+	l.Log(severity, NodeLog{Node: node, Source: LogSourceRuntime, Msg: fmt.Sprintf(format, params...)})
+}
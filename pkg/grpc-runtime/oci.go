@@ -36,17 +36,18 @@ func (r *Runtime) GetGadgetInfo(gadgetCtx runtime.GadgetContext, runtimeParams *
 		runtimeParams = r.ParamDescs().ToParams()
 	}
 
-	conn, err := r.getConnToRandomTarget(gadgetCtx.Context(), runtimeParams)
+	conn, release, err := r.getConnToRandomTarget(gadgetCtx.Context(), runtimeParams)
 	if err != nil {
 		return nil, fmt.Errorf("dialing random target: %w", err)
 	}
-	defer conn.Close()
+	defer release()
 	client := api.NewGadgetManagerClient(conn)
 
 	in := &api.GetGadgetInfoRequest{
-		ParamValues: paramValues,
-		ImageName:   gadgetCtx.ImageName(),
-		Version:     api.VersionGadgetInfo,
+		ParamValues:      paramValues,
+		ImageName:        gadgetCtx.ImageName(),
+		Version:          api.VersionGadgetInfo,
+		RequestExtraInfo: gadgetCtx.ExtraInfo(),
 	}
 
 	// specify that ImageName will contain a gadget instance ID
@@ -58,16 +59,25 @@ func (r *Runtime) GetGadgetInfo(gadgetCtx runtime.GadgetContext, runtimeParams *
 	if err != nil {
 		return nil, fmt.Errorf("getting gadget info: %w", err)
 	}
+	addTargetFields(out.GadgetInfo)
 
-	err = gadgetCtx.LoadGadgetInfo(out.GadgetInfo, paramValues, false, nil)
+	// Extra info, like the digest of the image, is only sent if requested
+	err = gadgetCtx.LoadGadgetInfo(out.GadgetInfo, paramValues, false, out.GadgetInfo.GetExtraInfo())
 	if err != nil {
 		return nil, fmt.Errorf("initializing local operators: %w", err)
 	}
 
-	return gadgetCtx.SerializeGadgetInfo(false)
+	return gadgetCtx.SerializeGadgetInfo(gadgetCtx.ExtraInfo())
 }
 
 func (r *Runtime) RunGadget(gadgetCtx runtime.GadgetContext, runtimeParams *params.Params, paramValues api.ParamValues) error {
+	_, err := r.RunGadgetWithResults(gadgetCtx, runtimeParams, paramValues)
+	return err
+}
+
+// RunGadgetWithResults runs the gadget like RunGadget and additionally returns the results the targets sent
+// when the gadget ended, keyed by node. Detached runs don't have results.
+func (r *Runtime) RunGadgetWithResults(gadgetCtx runtime.GadgetContext, runtimeParams *params.Params, paramValues api.ParamValues) (runtime.CombinedGadgetResult, error) {
 	if runtimeParams == nil {
 		runtimeParams = r.ParamDescs().ToParams()
 	}
@@ -78,18 +88,17 @@ func (r *Runtime) RunGadget(gadgetCtx runtime.GadgetContext, runtimeParams *para
 	}
 
 	if p := runtimeParams.Get(ParamDetach); p != nil && p.AsBool() {
-		return r.createGadgetInstance(gadgetCtx, runtimeParams, paramValues)
+		return nil, r.createGadgetInstance(gadgetCtx, runtimeParams, paramValues)
 	}
 
 	targets, err := r.getTargets(gadgetCtx.Context(), runtimeParams)
 	if err != nil {
-		return fmt.Errorf("getting target nodes: %w", err)
+		return nil, fmt.Errorf("getting target nodes: %w", err)
 	}
 
 	gadgetCtx.SetVar(runtime.NumRunTargets, len(targets))
 
-	_, err = r.runGadgetOnTargets(gadgetCtx, paramValues, targets)
-	return err
+	return r.runGadgetOnTargets(gadgetCtx, paramValues, targets)
 }
 
 func (r *Runtime) runGadgetOnTargets(
@@ -105,7 +114,7 @@ func (r *Runtime) runGadgetOnTargets(
 		wg.Add(1)
 		go func(target target) {
 			gadgetCtx.Logger().Debugf("running gadget on node %q", target.node)
-			res, err := r.runGadget(gadgetCtx, target, paramMap)
+			res, err := r.runGadgetWithReconnect(gadgetCtx, target, paramMap)
 			resultsLock.Lock()
 			results[target.node] = &runtime.GadgetResult{
 				Payload: res,
@@ -120,7 +129,9 @@ func (r *Runtime) runGadgetOnTargets(
 	return results, results.Err()
 }
 
-func (r *Runtime) runGadget(gadgetCtx runtime.GadgetContext, target target, allParams map[string]string) ([]byte, error) {
+// runGadget runs the gadget on a single target until either side ends the stream. onConnected is called once the
+// run or attach request has been sent successfully.
+func (r *Runtime) runGadget(gadgetCtx runtime.GadgetContext, target target, allParams map[string]string, onConnected func()) ([]byte, error) {
 	// Notice that we cannot use gadgetCtx.Context() here, as that would - when cancelled by the user - also cancel the
 	// underlying gRPC connection. That would then lead to results not being received anymore (mostly for profile
 	// gadgets.)
@@ -133,14 +144,14 @@ func (r *Runtime) runGadget(gadgetCtx runtime.GadgetContext, target target, allP
 
 	conn, err := r.dialContext(dialCtx, target, timeout)
 	if err != nil {
-		return nil, fmt.Errorf("dialing target on node %q: %w", target.node, err)
+		return nil, &connectionError{err: fmt.Errorf("dialing target on node %q: %w", target.node, err)}
 	}
 	defer conn.Close()
 	client := api.NewGadgetManagerClient(conn)
 
 	runClient, err := client.RunGadget(connCtx)
 	if err != nil && !errors.Is(err, context.Canceled) {
-		return nil, err
+		return nil, &connectionError{err: err}
 	}
 
 	var controlRequest *api.GadgetControlRequest
@@ -174,7 +185,10 @@ func (r *Runtime) runGadget(gadgetCtx runtime.GadgetContext, target target, allP
 
 	err = runClient.Send(controlRequest)
 	if err != nil {
-		return nil, err
+		return nil, &connectionError{err: err}
+	}
+	if onConnected != nil {
+		onConnected()
 	}
 
 	doneChan := make(chan error)
@@ -185,11 +199,12 @@ func (r *Runtime) runGadget(gadgetCtx runtime.GadgetContext, target target, allP
 	go func() {
 		dsMap := make(map[uint32]datasource.DataSource)
 		dsNameMap := make(map[string]uint32)
+		taggers := make(map[uint32]*targetTagger)
 		initialized := false
 		for {
 			ev, err := runClient.Recv()
 			if err != nil {
-				gadgetCtx.Logger().Debugf("%-20s | runClient returned with %v", target.node, err)
+				logNode(gadgetCtx.Logger(), logger.DebugLevel, target.node, "runClient returned with %v", err)
 				if !errors.Is(err, io.EOF) {
 					doneChan <- err
 					return
@@ -200,11 +215,11 @@ func (r *Runtime) runGadget(gadgetCtx runtime.GadgetContext, target target, allP
 			switch ev.Type {
 			case api.EventTypeGadgetPayload:
 				if !initialized {
-					gadgetCtx.Logger().Warnf("%-20s | received payload without being initialized", target.node)
+					logNode(gadgetCtx.Logger(), logger.WarnLevel, target.node, "received payload without being initialized")
 					continue
 				}
 				if expectedSeq != ev.Seq {
-					gadgetCtx.Logger().Warnf("%-20s | expected seq %d, got %d, %d messages dropped", target.node, expectedSeq, ev.Seq, ev.Seq-expectedSeq)
+					logNode(gadgetCtx.Logger(), logger.WarnLevel, target.node, "expected seq %d, got %d, %d messages dropped", expectedSeq, ev.Seq, ev.Seq-expectedSeq)
 				}
 				expectedSeq = ev.Seq + 1
 				if ds, ok := dsMap[ev.DataSourceID]; ok && ds != nil {
@@ -222,10 +237,11 @@ func (r *Runtime) runGadget(gadgetCtx runtime.GadgetContext, target target, allP
 						gadgetCtx.Logger().Debugf("error unmarshaling payload: %v", err)
 						continue
 					}
+					taggers[ev.DataSourceID].tag(p)
 					ds.EmitAndRelease(p)
 				}
 			case api.EventTypeGadgetResult:
-				gadgetCtx.Logger().Debugf("%-20s | got result from server", target.node)
+				logNode(gadgetCtx.Logger(), logger.DebugLevel, target.node, "got result from server")
 				result = ev.Payload
 			case api.EventTypeGadgetJobID: // not needed right now
 			case api.EventTypeGadgetInfo:
@@ -238,6 +254,7 @@ func (r *Runtime) runGadget(gadgetCtx runtime.GadgetContext, target target, allP
 				for _, ds := range gi.DataSources {
 					dsNameMap[ds.Name] = ds.Id
 				}
+				payloads := addTargetFields(gi)
 
 				// Try to load gadget info; if gadget info has already been loaded and this one
 				// doesn't match, this will terminate this particular client session
@@ -251,6 +268,7 @@ func (r *Runtime) runGadget(gadgetCtx runtime.GadgetContext, target target, allP
 					gadgetCtx.Logger().Debugf("registered ds %s", ds.Name())
 					if dsId, ok := dsNameMap[ds.Name()]; ok {
 						dsMap[dsId] = ds
+						taggers[dsId] = newTargetTagger(ds, target, payloads[dsId])
 					} else {
 						gadgetCtx.Logger().Debugf("datasource %s not found in gadget info", ds.Name())
 					}
@@ -258,7 +276,7 @@ func (r *Runtime) runGadget(gadgetCtx runtime.GadgetContext, target target, allP
 				initialized = true
 			default:
 				if ev.Type >= 1<<api.EventLogShift {
-					gadgetCtx.Logger().Log(logger.Level(ev.Type>>api.EventLogShift), fmt.Sprintf("%-20s | %s", target.node, string(ev.Payload)))
+					gadgetCtx.Logger().Log(logger.Level(ev.Type>>api.EventLogShift), NodeLog{Node: target.node, Source: LogSourceGadget, Msg: string(ev.Payload)})
 					continue
 				}
 				gadgetCtx.Logger().Warnf("unknown payload type %d: %s", ev.Type, ev.Payload)
@@ -269,19 +287,19 @@ func (r *Runtime) runGadget(gadgetCtx runtime.GadgetContext, target target, allP
 	var runErr error
 	select {
 	case doneErr := <-doneChan:
-		gadgetCtx.Logger().Debugf("%-20s | done from server side (%v)", target.node, doneErr)
+		logNode(gadgetCtx.Logger(), logger.DebugLevel, target.node, "done from server side (%v)", doneErr)
 		runErr = doneErr
 	case <-gadgetCtx.Context().Done():
 		if interactive {
 			// Send stop request
-			gadgetCtx.Logger().Debugf("%-20s | sending stop request", target.node)
+			logNode(gadgetCtx.Logger(), logger.DebugLevel, target.node, "sending stop request")
 			controlRequest := &api.GadgetControlRequest{Event: &api.GadgetControlRequest_StopRequest{StopRequest: &api.GadgetStopRequest{}}}
 			runClient.Send(controlRequest)
 
 			// Wait for done or timeout
 			select {
 			case doneErr := <-doneChan:
-				gadgetCtx.Logger().Debugf("%-20s | done after cancel request (%v)", target.node, doneErr)
+				logNode(gadgetCtx.Logger(), logger.DebugLevel, target.node, "done after cancel request (%v)", doneErr)
 				runErr = doneErr
 			case <-time.After(ResultTimeout * time.Second):
 				return nil, fmt.Errorf("timed out while getting result")
@@ -14,8 +14,44 @@
 
 package grpcruntime
 
+import "time"
+
 type Option func(runtime *Runtime)
 
 func WithConnectUsingK8SProxy(runtime *Runtime) {
 	runtime.connectionMode = ConnectionModeKubernetesProxy
 }
+
+// WithConnectUsingSSH connects to the IG daemon on the hosts given by ParamSSHHosts through SSH
+func WithConnectUsingSSH(runtime *Runtime) {
+	runtime.connectionMode = ConnectionModeSSH
+}
+
+// WithConnectionPool shares connections to the targets between calls like getting gadget info or
+// listing instances; connections unused for idleTimeout are closed. Close has to be called to
+// release the pool.
+func WithConnectionPool(idleTimeout time.Duration) Option {
+	return func(runtime *Runtime) {
+		runtime.pool = newConnPool(idleTimeout)
+	}
+}
+
+// WithTLSData provides the PEM material for one of the TLS params (ParamTLSKey, ParamTLSCert or
+// ParamTLSServerCA); the value of the param is then only used to tell that TLS is enabled
+// instead of as path to read the material from.
+func WithTLSData(param string, data []byte) Option {
+	return func(runtime *Runtime) {
+		if runtime.tlsData == nil {
+			runtime.tlsData = map[string][]byte{}
+		}
+		runtime.tlsData[param] = data
+	}
+}
+
+// WithSSHKeyData provides the private key to authenticate with in SSH mode; the value of
+// ParamSSHKeyFile is then not used as path to read the key from.
+func WithSSHKeyData(data []byte) Option {
+	return func(runtime *Runtime) {
+		runtime.sshKey = data
+	}
+}
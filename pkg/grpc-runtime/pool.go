@@ -0,0 +1,173 @@
+// Copyright 2026 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpcruntime
+
+import (
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+)
+
+// DefaultConnIdleTimeout is the time after which unused pooled connections are closed
+const DefaultConnIdleTimeout = 5 * time.Minute
+
+// connPool shares gRPC connections to the targets of a runtime between short-lived calls like
+// getting gadget info or listing instances. Gadget runs keep dialing their own connections, as
+// their streams are reconnected individually.
+type connPool struct {
+	idleTimeout time.Duration
+
+	mu     sync.Mutex
+	conns  map[string]*pooledConn
+	done   chan struct{}
+	closed bool
+}
+
+type pooledConn struct {
+	conn     *grpc.ClientConn
+	refs     int
+	lastUsed time.Time
+}
+
+func newConnPool(idleTimeout time.Duration) *connPool {
+	if idleTimeout <= 0 {
+		idleTimeout = DefaultConnIdleTimeout
+	}
+	p := &connPool{
+		idleTimeout: idleTimeout,
+		conns:       make(map[string]*pooledConn),
+		done:        make(chan struct{}),
+	}
+	go p.evictLoop()
+	return p
+}
+
+// healthy returns false for connections that won't recover on their own, e.g. because the pod
+// behind a port-forward is gone
+func healthy(conn *grpc.ClientConn) bool {
+	switch conn.GetState() {
+	case connectivity.TransientFailure, connectivity.Shutdown:
+		return false
+	}
+	return true
+}
+
+// get returns the pooled connection to the target or dials a new one. The returned function has
+// to be called once the connection isn't used anymore.
+func (p *connPool) get(t target, dial func() (*grpc.ClientConn, error)) (*grpc.ClientConn, func(), error) {
+	key := t.addressOrPod
+
+	p.mu.Lock()
+	pc, ok := p.conns[key]
+	if ok && !healthy(pc.conn) {
+		log.Debugf("dropping unhealthy connection to %q (%q)", t.addressOrPod, t.node)
+		delete(p.conns, key)
+		if pc.refs == 0 {
+			pc.conn.Close()
+		}
+		ok = false
+	}
+	if ok {
+		pc.refs++
+		p.mu.Unlock()
+		return pc.conn, p.releaseFunc(key, pc), nil
+	}
+	p.mu.Unlock()
+
+	// Dial without holding the lock; concurrent callers might dial the same target, in which
+	// case only the first connection is pooled
+	conn, err := dial()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return conn, func() { conn.Close() }, nil
+	}
+	if existing, ok := p.conns[key]; ok {
+		existing.refs++
+		conn.Close()
+		return existing.conn, p.releaseFunc(key, existing), nil
+	}
+	pc = &pooledConn{conn: conn, refs: 1}
+	p.conns[key] = pc
+	return conn, p.releaseFunc(key, pc), nil
+}
+
+func (p *connPool) releaseFunc(key string, pc *pooledConn) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			p.mu.Lock()
+			defer p.mu.Unlock()
+			pc.refs--
+			pc.lastUsed = time.Now()
+			// Connections dropped from the pool while in use are closed by their last user
+			if pc.refs == 0 && p.conns[key] != pc {
+				pc.conn.Close()
+			}
+		})
+	}
+}
+
+// evictLoop closes connections that have been idle for too long or became unhealthy
+func (p *connPool) evictLoop() {
+	ticker := time.NewTicker(p.idleTimeout / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.done:
+			return
+		case <-ticker.C:
+			p.evict(time.Now())
+		}
+	}
+}
+
+func (p *connPool) evict(now time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for key, pc := range p.conns {
+		if pc.refs > 0 {
+			continue
+		}
+		if now.Sub(pc.lastUsed) >= p.idleTimeout || !healthy(pc.conn) {
+			pc.conn.Close()
+			delete(p.conns, key)
+		}
+	}
+}
+
+// close closes all connections that aren't in use; the others are closed once released
+func (p *connPool) close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return
+	}
+	p.closed = true
+	close(p.done)
+	for key, pc := range p.conns {
+		if pc.refs == 0 {
+			pc.conn.Close()
+		}
+		delete(p.conns, key)
+	}
+}
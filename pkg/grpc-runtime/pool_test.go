@@ -0,0 +1,72 @@
+// Copyright 2026 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpcruntime
+
+import (
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+func TestConnPool(t *testing.T) {
+	p := newConnPool(time.Minute)
+	defer p.close()
+
+	dials := 0
+	dial := func() (*grpc.ClientConn, error) {
+		dials++
+		// Connects lazily, so nothing has to listen on the address
+		return grpc.NewClient("passthrough:///127.0.0.1:1", grpc.WithTransportCredentials(insecure.NewCredentials()))
+	}
+	tg := target{addressOrPod: "127.0.0.1:1", node: "local"}
+
+	conn1, release1, err := p.get(tg, dial)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn2, release2, err := p.get(tg, dial)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if conn1 != conn2 || dials != 1 {
+		t.Fatalf("expected a shared connection, got %d dials", dials)
+	}
+
+	// Connections in use are never evicted
+	release1()
+	release1()
+	p.evict(time.Now().Add(time.Hour))
+	if conn1.GetState() == connectivity.Shutdown {
+		t.Fatal("connection in use was closed")
+	}
+
+	release2()
+	p.evict(time.Now().Add(time.Hour))
+	if conn1.GetState() != connectivity.Shutdown {
+		t.Fatal("idle connection wasn't closed")
+	}
+
+	conn3, release3, err := p.get(tg, dial)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer release3()
+	if conn3 == conn1 || dials != 2 {
+		t.Fatal("expected a new connection after eviction")
+	}
+}
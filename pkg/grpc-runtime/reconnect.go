@@ -0,0 +1,150 @@
+// Copyright 2026 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpcruntime
+
+import (
+	"errors"
+	"io"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/logger"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/runtime"
+)
+
+// VarReconnectHandler is the name of the gadget context variable that can hold a ReconnectHandler. It is called
+// whenever the stream to a target has been re-established after a drop.
+const VarReconnectHandler = "grpcruntime.reconnect-handler"
+
+// ReconnectHandler is notified after the stream to the given node has been re-established; missing is the time
+// during which no events could be received from that node.
+type ReconnectHandler func(node string, missing time.Duration)
+
+// ReconnectPolicy describes how interactive runs recover from dropped streams to their targets
+type ReconnectPolicy struct {
+	// MaxRetries is the number of consecutive reconnection attempts per target; 0 disables reconnection
+	MaxRetries int
+	// Backoff is the wait before the first attempt; it doubles with every failed attempt
+	Backoff time.Duration
+	// MaxBackoff caps the wait between two attempts
+	MaxBackoff time.Duration
+}
+
+// Wait returns the time to wait before the given (zero-based) reconnection attempt
+func (p ReconnectPolicy) Wait(attempt int) time.Duration {
+	wait := p.Backoff
+	for i := 0; i < attempt; i++ {
+		if p.MaxBackoff > 0 && wait*2 >= p.MaxBackoff {
+			return p.MaxBackoff
+		}
+		wait *= 2
+	}
+	return wait
+}
+
+// ReconnectPolicy returns the reconnect policy configured by the global params of the runtime
+func (r *Runtime) ReconnectPolicy() ReconnectPolicy {
+	return ReconnectPolicy{
+		MaxRetries: int(r.globalParams.Get(ParamReconnectRetries).AsUint16()),
+		Backoff:    time.Second * time.Duration(r.globalParams.Get(ParamReconnectBackoff).AsUint16()),
+		MaxBackoff: time.Second * time.Duration(r.globalParams.Get(ParamReconnectMaxWait).AsUint16()),
+	}
+}
+
+// connectionError marks errors that happened while establishing the stream to a target, as opposed to errors
+// reported by the gadget itself
+type connectionError struct {
+	err error
+}
+
+func (e *connectionError) Error() string {
+	return e.err.Error()
+}
+
+func (e *connectionError) Unwrap() error {
+	return e.err
+}
+
+// isStreamDropped returns true if err indicates a broken connection to the target that is worth retrying
+func isStreamDropped(err error) bool {
+	var connErr *connectionError
+	if errors.As(err, &connErr) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	return status.Code(err) == codes.Unavailable
+}
+
+// runGadgetWithReconnect runs the gadget on the given target and re-issues the run (or attach) request according
+// to the reconnect policy if the stream drops after it has been established once. The gadget context - and with
+// it the local instance - is kept across reconnections.
+func (r *Runtime) runGadgetWithReconnect(gadgetCtx runtime.GadgetContext, target target, paramMap map[string]string) ([]byte, error) {
+	return reconnectLoop(gadgetCtx, target.node, r.ReconnectPolicy(), func(onConnected func()) ([]byte, error) {
+		return r.runGadget(gadgetCtx, target, paramMap, onConnected)
+	})
+}
+
+// reconnectLoop calls run until it succeeds, fails for another reason than a dropped stream or the policy gives
+// up; run has to call onConnected once the stream to node is established.
+func reconnectLoop(gadgetCtx runtime.GadgetContext, node string, policy ReconnectPolicy, run func(onConnected func()) ([]byte, error)) ([]byte, error) {
+	var lostAt time.Time
+	connected := false
+	attempt := 0
+
+	onConnected := func() {
+		connected = true
+		if lostAt.IsZero() {
+			return
+		}
+		missing := time.Since(lostAt)
+		lostAt = time.Time{}
+		attempt = 0
+
+		logNode(gadgetCtx.Logger(), logger.WarnLevel, node, "reconnected, %d seconds missing", int(missing.Seconds()))
+		if h, ok := gadgetCtx.GetVar(VarReconnectHandler); ok {
+			if handler, ok := h.(ReconnectHandler); ok {
+				handler(node, missing)
+			}
+		}
+	}
+
+	for {
+		res, err := run(onConnected)
+		if err == nil || !connected || gadgetCtx.Context().Err() != nil || !isStreamDropped(err) {
+			return res, err
+		}
+		if attempt >= policy.MaxRetries {
+			if policy.MaxRetries > 0 {
+				logNode(gadgetCtx.Logger(), logger.ErrorLevel, node, "giving up after %d reconnection attempts", attempt)
+			}
+			return res, err
+		}
+		if lostAt.IsZero() {
+			lostAt = time.Now()
+		}
+
+		wait := policy.Wait(attempt)
+		attempt++
+		logNode(gadgetCtx.Logger(), logger.WarnLevel, node, "connection lost (%v), reconnecting in %s (attempt %d/%d)",
+			err, wait, attempt, policy.MaxRetries)
+
+		select {
+		case <-gadgetCtx.Context().Done():
+			return res, err
+		case <-time.After(wait):
+		}
+	}
+}
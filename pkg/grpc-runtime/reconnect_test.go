@@ -0,0 +1,178 @@
+// Copyright 2026 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpcruntime
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	gadgetcontext "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-context"
+)
+
+func TestReconnectPolicyWait(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  ReconnectPolicy
+		attempt int
+		want    time.Duration
+	}{
+		{"first attempt", ReconnectPolicy{Backoff: time.Second, MaxBackoff: 10 * time.Second}, 0, time.Second},
+		{"doubles", ReconnectPolicy{Backoff: time.Second, MaxBackoff: 10 * time.Second}, 3, 8 * time.Second},
+		{"capped", ReconnectPolicy{Backoff: time.Second, MaxBackoff: 10 * time.Second}, 4, 10 * time.Second},
+		{"stays capped", ReconnectPolicy{Backoff: time.Second, MaxBackoff: 10 * time.Second}, 50, 10 * time.Second},
+		{"no cap", ReconnectPolicy{Backoff: time.Second}, 5, 32 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.Wait(tt.attempt); got != tt.want {
+				t.Errorf("Wait(%d) = %s, want %s", tt.attempt, got, tt.want)
+			}
+		})
+	}
+}
+
+// step is the outcome of one run in TestReconnectLoop
+type step struct {
+	connect bool
+	err     error
+}
+
+func TestReconnectLoop(t *testing.T) {
+	dropped := status.Error(codes.Unavailable, "connection lost")
+	refused := &connectionError{err: errors.New("connection refused")}
+	failed := errors.New("gadget failed")
+
+	tests := []struct {
+		name       string
+		maxRetries int
+		steps      []step
+		wantErr    error
+		reconnects int
+	}{
+		{
+			name:       "error before the first connection",
+			maxRetries: 3,
+			steps:      []step{{err: refused}},
+			wantErr:    refused,
+		},
+		{
+			name:       "gadget error",
+			maxRetries: 3,
+			steps:      []step{{connect: true, err: failed}},
+			wantErr:    failed,
+		},
+		{
+			name:       "reconnection disabled",
+			maxRetries: 0,
+			steps:      []step{{connect: true, err: dropped}},
+			wantErr:    dropped,
+		},
+		{
+			name:       "reconnects",
+			maxRetries: 3,
+			steps:      []step{{connect: true, err: dropped}, {err: refused}, {connect: true}},
+			reconnects: 1,
+		},
+		{
+			name:       "gives up",
+			maxRetries: 2,
+			steps:      []step{{connect: true, err: dropped}, {err: refused}, {err: refused}},
+			wantErr:    refused,
+		},
+		{
+			// Without the reset, the fourth attempt would exceed the retries
+			name:       "resets attempts after reconnecting",
+			maxRetries: 2,
+			steps: []step{
+				{connect: true, err: dropped}, {err: refused},
+				{connect: true, err: dropped}, {err: refused},
+				{connect: true},
+			},
+			reconnects: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gadgetCtx := gadgetcontext.New(context.Background(), "test")
+			reconnects := 0
+			gadgetCtx.SetVar(VarReconnectHandler, ReconnectHandler(func(node string, missing time.Duration) {
+				if node != "node1" {
+					t.Errorf("reconnect handler called for node %q", node)
+				}
+				reconnects++
+			}))
+
+			calls := 0
+			policy := ReconnectPolicy{MaxRetries: tt.maxRetries, Backoff: time.Millisecond}
+			_, err := reconnectLoop(gadgetCtx, "node1", policy, func(onConnected func()) ([]byte, error) {
+				if calls >= len(tt.steps) {
+					t.Fatalf("run called %d times, want %d", calls+1, len(tt.steps))
+				}
+				s := tt.steps[calls]
+				calls++
+				if s.connect {
+					onConnected()
+				}
+				return nil, s.err
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("reconnectLoop() error = %v, want %v", err, tt.wantErr)
+			}
+			if calls != len(tt.steps) {
+				t.Errorf("run called %d times, want %d", calls, len(tt.steps))
+			}
+			if reconnects != tt.reconnects {
+				t.Errorf("reconnect handler called %d times, want %d", reconnects, tt.reconnects)
+			}
+		})
+	}
+}
+
+func TestReconnectLoopCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	gadgetCtx := gadgetcontext.New(ctx, "test")
+
+	calls := 0
+	policy := ReconnectPolicy{MaxRetries: 3, Backoff: time.Hour}
+	done := make(chan error)
+	go func() {
+		_, err := reconnectLoop(gadgetCtx, "node1", policy, func(onConnected func()) ([]byte, error) {
+			calls++
+			onConnected()
+			return nil, status.Error(codes.Unavailable, "connection lost")
+		})
+		done <- err
+	}()
+
+	// The loop waits for the backoff; cancelling stops it
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+	select {
+	case err := <-done:
+		if status.Code(err) != codes.Unavailable {
+			t.Errorf("reconnectLoop() error = %v, want the dropped stream", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("reconnectLoop() didn't return after cancelling")
+	}
+	if calls != 1 {
+		t.Errorf("run called %d times, want 1", calls)
+	}
+}
@@ -0,0 +1,288 @@
+// Copyright 2026 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpcruntime
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// sshClients keeps one SSH connection per host; every gRPC connection to a host is forwarded
+// through its own channel of the shared SSH connection. SSH connections count the connections
+// forwarded through them, so that closing the runtime doesn't cut off runs that still use them.
+type sshClients struct {
+	mu      sync.Mutex
+	clients map[string]*sshClient
+	closed  bool
+}
+
+type sshClient struct {
+	*ssh.Client
+	refs int
+}
+
+// sshConn is a connection forwarded through a shared SSH connection
+type sshConn struct {
+	net.Conn
+	release func()
+}
+
+func (c *sshConn) Close() error {
+	err := c.Conn.Close()
+	c.release()
+	return err
+}
+
+// parseSSHHost splits "[user@]host[:port]" into user and address, using defaultUser and port 22
+// if they're omitted
+func parseSSHHost(s string, defaultUser string) (string, string, error) {
+	sshUser := defaultUser
+	if i := strings.LastIndex(s, "@"); i >= 0 {
+		sshUser, s = s[:i], s[i+1:]
+	}
+	if s == "" || sshUser == "" {
+		return "", "", fmt.Errorf("expected [user@]host[:port]")
+	}
+	if _, _, err := net.SplitHostPort(s); err != nil {
+		s = net.JoinHostPort(strings.Trim(s, "[]"), "22")
+	}
+	return sshUser, s, nil
+}
+
+// getSSHTargets returns a target per configured SSH host
+func (r *Runtime) getSSHTargets() ([]target, error) {
+	defaultUser := r.globalParams.Get(ParamSSHUser).AsString()
+	if defaultUser == "" {
+		if u, err := user.Current(); err == nil {
+			defaultUser = u.Username
+		}
+	}
+
+	var targets []target
+	for _, h := range r.globalParams.Get(ParamSSHHosts).AsStringSlice() {
+		if h == "" {
+			continue
+		}
+		sshUser, addr, err := parseSSHHost(h, defaultUser)
+		if err != nil {
+			return nil, fmt.Errorf("invalid SSH host %q: %w", h, err)
+		}
+		host, _, _ := net.SplitHostPort(addr)
+		targets = append(targets, target{
+			addressOrPod: sshUser + "@" + addr,
+			node:         host,
+		})
+	}
+	return targets, nil
+}
+
+// sshConfig returns the client config for the given user, authenticating with the key given by
+// WithSSHKeyData or ParamSSHKeyFile or, if none is set, with the keys of the SSH agent. The returned function releases the agent.
+func (r *Runtime) sshConfig(sshUser string, timeout time.Duration) (*ssh.ClientConfig, func(), error) {
+	config := &ssh.ClientConfig{
+		User:    sshUser,
+		Timeout: timeout,
+	}
+	release := func() {}
+
+	key := r.sshKey
+	if keyFile := r.globalParams.Get(ParamSSHKeyFile).AsString(); key == nil && keyFile != "" {
+		var err error
+		key, err = os.ReadFile(keyFile)
+		if err != nil {
+			return nil, nil, fmt.Errorf("reading SSH key: %w", err)
+		}
+	}
+	if key != nil {
+		signer, err := ssh.ParsePrivateKey(key)
+		if err != nil {
+			return nil, nil, fmt.Errorf("parsing SSH key: %w", err)
+		}
+		config.Auth = []ssh.AuthMethod{ssh.PublicKeys(signer)}
+	} else {
+		sock := os.Getenv("SSH_AUTH_SOCK")
+		if sock == "" {
+			return nil, nil, fmt.Errorf("neither %s nor SSH_AUTH_SOCK is set", ParamSSHKeyFile)
+		}
+		agentConn, err := net.DialTimeout("unix", sock, timeout)
+		if err != nil {
+			return nil, nil, fmt.Errorf("connecting to SSH agent: %w", err)
+		}
+		config.Auth = []ssh.AuthMethod{ssh.PublicKeysCallback(agent.NewClient(agentConn).Signers)}
+		release = func() { agentConn.Close() }
+	}
+
+	if r.globalParams.Get(ParamSSHInsecureHostKey).AsBool() {
+		//nolint:gosec
+		config.HostKeyCallback = ssh.InsecureIgnoreHostKey()
+		return config, release, nil
+	}
+	knownHostsFile := r.globalParams.Get(ParamSSHKnownHostsFile).AsString()
+	if knownHostsFile == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			release()
+			return nil, nil, fmt.Errorf("finding known hosts: %w", err)
+		}
+		knownHostsFile = filepath.Join(home, ".ssh", "known_hosts")
+	}
+	callback, err := knownhosts.New(knownHostsFile)
+	if err != nil {
+		release()
+		return nil, nil, fmt.Errorf("loading known hosts: %w", err)
+	}
+	config.HostKeyCallback = callback
+	return config, release, nil
+}
+
+// sshClient returns the SSH connection to a target, connecting if there isn't one yet. The
+// returned function has to be called once the connection isn't used anymore.
+func (r *Runtime) sshClient(ctx context.Context, t target, timeout time.Duration) (*sshClient, func(), error) {
+	key := t.addressOrPod
+
+	r.ssh.mu.Lock()
+	client, ok := r.ssh.clients[key]
+	if ok {
+		client.refs++
+		r.ssh.mu.Unlock()
+		return client, r.ssh.releaseFunc(key, client), nil
+	}
+	r.ssh.mu.Unlock()
+
+	// Connect without holding the lock, so that hosts are connected to concurrently; if another
+	// caller connected to the same host in the meantime, its connection is used instead
+	sshUser, addr, _ := strings.Cut(key, "@")
+	config, release, err := r.sshConfig(sshUser, timeout)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer release()
+
+	dialer := &net.Dialer{Timeout: timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, nil, fmt.Errorf("connecting to %s: %w", addr, err)
+	}
+	// The handshake doesn't take a context, so limit it using a deadline
+	conn.SetDeadline(time.Now().Add(timeout))
+	c, chans, reqs, err := ssh.NewClientConn(conn, addr, config)
+	if err != nil {
+		conn.Close()
+		return nil, nil, fmt.Errorf("SSH handshake with %s: %w", addr, err)
+	}
+	conn.SetDeadline(time.Time{})
+	client = &sshClient{Client: ssh.NewClient(c, chans, reqs), refs: 1}
+
+	r.ssh.mu.Lock()
+	defer r.ssh.mu.Unlock()
+	if existing, ok := r.ssh.clients[key]; ok {
+		client.Close()
+		existing.refs++
+		return existing, r.ssh.releaseFunc(key, existing), nil
+	}
+	if r.ssh.closed {
+		// Runs of a closed runtime reconnecting; the connection isn't shared and is closed
+		// once released
+		return client, r.ssh.releaseFunc(key, client), nil
+	}
+	if r.ssh.clients == nil {
+		r.ssh.clients = make(map[string]*sshClient)
+	}
+	r.ssh.clients[key] = client
+	go func() {
+		err := client.Wait()
+		log.Debugf("SSH connection to %q closed: %v", key, err)
+		r.ssh.drop(key, client)
+	}()
+	return client, r.ssh.releaseFunc(key, client), nil
+}
+
+func (s *sshClients) releaseFunc(key string, client *sshClient) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			client.refs--
+			// Connections dropped while in use are closed by their last user
+			if client.refs == 0 && s.clients[key] != client {
+				client.Close()
+			}
+		})
+	}
+}
+
+// drop removes a connection that is gone from the cache
+func (s *sshClients) drop(key string, client *sshClient) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.clients[key] == client {
+		delete(s.clients, key)
+	}
+}
+
+// newSSHConn opens a connection to the IG daemon socket of the target through SSH
+func (r *Runtime) newSSHConn(ctx context.Context, t target, timeout time.Duration) (net.Conn, error) {
+	socket := strings.TrimPrefix(r.globalParams.Get(ParamSSHRemoteSocket).AsString(), "unix://")
+
+	client, release, err := r.sshClient(ctx, t, timeout)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := client.Dial("unix", socket)
+	var openErr *ssh.OpenChannelError
+	if err != nil && !errors.As(err, &openErr) {
+		// The SSH connection went away without being noticed yet; retry once on a new one
+		client.Close()
+		r.ssh.drop(t.addressOrPod, client)
+		release()
+		client, release, err = r.sshClient(ctx, t, timeout)
+		if err != nil {
+			return nil, err
+		}
+		conn, err = client.Dial("unix", socket)
+	}
+	if err != nil {
+		release()
+		return nil, fmt.Errorf("forwarding %s: %w", socket, err)
+	}
+	return &sshConn{Conn: conn, release: release}, nil
+}
+
+// close closes all SSH connections that aren't in use; the others are closed once released
+func (s *sshClients) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	for key, client := range s.clients {
+		if client.refs == 0 {
+			client.Close()
+		}
+		delete(s.clients, key)
+	}
+}
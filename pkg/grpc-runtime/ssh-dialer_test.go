@@ -0,0 +1,307 @@
+// Copyright 2026 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpcruntime
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+	"google.golang.org/grpc"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-service/api"
+)
+
+func TestParseSSHHost(t *testing.T) {
+	for _, tc := range []struct {
+		in, user, addr string
+		err            bool
+	}{
+		{in: "host", user: "me", addr: "host:22"},
+		{in: "root@host:2222", user: "root", addr: "host:2222"},
+		{in: "10.0.0.1", user: "me", addr: "10.0.0.1:22"},
+		{in: "admin@[::1]", user: "admin", addr: "[::1]:22"},
+		{in: "[::1]:2222", user: "me", addr: "[::1]:2222"},
+		{in: "root@", err: true},
+	} {
+		user, addr, err := parseSSHHost(tc.in, "me")
+		if tc.err {
+			if err == nil {
+				t.Errorf("%s: expected error", tc.in)
+			}
+			continue
+		}
+		if err != nil || user != tc.user || addr != tc.addr {
+			t.Errorf("%s: got %q, %q, %v", tc.in, user, addr, err)
+		}
+	}
+}
+
+type infoServer struct {
+	api.UnimplementedBuiltInGadgetManagerServer
+}
+
+func (infoServer) GetInfo(context.Context, *api.InfoRequest) (*api.InfoResponse, error) {
+	return &api.InfoResponse{ServerVersion: "v0.0.0-test"}, nil
+}
+
+// sshServerStats counts the connections of the test SSH server
+type sshServerStats struct {
+	accepted atomic.Int32
+	closed   atomic.Int32
+}
+
+// startSSHServer serves SSH on a local port, accepting the given client key and forwarding unix
+// sockets like OpenSSH does. It returns the address and the connection counters.
+func startSSHServer(t *testing.T, hostKey ssh.Signer, clientKey ssh.PublicKey) (string, *sshServerStats) {
+	t.Helper()
+	config := &ssh.ServerConfig{
+		PublicKeyCallback: func(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if conn.User() == "tester" && bytes.Equal(key.Marshal(), clientKey.Marshal()) {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("unknown key for %s", conn.User())
+		},
+	}
+	config.AddHostKey(hostKey)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	stats := &sshServerStats{}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				_, chans, reqs, err := ssh.NewServerConn(conn, config)
+				if err != nil {
+					return
+				}
+				stats.accepted.Add(1)
+				defer stats.closed.Add(1)
+				go ssh.DiscardRequests(reqs)
+				for newChan := range chans {
+					if newChan.ChannelType() != "direct-streamlocal@openssh.com" {
+						newChan.Reject(ssh.UnknownChannelType, "unsupported channel type")
+						continue
+					}
+					var msg struct {
+						SocketPath string
+						Reserved0  string
+						Reserved1  uint32
+					}
+					if err := ssh.Unmarshal(newChan.ExtraData(), &msg); err != nil {
+						newChan.Reject(ssh.ConnectionFailed, err.Error())
+						continue
+					}
+					local, err := net.Dial("unix", msg.SocketPath)
+					if err != nil {
+						newChan.Reject(ssh.ConnectionFailed, err.Error())
+						continue
+					}
+					ch, chReqs, err := newChan.Accept()
+					if err != nil {
+						local.Close()
+						continue
+					}
+					go ssh.DiscardRequests(chReqs)
+					go func() {
+						io.Copy(ch, local)
+						ch.CloseWrite()
+					}()
+					go func() {
+						io.Copy(local, ch)
+						local.Close()
+					}()
+				}
+			}()
+		}
+	}()
+	return l.Addr().String(), stats
+}
+
+// newSSHTestRuntime starts an IG daemon stand-in and an SSH server in front of it. It returns
+// the socket of the daemon, the counters of the SSH server and a function creating runtimes that
+// connect to the given remote socket through the SSH server.
+func newSSHTestRuntime(t *testing.T) (string, *sshServerStats, func(remoteSocket string, opts ...Option) *Runtime) {
+	t.Helper()
+
+	// Unix socket paths are limited in length, so don't use t.TempDir()
+	dir, err := os.MkdirTemp("", "ig-ssh")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	// IG daemon stand-in
+	socket := filepath.Join(dir, "ig.socket")
+	l, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := grpc.NewServer()
+	api.RegisterBuiltInGadgetManagerServer(srv, infoServer{})
+	go srv.Serve(l)
+	t.Cleanup(srv.Stop)
+
+	_, hostPriv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hostKey, err := ssh.NewSignerFromKey(hostPriv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, clientPriv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientKey, err := ssh.NewSignerFromKey(clientPriv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr, stats := startSSHServer(t, hostKey, clientKey.PublicKey())
+
+	block, err := ssh.MarshalPrivateKey(clientPriv, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyFile := filepath.Join(dir, "id_ed25519")
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(block), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	knownHostsFile := filepath.Join(dir, "known_hosts")
+	line := knownhosts.Line([]string{knownhosts.Normalize(addr)}, hostKey.PublicKey())
+	if err := os.WriteFile(knownHostsFile, []byte(line+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	newRuntime := func(remoteSocket string, opts ...Option) *Runtime {
+		rt := New(append([]Option{WithConnectUsingSSH}, opts...)...)
+		params := rt.GlobalParamDescs().ToParams()
+		for key, value := range map[string]string{
+			ParamSSHHosts:          "tester@" + addr,
+			ParamSSHKeyFile:        keyFile,
+			ParamSSHKnownHostsFile: knownHostsFile,
+			ParamSSHRemoteSocket:   remoteSocket,
+			ParamConnectionTimeout: "5",
+		} {
+			if err := params.Set(key, value); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := rt.Init(params); err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { rt.Close() })
+		return rt
+	}
+	return socket, stats, newRuntime
+}
+
+func TestCheckSSH(t *testing.T) {
+	socket, stats, newRuntime := newSSHTestRuntime(t)
+
+	steps := newRuntime("unix://" + socket).Check(context.Background())
+	if len(steps) != 2 {
+		t.Fatalf("expected 2 steps, got %d", len(steps))
+	}
+	if steps[0].Step != CheckStepSSH || !steps[0].OK() || steps[0].Node != "127.0.0.1" {
+		t.Errorf("unexpected ssh step: %+v", steps[0])
+	}
+	if steps[1].Step != CheckStepConnect || !steps[1].OK() || steps[1].Version != "v0.0.0-test" {
+		t.Errorf("unexpected connect step: %+v", steps[1])
+	}
+	if n := stats.accepted.Load(); n != 1 {
+		t.Errorf("expected forwarded connections to share one SSH connection, got %d", n)
+	}
+
+	steps = newRuntime(filepath.Join(filepath.Dir(socket), "missing.socket")).Check(context.Background())
+	if len(steps) != 1 || steps[0].Step != CheckStepSSH || steps[0].OK() {
+		t.Errorf("expected ssh step to fail: %+v", steps)
+	}
+
+	// A key given as data is used instead of reading the key file
+	keyFile := filepath.Join(filepath.Dir(socket), "id_ed25519")
+	key, err := os.ReadFile(keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(keyFile); err != nil {
+		t.Fatal(err)
+	}
+	steps = newRuntime("unix://"+socket, WithSSHKeyData(key)).Check(context.Background())
+	if len(steps) != 2 || !steps[0].OK() || !steps[1].OK() {
+		t.Errorf("expected key data to be used: %+v", steps)
+	}
+}
+
+func TestCloseSSHWithOpenStream(t *testing.T) {
+	socket, stats, newRuntime := newSSHTestRuntime(t)
+	rt := newRuntime(socket)
+
+	targets, err := rt.getSSHTargets()
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn, err := rt.dialContext(context.Background(), targets[0], 5*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := api.NewBuiltInGadgetManagerClient(conn)
+	if _, err := client.GetInfo(context.Background(), &api.InfoRequest{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Closing the runtime keeps the SSH connection the gRPC connection is forwarded through
+	rt.Close()
+	if _, err := client.GetInfo(context.Background(), &api.InfoRequest{}); err != nil {
+		t.Fatalf("stream broke after closing the runtime: %v", err)
+	}
+	if n := stats.closed.Load(); n != 0 {
+		t.Fatalf("SSH connection closed while in use")
+	}
+
+	// ... until the last forwarded connection is gone
+	conn.Close()
+	deadline := time.Now().Add(5 * time.Second)
+	for stats.closed.Load() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("SSH connection not closed after its last user")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := stats.accepted.Load(); n != 1 {
+		t.Errorf("expected a single SSH connection, got %d", n)
+	}
+}
@@ -0,0 +1,145 @@
+// Copyright 2026 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpcruntime
+
+import (
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/datasource"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-service/api"
+)
+
+// Fields the runtime adds to every data source, so that events of different targets can be told apart
+const (
+	TargetFieldParent = "runtime"
+	TargetFieldNode   = "runtime.node"
+	TargetFieldTarget = "runtime.target"
+)
+
+// addTargetFields extends all data sources of the gadget info with the target fields. It returns the
+// number of payloads the server sends per data source id, which is where the target fields start.
+func addTargetFields(gi *api.GadgetInfo) map[uint32]int {
+	payloads := make(map[uint32]int, len(gi.DataSources))
+	for _, ds := range gi.DataSources {
+		count := uint32(0)
+		for _, f := range ds.Fields {
+			if !datasource.FieldFlagEmpty.In(f.Flags) && f.PayloadIndex+1 > count {
+				count = f.PayloadIndex + 1
+			}
+		}
+		payloads[ds.Id] = int(count)
+
+		// The enrichment of IG usually adds a "runtime" parent already (runtime.containerName, ...);
+		// append to it instead of adding a second field with the same name
+		var parentField *api.Field
+		for _, f := range ds.Fields {
+			if f.FullName == TargetFieldParent {
+				parentField = f
+				break
+			}
+		}
+		if parentField == nil {
+			parentField = &api.Field{
+				Name:     TargetFieldParent,
+				FullName: TargetFieldParent,
+				Index:    uint32(len(ds.Fields)),
+				Flags:    uint32(datasource.FieldFlagEmpty),
+				Kind:     api.Kind_Invalid,
+				Order:    int32(len(ds.Fields)),
+			}
+			ds.Fields = append(ds.Fields, parentField)
+		}
+
+		annotations := func() map[string]string {
+			return map[string]string{
+				"columns.width":    "16",
+				"columns.ellipsis": "middle",
+			}
+		}
+		index := uint32(len(ds.Fields))
+		ds.Fields = append(ds.Fields,
+			&api.Field{
+				Name:         "node",
+				FullName:     TargetFieldNode,
+				Index:        index,
+				PayloadIndex: count,
+				Flags:        uint32(datasource.FieldFlagHasParent),
+				Parent:       parentField.Index,
+				Kind:         api.Kind_String,
+				Annotations:  annotations(),
+				Order:        int32(index),
+			},
+			&api.Field{
+				Name:         "target",
+				FullName:     TargetFieldTarget,
+				Index:        index + 1,
+				PayloadIndex: count + 1,
+				Flags:        uint32(datasource.FieldFlagHasParent),
+				Parent:       parentField.Index,
+				Kind:         api.Kind_String,
+				Annotations:  annotations(),
+				Order:        int32(index + 1),
+			},
+		)
+	}
+	return payloads
+}
+
+// targetTagger fills the target fields of packets received from a single target
+type targetTagger struct {
+	node     []byte
+	target   []byte
+	payloads int
+	nodeF    datasource.FieldAccessor
+	targetF  datasource.FieldAccessor
+}
+
+func newTargetTagger(ds datasource.DataSource, t target, payloads int) *targetTagger {
+	nodeF := ds.GetField(TargetFieldNode)
+	targetF := ds.GetField(TargetFieldTarget)
+	if nodeF == nil || targetF == nil {
+		return nil
+	}
+	return &targetTagger{
+		node:     []byte(t.node),
+		target:   []byte(t.addressOrPod),
+		payloads: payloads,
+		nodeF:    nodeF,
+		targetF:  targetF,
+	}
+}
+
+// tag makes room for the target fields in the payload of the packet and sets them
+func (t *targetTagger) tag(p datasource.Packet) {
+	if t == nil {
+		return
+	}
+	switch raw := p.Raw().(type) {
+	case *api.GadgetData:
+		t.tagElement(raw.Data, p.(datasource.Data))
+	case *api.GadgetDataArray:
+		arr := p.(datasource.DataArray)
+		for i, el := range raw.DataArray {
+			t.tagElement(el, arr.Get(i))
+		}
+	}
+}
+
+func (t *targetTagger) tagElement(el *api.DataElement, data datasource.Data) {
+	if el == nil || len(el.Payload) != t.payloads {
+		return
+	}
+	el.Payload = append(el.Payload, nil, nil)
+	t.nodeF.Set(data, t.node)
+	t.targetF.Set(data, t.target)
+}
@@ -0,0 +1,141 @@
+// Copyright 2026 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpcruntime
+
+import (
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/datasource"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-service/api"
+)
+
+func TestTargetFields(t *testing.T) {
+	// Data source and packet as the server sends them
+	server, err := datasource.New(datasource.TypeSingle, "exec")
+	if err != nil {
+		t.Fatal(err)
+	}
+	comm, _ := server.AddField("comm", api.Kind_String)
+	pid, _ := server.AddField("pid", api.Kind_Uint32)
+	p, _ := server.NewPacketSingle()
+	comm.PutString(p, "sh")
+	pid.PutUint32(p, 42)
+	raw, err := proto.Marshal(p.Raw())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gi := &api.GadgetInfo{DataSources: []*api.DataSource{{Id: 1, Name: "exec", Type: uint32(datasource.TypeSingle), Fields: server.Fields()}}}
+	payloads := addTargetFields(gi)
+	if payloads[1] != 2 {
+		t.Fatalf("expected 2 server payloads, got %d", payloads[1])
+	}
+
+	client, err := datasource.NewFromAPI(gi.DataSources[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	tagger := newTargetTagger(client, target{addressOrPod: "10.0.0.1:1234", node: "10.0.0.1"}, payloads[1])
+	if tagger == nil {
+		t.Fatal("target fields not found")
+	}
+
+	cp, err := client.NewPacketSingleFromRaw(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tagger.tag(cp)
+
+	node, _ := client.GetField(TargetFieldNode).String(cp)
+	addr, _ := client.GetField(TargetFieldTarget).String(cp)
+	c, _ := client.GetField("comm").String(cp)
+	if node != "10.0.0.1" || addr != "10.0.0.1:1234" || c != "sh" {
+		t.Errorf("unexpected values: node %q, target %q, comm %q", node, addr, c)
+	}
+}
+
+func TestTargetFieldsExistingParent(t *testing.T) {
+	// Enriched data sources already have a runtime parent
+	server, err := datasource.New(datasource.TypeSingle, "exec")
+	if err != nil {
+		t.Fatal(err)
+	}
+	comm, _ := server.AddField("comm", api.Kind_String)
+	runtimeF, err := server.AddField(TargetFieldParent, api.Kind_Invalid, datasource.WithFlags(datasource.FieldFlagEmpty))
+	if err != nil {
+		t.Fatal(err)
+	}
+	containerName, err := runtimeF.AddSubField("containerName", api.Kind_String)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, _ := server.NewPacketSingle()
+	comm.PutString(p, "sh")
+	containerName.PutString(p, "nginx")
+	raw, err := proto.Marshal(p.Raw())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gi := &api.GadgetInfo{DataSources: []*api.DataSource{{Id: 1, Name: "exec", Type: uint32(datasource.TypeSingle), Fields: server.Fields()}}}
+	payloads := addTargetFields(gi)
+	if payloads[1] != 2 {
+		t.Fatalf("expected 2 server payloads, got %d", payloads[1])
+	}
+
+	parents := 0
+	for _, f := range gi.DataSources[0].Fields {
+		if f.FullName == TargetFieldParent {
+			parents++
+		}
+	}
+	if parents != 1 {
+		t.Fatalf("expected a single %q field, got %d", TargetFieldParent, parents)
+	}
+
+	client, err := datasource.NewFromAPI(gi.DataSources[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	tagger := newTargetTagger(client, target{addressOrPod: "10.0.0.1:1234", node: "10.0.0.1"}, payloads[1])
+	if tagger == nil {
+		t.Fatal("target fields not found")
+	}
+
+	cp, err := client.NewPacketSingleFromRaw(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tagger.tag(cp)
+
+	node, _ := client.GetField(TargetFieldNode).String(cp)
+	addr, _ := client.GetField(TargetFieldTarget).String(cp)
+	container, _ := client.GetField("runtime.containerName").String(cp)
+	if node != "10.0.0.1" || addr != "10.0.0.1:1234" || container != "nginx" {
+		t.Errorf("unexpected values: node %q, target %q, container %q", node, addr, container)
+	}
+
+	// The target fields are children of the existing parent
+	subFields := client.GetField(TargetFieldParent).SubFields()
+	names := make([]string, 0, len(subFields))
+	for _, f := range subFields {
+		names = append(names, f.FullName())
+	}
+	if len(names) != 3 || names[1] != TargetFieldNode || names[2] != TargetFieldTarget {
+		t.Errorf("unexpected children of %q: %v", TargetFieldParent, names)
+	}
+}
@@ -28,10 +28,10 @@ import (
 	"helm.sh/helm/v3/pkg/getter"
 	"helm.sh/helm/v3/pkg/repo"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/cli-runtime/pkg/genericclioptions"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
-	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/inspektor-gadget/ig-desktop/pkg/k8s"
 )
 
 const (
@@ -54,8 +54,7 @@ type DeployConfig struct {
 	ReleaseName  string
 	ChartVersion string
 	CustomValues map[string]interface{}
-	KubeConfig   string
-	KubeContext  string
+	Kube         k8s.ConfigSource
 	HelmDir      string
 }
 
@@ -77,11 +76,11 @@ func NewDeployer(config *DeployConfig) (*Deployer, error) {
 	if err != nil {
 		return nil, err
 	}
-	if config.KubeConfig != "" {
-		settings.KubeConfig = config.KubeConfig
+	if config.Kube.Path != "" {
+		settings.KubeConfig = config.Kube.Path
 	}
-	if config.KubeContext != "" {
-		settings.KubeContext = config.KubeContext
+	if config.Kube.Context != "" {
+		settings.KubeContext = config.Kube.Context
 	}
 
 	return &Deployer{
@@ -239,24 +238,18 @@ func (d *Deployer) Redeploy(ctx context.Context) error {
 }
 
 // newActionConfig builds a Helm action.Configuration that targets the
-// user-selected kubeconfig file and context, falling back to defaults
-// when either field is empty. All Helm operations must go through this
-// helper so they consistently honour the environment's selected context
+// user-selected Kubernetes config, falling back to defaults when nothing
+// is selected. All Helm operations must go through this helper so they
+// consistently honour the environment's selected config and context
 // instead of silently using kubectl's default current-context.
 func (d *Deployer) newActionConfig() (*action.Configuration, error) {
 	actionConfig := new(action.Configuration)
-	configFlags := genericclioptions.NewConfigFlags(true)
-	configFlags.Namespace = &d.config.Namespace
-	if d.config.KubeContext != "" {
-		ctx := d.config.KubeContext
-		configFlags.Context = &ctx
-	}
-	if d.config.KubeConfig != "" {
-		kc := d.config.KubeConfig
-		configFlags.KubeConfig = &kc
+	getter, err := d.config.Kube.RESTClientGetter(d.config.Namespace)
+	if err != nil {
+		return nil, err
 	}
 
-	if err := actionConfig.Init(configFlags, d.config.Namespace, os.Getenv("HELM_DRIVER"), log.Debugf); err != nil {
+	if err := actionConfig.Init(getter, d.config.Namespace, os.Getenv("HELM_DRIVER"), log.Debugf); err != nil {
 		return nil, err
 	}
 	return actionConfig, nil
@@ -447,7 +440,7 @@ func (d *Deployer) verifyDeployment(ctx context.Context) error {
 
 func (d *Deployer) waitForDaemonSet(ctx context.Context, timeout time.Duration) error {
 	// Get Kubernetes config with context
-	config, err := getKubeConfig(d.config.KubeConfig, d.config.KubeContext)
+	config, err := d.config.Kube.RESTConfig()
 	if err != nil {
 		return fmt.Errorf("failed to get kubeconfig: %w", err)
 	}
@@ -510,7 +503,7 @@ func (d *Deployer) waitForDaemonSet(ctx context.Context, timeout time.Duration)
 
 func (d *Deployer) waitForDaemonSetRemoval(ctx context.Context, timeout time.Duration) error {
 	// Get Kubernetes config with context
-	config, err := getKubeConfig(d.config.KubeConfig, d.config.KubeContext)
+	config, err := d.config.Kube.RESTConfig()
 	if err != nil {
 		return fmt.Errorf("failed to get kubeconfig: %w", err)
 	}
@@ -566,27 +559,6 @@ func (d *Deployer) waitForDaemonSetRemoval(ctx context.Context, timeout time.Dur
 	}
 }
 
-func getKubeConfig(kubeconfig string, kubeContext string) (*rest.Config, error) {
-	if kubeconfig != "" {
-		return clientcmd.BuildConfigFromFlags("", kubeconfig)
-	}
-
-	// Try in-cluster config first
-	if config, err := rest.InClusterConfig(); err == nil {
-		return config, nil
-	}
-
-	// Fall back to default kubeconfig with optional context override
-	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
-	configOverrides := &clientcmd.ConfigOverrides{
-		CurrentContext: kubeContext,
-	}
-	return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
-		loadingRules,
-		configOverrides,
-	).ClientConfig()
-}
-
 func getKubernetesClient(config *rest.Config) (*kubernetes.Clientset, error) {
 	return kubernetes.NewForConfig(config)
 }
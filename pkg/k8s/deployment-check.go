@@ -25,7 +25,6 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
-	"k8s.io/client-go/tools/clientcmd"
 )
 
 type DeploymentStatus struct {
@@ -199,28 +198,6 @@ func extractVersionFromImage(image string) string {
 	return "latest"
 }
 
-// GetKubeConfig loads the Kubernetes configuration with optional context
-func GetKubeConfig(kubeconfig string, kubeContext string) (*rest.Config, error) {
-	if kubeconfig != "" {
-		return clientcmd.BuildConfigFromFlags("", kubeconfig)
-	}
-
-	// Try in-cluster config first
-	if config, err := rest.InClusterConfig(); err == nil {
-		return config, nil
-	}
-
-	// Fall back to default kubeconfig with optional context override
-	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
-	configOverrides := &clientcmd.ConfigOverrides{
-		CurrentContext: kubeContext,
-	}
-	return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
-		loadingRules,
-		configOverrides,
-	).ClientConfig()
-}
-
 // GetGadgetPods returns all gadget pods in the namespace
 func GetGadgetPods(ctx context.Context, config *rest.Config, namespace string) ([]v1.Pod, error) {
 	clientset, err := kubernetes.NewForConfig(config)
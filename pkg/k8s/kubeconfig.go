@@ -0,0 +1,381 @@
+// Copyright 2026 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8s
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// Environment params describing where the Kubernetes config comes from
+const (
+	ParamKubeconfig     = "kubeconfig"      // path, or list of paths like KUBECONFIG
+	ParamKubeconfigData = "kubeconfig-data" // kubeconfig content
+	ParamInCluster      = "in-cluster"      // "true" to use the service account of the pod
+	ParamContext        = "context"
+
+	ParamImpersonate       = "as"        // user to impersonate, like kubectl --as
+	ParamImpersonateGroups = "as-group"  // comma-separated groups to impersonate, like kubectl --as-group
+	ParamProxyURL          = "proxy-url" // http, https or socks5 proxy for the API server
+)
+
+const serviceAccountNamespaceFile = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+
+// ConfigSource describes where to load a Kubernetes config from. The first of Data, InCluster
+// and Path that is set wins; without any of them, the default loading rules apply, merging the
+// files listed in KUBECONFIG or using ~/.kube/config, and falling back to the in-cluster config
+// if there's no kubeconfig at all.
+type ConfigSource struct {
+	Path      string // kubeconfig file, or list of files separated like in KUBECONFIG
+	Data      string // kubeconfig content; users running credential plugins are rejected
+	InCluster bool
+	Context   string // context to use instead of the current one; not used in-cluster
+
+	// Impersonation and proxy apply on top of whatever config is loaded, including in-cluster
+	Impersonate       string
+	ImpersonateGroups []string
+	ProxyURL          string
+}
+
+// ConfigSourceFromParams returns the source described by the params of an environment
+func ConfigSourceFromParams(params map[string]string) ConfigSource {
+	return ConfigSource{
+		Path:      params[ParamKubeconfig],
+		Data:      params[ParamKubeconfigData],
+		InCluster: params[ParamInCluster] == "true",
+		Context:   params[ParamContext],
+
+		Impersonate:       params[ParamImpersonate],
+		ImpersonateGroups: splitList(params[ParamImpersonateGroups]),
+		ProxyURL:          params[ParamProxyURL],
+	}
+}
+
+func splitList(s string) []string {
+	var res []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			res = append(res, v)
+		}
+	}
+	return res
+}
+
+// ParseProxyURL parses the URL of a proxy to reach the API server through
+func ParseProxyURL(s string) (*url.URL, error) {
+	u, err := url.Parse(s)
+	if err != nil {
+		return nil, err
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return nil, fmt.Errorf("unsupported proxy scheme %q, expected http, https or socks5", u.Scheme)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("missing proxy host")
+	}
+	return u, nil
+}
+
+// IsDefault returns true if the config comes from the default kubeconfig, that is the files
+// listed in KUBECONFIG or ~/.kube/config
+func (s ConfigSource) IsDefault() bool {
+	return s.Path == "" && s.Data == "" && !s.InCluster
+}
+
+// ParamError is an invalid setting of a ConfigSource, along with the param it comes from
+type ParamError struct {
+	Param string
+	Err   error
+}
+
+func (e *ParamError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ParamError) Unwrap() error {
+	return e.Err
+}
+
+// HostParams returns the params of the source that make the config come from the host: kubeconfig
+// files, inline kubeconfigs, which can reference files of the host, and the in-cluster config
+func (s ConfigSource) HostParams() []string {
+	var params []string
+	if s.Path != "" {
+		params = append(params, ParamKubeconfig)
+	}
+	if s.Data != "" {
+		params = append(params, ParamKubeconfigData)
+	}
+	if s.InCluster {
+		params = append(params, ParamInCluster)
+	}
+	return params
+}
+
+// checkInline rejects users of an inline kubeconfig that run a credential plugin, as that would
+// run commands given by whoever provided the kubeconfig
+func checkInline(cfg *clientcmdapi.Config) error {
+	for name, user := range cfg.AuthInfos {
+		if user == nil {
+			continue
+		}
+		if user.Exec != nil {
+			return fmt.Errorf("user %q of the inline kubeconfig uses an exec credential plugin, which is not supported", name)
+		}
+		if user.AuthProvider != nil {
+			return fmt.Errorf("user %q of the inline kubeconfig uses an auth provider, which is not supported", name)
+		}
+	}
+	return nil
+}
+
+// Errors returns the invalid impersonation and proxy settings, and credential plugins of an
+// inline kubeconfig
+func (s ConfigSource) Errors() []*ParamError {
+	var errs []*ParamError
+	if len(s.ImpersonateGroups) > 0 && s.Impersonate == "" {
+		errs = append(errs, &ParamError{
+			Param: ParamImpersonateGroups,
+			Err:   fmt.Errorf("impersonating groups requires %s to be set", ParamImpersonate),
+		})
+	}
+	if s.ProxyURL != "" {
+		if _, err := ParseProxyURL(s.ProxyURL); err != nil {
+			errs = append(errs, &ParamError{
+				Param: ParamProxyURL,
+				Err:   fmt.Errorf("invalid proxy URL: %w", err),
+			})
+		}
+	}
+	if s.Data != "" {
+		// Parsing errors are reported when loading the config
+		if cfg, err := clientcmd.Load([]byte(s.Data)); err == nil {
+			if err := checkInline(cfg); err != nil {
+				errs = append(errs, &ParamError{Param: ParamKubeconfigData, Err: err})
+			}
+		}
+	}
+	return errs
+}
+
+// Validate checks the impersonation and proxy settings, and the inline kubeconfig
+func (s ConfigSource) Validate() error {
+	if errs := s.Errors(); len(errs) > 0 {
+		return errs[0]
+	}
+	return nil
+}
+
+// ClientConfig returns the loader for the config of the source
+func (s ConfigSource) ClientConfig() (clientcmd.ClientConfig, error) {
+	return s.clientConfig("")
+}
+
+// clientConfig returns the loader for the config of the source, overriding the namespace if set
+func (s ConfigSource) clientConfig(namespace string) (clientcmd.ClientConfig, error) {
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+	cc, err := s.loader(namespace)
+	if err != nil {
+		return nil, err
+	}
+	if s.Impersonate == "" && s.ProxyURL == "" {
+		return cc, nil
+	}
+	return &overridingClientConfig{inner: cc, source: s}, nil
+}
+
+// loader returns the loader for the config of the source without impersonation and proxy
+func (s ConfigSource) loader(namespace string) (clientcmd.ClientConfig, error) {
+	overrides := &clientcmd.ConfigOverrides{
+		CurrentContext: s.Context,
+		Context:        clientcmdapi.Context{Namespace: namespace},
+	}
+	switch {
+	case s.Data != "":
+		cfg, err := clientcmd.Load([]byte(s.Data))
+		if err != nil {
+			return nil, fmt.Errorf("parsing kubeconfig: %w", err)
+		}
+		if err := checkInline(cfg); err != nil {
+			return nil, err
+		}
+		return clientcmd.NewNonInteractiveClientConfig(*cfg, s.Context, overrides, nil), nil
+	case s.InCluster:
+		return inClusterClientConfig{namespace: namespace}, nil
+	}
+
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	if files := filepath.SplitList(s.Path); len(files) == 1 {
+		// A single explicit file has to exist
+		rules.ExplicitPath = files[0]
+	} else if len(files) > 1 {
+		rules.Precedence = files
+	}
+	return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, overrides), nil
+}
+
+// RESTConfig returns the config of the source
+func (s ConfigSource) RESTConfig() (*rest.Config, error) {
+	cc, err := s.ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+	return cc.ClientConfig()
+}
+
+// Files returns the kubeconfig files the config is loaded from, so that changes can be noticed
+func (s ConfigSource) Files() []string {
+	if s.Data != "" || s.InCluster {
+		return nil
+	}
+	if s.Path != "" {
+		return filepath.SplitList(s.Path)
+	}
+	return clientcmd.NewDefaultClientConfigLoadingRules().GetLoadingPrecedence()
+}
+
+// RESTClientGetter returns a getter for the config of the source, as used by Helm
+func (s ConfigSource) RESTClientGetter(namespace string) (genericclioptions.RESTClientGetter, error) {
+	cc, err := s.clientConfig(namespace)
+	if err != nil {
+		return nil, err
+	}
+	return &restClientGetter{clientConfig: cc}, nil
+}
+
+// GetKubeConfig loads the Kubernetes configuration with optional context
+func GetKubeConfig(kubeconfig string, kubeContext string) (*rest.Config, error) {
+	return ConfigSource{Path: kubeconfig, Context: kubeContext}.RESTConfig()
+}
+
+// overridingClientConfig applies the impersonation and proxy settings of a source to the loaded
+// config. This is done on the final config instead of using ConfigOverrides, so that it applies
+// to the in-cluster config as well.
+type overridingClientConfig struct {
+	inner  clientcmd.ClientConfig
+	source ConfigSource
+}
+
+func (c *overridingClientConfig) RawConfig() (clientcmdapi.Config, error) {
+	return c.inner.RawConfig()
+}
+
+func (c *overridingClientConfig) Namespace() (string, bool, error) {
+	return c.inner.Namespace()
+}
+
+func (c *overridingClientConfig) ConfigAccess() clientcmd.ConfigAccess {
+	return c.inner.ConfigAccess()
+}
+
+func (c *overridingClientConfig) ClientConfig() (*rest.Config, error) {
+	config, err := c.inner.ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+	if c.source.Impersonate != "" {
+		config.Impersonate = rest.ImpersonationConfig{
+			UserName: c.source.Impersonate,
+			Groups:   c.source.ImpersonateGroups,
+		}
+	}
+	if c.source.ProxyURL != "" {
+		proxy, err := ParseProxyURL(c.source.ProxyURL)
+		if err != nil {
+			return nil, err
+		}
+		config.Proxy = http.ProxyURL(proxy)
+	}
+	return config, nil
+}
+
+// inClusterClientConfig uses the service account of the pod the app is running in
+type inClusterClientConfig struct {
+	namespace string // overrides the namespace of the service account if set
+}
+
+func (inClusterClientConfig) RawConfig() (clientcmdapi.Config, error) {
+	return clientcmdapi.Config{}, nil
+}
+
+func (inClusterClientConfig) ClientConfig() (*rest.Config, error) {
+	return rest.InClusterConfig()
+}
+
+func (c inClusterClientConfig) Namespace() (string, bool, error) {
+	if c.namespace != "" {
+		return c.namespace, true, nil
+	}
+	ns, err := os.ReadFile(serviceAccountNamespaceFile)
+	if err != nil {
+		return "default", false, nil
+	}
+	return strings.TrimSpace(string(ns)), false, nil
+}
+
+func (inClusterClientConfig) ConfigAccess() clientcmd.ConfigAccess {
+	return clientcmd.NewDefaultClientConfigLoadingRules()
+}
+
+// restClientGetter implements genericclioptions.RESTClientGetter on top of a ClientConfig
+type restClientGetter struct {
+	clientConfig clientcmd.ClientConfig
+}
+
+func (g *restClientGetter) ToRESTConfig() (*rest.Config, error) {
+	return g.clientConfig.ClientConfig()
+}
+
+func (g *restClientGetter) ToDiscoveryClient() (discovery.CachedDiscoveryInterface, error) {
+	config, err := g.ToRESTConfig()
+	if err != nil {
+		return nil, err
+	}
+	client, err := discovery.NewDiscoveryClientForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	return memory.NewMemCacheClient(client), nil
+}
+
+func (g *restClientGetter) ToRESTMapper() (meta.RESTMapper, error) {
+	client, err := g.ToDiscoveryClient()
+	if err != nil {
+		return nil, err
+	}
+	mapper := restmapper.NewDeferredDiscoveryRESTMapper(client)
+	return restmapper.NewShortcutExpander(mapper, client, nil), nil
+}
+
+func (g *restClientGetter) ToRawKubeConfigLoader() clientcmd.ClientConfig {
+	return g.clientConfig
+}
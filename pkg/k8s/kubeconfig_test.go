@@ -0,0 +1,207 @@
+// Copyright 2026 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8s
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func kubeconfig(name, server string) string {
+	return fmt.Sprintf(`apiVersion: v1
+kind: Config
+current-context: %[1]s
+clusters:
+- name: %[1]s
+  cluster:
+    server: %[2]s
+users:
+- name: %[1]s
+  user:
+    token: secret
+contexts:
+- name: %[1]s
+  context:
+    cluster: %[1]s
+    user: %[1]s
+    namespace: ns-%[1]s
+`, name, server)
+}
+
+func TestConfigSource(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.yaml")
+	b := filepath.Join(dir, "b.yaml")
+	if err := os.WriteFile(a, []byte(kubeconfig("a", "https://a.example.com")), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(b, []byte(kubeconfig("b", "https://b.example.com")), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name      string
+		source    ConfigSource
+		server    string
+		namespace string
+	}{
+		{
+			name:      "path",
+			source:    ConfigSource{Path: b},
+			server:    "https://b.example.com",
+			namespace: "ns-b",
+		},
+		{
+			name:      "merged list",
+			source:    ConfigSource{Path: a + string(filepath.ListSeparator) + b, Context: "b"},
+			server:    "https://b.example.com",
+			namespace: "ns-b",
+		},
+		{
+			name:      "inline",
+			source:    ConfigSourceFromParams(map[string]string{ParamKubeconfigData: kubeconfig("c", "https://c.example.com")}),
+			server:    "https://c.example.com",
+			namespace: "ns-c",
+		},
+	} {
+		cc, err := tc.source.ClientConfig()
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		config, err := cc.ClientConfig()
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if config.Host != tc.server {
+			t.Errorf("%s: expected server %q, got %q", tc.name, tc.server, config.Host)
+		}
+		if ns, _, _ := cc.Namespace(); ns != tc.namespace {
+			t.Errorf("%s: expected namespace %q, got %q", tc.name, tc.namespace, ns)
+		}
+	}
+
+	if _, err := (ConfigSource{Path: filepath.Join(dir, "missing.yaml")}).RESTConfig(); err == nil {
+		t.Error("expected error for missing explicit kubeconfig")
+	}
+	if _, err := (ConfigSource{Data: "{not yaml"}).ClientConfig(); err == nil {
+		t.Error("expected error for invalid inline kubeconfig")
+	}
+
+	getter, err := ConfigSource{Path: a}.RESTClientGetter("gadget")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ns, _, _ := getter.ToRawKubeConfigLoader().Namespace(); ns != "gadget" {
+		t.Errorf("expected namespace override, got %q", ns)
+	}
+
+	if files := (ConfigSource{Path: a + string(filepath.ListSeparator) + b}).Files(); !slices.Equal(files, []string{a, b}) {
+		t.Errorf("unexpected files %v", files)
+	}
+	if files := (ConfigSource{Data: "x", Path: a}).Files(); files != nil {
+		t.Errorf("expected no files for inline config, got %v", files)
+	}
+}
+
+func TestConfigSourceImpersonationAndProxy(t *testing.T) {
+	source := ConfigSourceFromParams(map[string]string{
+		ParamKubeconfigData:    kubeconfig("a", "https://a.example.com"),
+		ParamImpersonate:       "alice",
+		ParamImpersonateGroups: "devs, ops",
+		ParamProxyURL:          "socks5://proxy.example.com:1080",
+	})
+	config, err := source.RESTConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Impersonate.UserName != "alice" || !slices.Equal(config.Impersonate.Groups, []string{"devs", "ops"}) {
+		t.Errorf("unexpected impersonation %+v", config.Impersonate)
+	}
+	if config.Proxy == nil {
+		t.Fatal("expected proxy to be set")
+	}
+	req, _ := http.NewRequest(http.MethodGet, config.Host, nil)
+	if proxy, err := config.Proxy(req); err != nil || proxy.String() != "socks5://proxy.example.com:1080" {
+		t.Errorf("unexpected proxy %v, %v", proxy, err)
+	}
+
+	// The Helm getter hands out the same config
+	getter, err := source.RESTClientGetter("gadget")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config, err := getter.ToRESTConfig(); err != nil || config.Impersonate.UserName != "alice" {
+		t.Errorf("getter doesn't impersonate: %v", err)
+	}
+
+	for _, invalid := range []ConfigSource{
+		{ImpersonateGroups: []string{"devs"}},
+		{ProxyURL: "ftp://proxy.example.com"},
+		{ProxyURL: "http://"},
+	} {
+		if _, err := invalid.ClientConfig(); err == nil {
+			t.Errorf("expected error for %+v", invalid)
+		}
+	}
+
+	errs := ConfigSource{ImpersonateGroups: []string{"devs"}, ProxyURL: "ftp://proxy.example.com"}.Errors()
+	if len(errs) != 2 || errs[0].Param != ParamImpersonateGroups || errs[1].Param != ParamProxyURL {
+		t.Errorf("unexpected errors %v", errs)
+	}
+}
+
+func TestConfigSourceInlineCredentialPlugins(t *testing.T) {
+	base := kubeconfig("a", "https://a.example.com")
+	for name, user := range map[string]string{
+		"exec": `    exec:
+      apiVersion: client.authentication.k8s.io/v1
+      command: /bin/sh
+      args: ["-c", "id"]
+`,
+		"auth provider": `    auth-provider:
+      name: oidc
+`,
+	} {
+		source := ConfigSource{Data: strings.Replace(base, "    token: secret\n", user, 1)}
+		if _, err := source.ClientConfig(); err == nil {
+			t.Errorf("%s: expected inline kubeconfig to be rejected", name)
+		}
+		if errs := source.Errors(); len(errs) != 1 || errs[0].Param != ParamKubeconfigData {
+			t.Errorf("%s: unexpected errors %v", name, errs)
+		}
+	}
+
+	if errs := (ConfigSource{Data: base}).Errors(); len(errs) != 0 {
+		t.Errorf("unexpected errors %v", errs)
+	}
+
+	params := ConfigSourceFromParams(map[string]string{
+		ParamKubeconfig:     "/etc/kube/config",
+		ParamKubeconfigData: base,
+		ParamInCluster:      "true",
+		ParamContext:        "a",
+	}).HostParams()
+	if !slices.Equal(params, []string{ParamKubeconfig, ParamKubeconfigData, ParamInCluster}) {
+		t.Errorf("unexpected host params %v", params)
+	}
+	if params := (ConfigSource{Context: "a"}).HostParams(); params != nil {
+		t.Errorf("expected no host params for the default config, got %v", params)
+	}
+}
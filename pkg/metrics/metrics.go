@@ -0,0 +1,333 @@
+// Copyright 2026 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package metrics aggregates gadget events into counters, gauges and histograms
+// and exposes them in the OpenMetrics text format.
+package metrics
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Metric types
+const (
+	TypeCounter   = "counter"
+	TypeGauge     = "gauge"
+	TypeHistogram = "histogram"
+)
+
+// ContentType is the content type of the OpenMetrics text format
+const ContentType = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+
+// DefaultBuckets are used for histograms without explicit buckets
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// DefaultMaxSeries is the number of label sets a metric keeps unless its definition sets another limit
+const DefaultMaxSeries = 10000
+
+// DroppedSeriesName is the counter reporting how many observations each metric dropped because
+// they would have exceeded its series limit. Definitions can't use it.
+const DroppedSeriesName = "ig_metrics_dropped_series"
+
+var nameRegexp = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+
+// Definition maps the events of a datasource to a metric
+type Definition struct {
+	Name string `json:"name"`
+	Help string `json:"help,omitempty"`
+	Type string `json:"type"`
+
+	// Selectors; empty values match everything. Image matches if it is contained in the gadget image.
+	Image      string `json:"image,omitempty"`
+	Datasource string `json:"datasource,omitempty"`
+
+	// Labels are the datasource fields used as labels, e.g. "k8s.namespace"; dots become underscores
+	// in the label name
+	Labels []string `json:"labels,omitempty"`
+
+	// Field is the datasource field holding the value. Counters without a field count events.
+	Field string `json:"field,omitempty"`
+
+	// Buckets are the upper bounds of histogram buckets
+	Buckets []float64 `json:"buckets,omitempty"`
+
+	// MaxSeries limits the number of label sets, so that labels with many distinct values, like
+	// PIDs, can't grow the metric without bounds. Observations of new label sets beyond the limit
+	// are dropped and counted in DroppedSeriesName. Defaults to DefaultMaxSeries.
+	MaxSeries int `json:"maxSeries,omitempty"`
+}
+
+// LabelName returns the label name used for the given field
+func LabelName(field string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' {
+			return r
+		}
+		return '_'
+	}, field)
+}
+
+// Validate checks the definition and fills in defaults
+func (d *Definition) Validate() error {
+	d.Name = strings.TrimSuffix(d.Name, "_total")
+	if !nameRegexp.MatchString(d.Name) {
+		return fmt.Errorf("invalid metric name %q", d.Name)
+	}
+	if d.Name == DroppedSeriesName {
+		return fmt.Errorf("metric name %q is reserved", d.Name)
+	}
+	if d.MaxSeries < 0 {
+		return fmt.Errorf("metric %q: max series must not be negative", d.Name)
+	}
+	if d.MaxSeries == 0 {
+		d.MaxSeries = DefaultMaxSeries
+	}
+	switch d.Type {
+	case TypeCounter:
+	case TypeGauge:
+		if d.Field == "" {
+			return fmt.Errorf("metric %q: gauges require a field", d.Name)
+		}
+	case TypeHistogram:
+		if d.Field == "" {
+			return fmt.Errorf("metric %q: histograms require a field", d.Name)
+		}
+		if len(d.Buckets) == 0 {
+			d.Buckets = DefaultBuckets
+		}
+		if !slices.IsSorted(d.Buckets) {
+			return fmt.Errorf("metric %q: buckets must be sorted", d.Name)
+		}
+	default:
+		return fmt.Errorf("metric %q: unknown type %q", d.Name, d.Type)
+	}
+	for _, l := range d.Labels {
+		if l == "" {
+			return fmt.Errorf("metric %q: empty label field", d.Name)
+		}
+	}
+	return nil
+}
+
+// LoadDefinitions reads a JSON array of definitions from the given file
+func LoadDefinitions(path string) ([]Definition, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading metric definitions: %w", err)
+	}
+	var defs []Definition
+	if err := json.Unmarshal(b, &defs); err != nil {
+		return nil, fmt.Errorf("parsing metric definitions: %w", err)
+	}
+	return defs, nil
+}
+
+// Metric is a single metric family
+type Metric struct {
+	def        Definition
+	labelNames []string
+
+	mu      sync.Mutex
+	series  map[string]*series
+	dropped uint64 // observations of new label sets beyond the series limit
+}
+
+type series struct {
+	labelValues []string
+	value       float64  // counter / gauge value, histogram sum
+	count       uint64   // histogram
+	buckets     []uint64 // histogram, not cumulative
+}
+
+// Definition returns the definition of the metric
+func (m *Metric) Definition() Definition {
+	return m.def
+}
+
+// Dropped returns how many observations have been dropped because of the series limit
+func (m *Metric) Dropped() uint64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.dropped
+}
+
+// Observe records a value for the given label values (in the order of the definition's labels).
+// Counters add the value, gauges are set to it and histograms record it. Values of new label sets
+// are dropped once the metric has as many series as its definition allows.
+func (m *Metric) Observe(labelValues []string, value float64) {
+	if math.IsNaN(value) {
+		return
+	}
+	key := strings.Join(labelValues, "\xff")
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.series[key]
+	if !ok {
+		if len(m.series) >= m.def.MaxSeries {
+			m.dropped++
+			return
+		}
+		s = &series{labelValues: slices.Clone(labelValues)}
+		if m.def.Type == TypeHistogram {
+			s.buckets = make([]uint64, len(m.def.Buckets))
+		}
+		m.series[key] = s
+	}
+
+	switch m.def.Type {
+	case TypeCounter:
+		if value > 0 {
+			s.value += value
+		}
+	case TypeGauge:
+		s.value = value
+	case TypeHistogram:
+		s.value += value
+		s.count++
+		if i, _ := slices.BinarySearch(m.def.Buckets, value); i < len(s.buckets) {
+			s.buckets[i]++
+		}
+	}
+}
+
+// Registry holds all metrics
+type Registry struct {
+	metrics []*Metric
+}
+
+// NewRegistry creates a registry with a metric for each definition
+func NewRegistry(defs []Definition) (*Registry, error) {
+	r := &Registry{}
+	seen := make(map[string]bool, len(defs))
+	for _, def := range defs {
+		if err := def.Validate(); err != nil {
+			return nil, err
+		}
+		if seen[def.Name] {
+			return nil, fmt.Errorf("duplicate metric %q", def.Name)
+		}
+		seen[def.Name] = true
+
+		labelNames := make([]string, 0, len(def.Labels))
+		for _, l := range def.Labels {
+			labelNames = append(labelNames, LabelName(l))
+		}
+		r.metrics = append(r.metrics, &Metric{
+			def:        def,
+			labelNames: labelNames,
+			series:     make(map[string]*series),
+		})
+	}
+	return r, nil
+}
+
+// Metrics returns all metrics of the registry
+func (r *Registry) Metrics() []*Metric {
+	return r.metrics
+}
+
+func escapeLabelValue(v string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(v)
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// labels renders the label set, with an optional extra label appended
+func labels(names []string, values []string, extra ...string) string {
+	if len(names) == 0 && len(extra) == 0 {
+		return ""
+	}
+	parts := make([]string, 0, len(names)+1)
+	for i, name := range names {
+		parts = append(parts, fmt.Sprintf(`%s="%s"`, name, escapeLabelValue(values[i])))
+	}
+	if len(extra) == 2 {
+		parts = append(parts, fmt.Sprintf(`%s="%s"`, extra[0], escapeLabelValue(extra[1])))
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+// WriteOpenMetrics writes all metrics in the OpenMetrics text format
+func (r *Registry) WriteOpenMetrics(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	for _, m := range r.metrics {
+		name := m.def.Name
+		fmt.Fprintf(bw, "# TYPE %s %s\n", name, m.def.Type)
+		if m.def.Help != "" {
+			fmt.Fprintf(bw, "# HELP %s %s\n", name, strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(m.def.Help))
+		}
+
+		m.mu.Lock()
+		keys := make([]string, 0, len(m.series))
+		for k := range m.series {
+			keys = append(keys, k)
+		}
+		slices.Sort(keys)
+		for _, k := range keys {
+			s := m.series[k]
+			switch m.def.Type {
+			case TypeCounter:
+				fmt.Fprintf(bw, "%s_total%s %s\n", name, labels(m.labelNames, s.labelValues), formatFloat(s.value))
+			case TypeGauge:
+				fmt.Fprintf(bw, "%s%s %s\n", name, labels(m.labelNames, s.labelValues), formatFloat(s.value))
+			case TypeHistogram:
+				var cumulative uint64
+				for i, le := range m.def.Buckets {
+					cumulative += s.buckets[i]
+					fmt.Fprintf(bw, "%s_bucket%s %d\n", name, labels(m.labelNames, s.labelValues, "le", formatFloat(le)), cumulative)
+				}
+				fmt.Fprintf(bw, "%s_bucket%s %d\n", name, labels(m.labelNames, s.labelValues, "le", "+Inf"), s.count)
+				fmt.Fprintf(bw, "%s_count%s %d\n", name, labels(m.labelNames, s.labelValues), s.count)
+				fmt.Fprintf(bw, "%s_sum%s %s\n", name, labels(m.labelNames, s.labelValues), formatFloat(s.value))
+			}
+		}
+		m.mu.Unlock()
+	}
+
+	fmt.Fprintf(bw, "# TYPE %s counter\n", DroppedSeriesName)
+	fmt.Fprintf(bw, "# HELP %s Observations dropped because the metric reached its series limit\n", DroppedSeriesName)
+	for _, m := range r.metrics {
+		fmt.Fprintf(bw, "%s_total%s %d\n", DroppedSeriesName, labels([]string{"metric"}, []string{m.def.Name}), m.Dropped())
+	}
+	fmt.Fprint(bw, "# EOF\n")
+	return bw.Flush()
+}
+
+// ServeHTTP exposes the metrics, e.g. as /metrics
+func (r *Registry) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", ContentType)
+	if err := r.WriteOpenMetrics(w); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,116 @@
+// Copyright 2026 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWriteOpenMetrics(t *testing.T) {
+	r, err := NewRegistry([]Definition{
+		{Name: "exec_events_total", Type: TypeCounter, Labels: []string{"k8s.namespace", "proc.comm"}},
+		{Name: "dns_latency_seconds", Type: TypeHistogram, Field: "latency", Buckets: []float64{0.01, 0.1}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	counter, histogram := r.Metrics()[0], r.Metrics()[1]
+	counter.Observe([]string{"default", "sh"}, 1)
+	counter.Observe([]string{"default", "sh"}, 1)
+	counter.Observe([]string{"kube-system", `a"b`}, 1)
+	histogram.Observe(nil, 0.005)
+	histogram.Observe(nil, 0.05)
+	histogram.Observe(nil, 1)
+
+	var sb strings.Builder
+	if err := r.WriteOpenMetrics(&sb); err != nil {
+		t.Fatal(err)
+	}
+	expected := `# TYPE exec_events counter
+exec_events_total{k8s_namespace="default",proc_comm="sh"} 2
+exec_events_total{k8s_namespace="kube-system",proc_comm="a\"b"} 1
+# TYPE dns_latency_seconds histogram
+dns_latency_seconds_bucket{le="0.01"} 1
+dns_latency_seconds_bucket{le="0.1"} 2
+dns_latency_seconds_bucket{le="+Inf"} 3
+dns_latency_seconds_count 3
+dns_latency_seconds_sum 1.055
+# TYPE ig_metrics_dropped_series counter
+# HELP ig_metrics_dropped_series Observations dropped because the metric reached its series limit
+ig_metrics_dropped_series_total{metric="exec_events"} 0
+ig_metrics_dropped_series_total{metric="dns_latency_seconds"} 0
+# EOF
+`
+	if sb.String() != expected {
+		t.Errorf("unexpected output:\n%s", sb.String())
+	}
+}
+
+func TestDefinitionValidate(t *testing.T) {
+	for _, def := range []Definition{
+		{Name: "1abc", Type: TypeCounter},
+		{Name: "g", Type: TypeGauge},
+		{Name: "h", Type: TypeHistogram, Field: "f", Buckets: []float64{2, 1}},
+		{Name: "x", Type: "summary"},
+		{Name: "c", Type: TypeCounter, MaxSeries: -1},
+		{Name: DroppedSeriesName, Type: TypeCounter},
+	} {
+		if err := def.Validate(); err == nil {
+			t.Errorf("expected %+v to be invalid", def)
+		}
+	}
+}
+
+func TestMaxSeries(t *testing.T) {
+	r, err := NewRegistry([]Definition{
+		{Name: "exec_events", Type: TypeCounter, Labels: []string{"proc.pid"}, MaxSeries: 2},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := r.Metrics()[0]
+	m.Observe([]string{"1"}, 1)
+	m.Observe([]string{"2"}, 1)
+	m.Observe([]string{"3"}, 1)
+	m.Observe([]string{"4"}, 1)
+	// Known label sets are still updated
+	m.Observe([]string{"1"}, 1)
+
+	if dropped := m.Dropped(); dropped != 2 {
+		t.Errorf("expected 2 dropped observations, got %d", dropped)
+	}
+
+	var sb strings.Builder
+	if err := r.WriteOpenMetrics(&sb); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{
+		`exec_events_total{proc_pid="1"} 2`,
+		`exec_events_total{proc_pid="2"} 1`,
+		`ig_metrics_dropped_series_total{metric="exec_events"} 2`,
+	} {
+		if !strings.Contains(sb.String(), line+"\n") {
+			t.Errorf("expected %q in output:\n%s", line, sb.String())
+		}
+	}
+	if strings.Contains(sb.String(), `proc_pid="3"`) {
+		t.Errorf("expected series beyond the limit to be dropped:\n%s", sb.String())
+	}
+
+	if def := (Definition{Name: "c", Type: TypeCounter}); def.Validate() != nil || def.MaxSeries != DefaultMaxSeries {
+		t.Errorf("expected default series limit")
+	}
+}